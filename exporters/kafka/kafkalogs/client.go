@@ -0,0 +1,190 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkalogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+var _ otlplogs.Client = &Client{}
+
+var (
+	errNoProducer = errors.New("no Kafka producer, use WithProducer")
+	errNotStarted = errors.New("kafka client not started")
+)
+
+// Client is an otlplogs.Client producing each batch of logs as a Kafka
+// message, or as one message per key with WithKey.
+type Client struct {
+	cfg config
+
+	mu       sync.RWMutex
+	producer Producer
+}
+
+// NewClient creates a Client configured with options.
+func NewClient(options ...Option) *Client {
+	return &Client{cfg: newConfig(options...)}
+}
+
+// Start creates the producer with the factory set with WithProducer.
+func (c *Client) Start(ctx context.Context) error {
+	if c.cfg.newProducer == nil {
+		return errNoProducer
+	}
+	producer, err := c.cfg.newProducer(c.cfg.brokers)
+	if err != nil {
+		return fmt.Errorf("create Kafka producer: %w", err)
+	}
+	c.mu.Lock()
+	c.producer = producer
+	c.mu.Unlock()
+	return nil
+}
+
+// Stop flushes the messages queued by the producer within ctx, then closes
+// it. It returns the joined errors of both.
+func (c *Client) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	producer := c.producer
+	c.producer = nil
+	c.mu.Unlock()
+	if producer == nil {
+		return nil
+	}
+	return errors.Join(producer.Flush(ctx), producer.Close())
+}
+
+// UploadLogs produces protoLogs as an ExportLogsServiceRequest encoded as
+// protobuf to the configured topic. With WithKey, the logs are produced as one
+// message per key, in the order of the first log with each key.
+func (c *Client) UploadLogs(ctx context.Context, protoLogs []*logspb.ResourceLogs) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.producer == nil {
+		return errNotStarted
+	}
+	if c.cfg.key == nil {
+		return c.produce(ctx, nil, protoLogs)
+	}
+	var errs []error
+	for _, kl := range c.keyedLogs(protoLogs) {
+		if err := c.produce(ctx, kl.key, kl.resourceLogs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// produce produces protoLogs as a message with the key.
+func (c *Client) produce(ctx context.Context, key []byte, protoLogs []*logspb.ResourceLogs) error {
+	value, err := proto.Marshal(&collogspb.ExportLogsServiceRequest{ResourceLogs: protoLogs})
+	if err != nil {
+		return err
+	}
+	return c.producer.Produce(ctx, Message{Topic: c.cfg.topic, Key: key, Value: value})
+}
+
+// keyedLogs are the logs of an upload with the same key.
+type keyedLogs struct {
+	key          []byte
+	resourceLogs []*logspb.ResourceLogs
+	// rl and sl are the resource and scope logs of protoLogs the last log
+	// added comes from.
+	rl *logspb.ResourceLogs
+	sl *logspb.ScopeLogs
+}
+
+// add adds lr, a log of the scope logs sl of the resource logs rl.
+func (kl *keyedLogs) add(rl *logspb.ResourceLogs, sl *logspb.ScopeLogs, lr *logspb.LogRecord) {
+	if kl.rl != rl {
+		kl.rl, kl.sl = rl, nil
+		kl.resourceLogs = append(kl.resourceLogs, &logspb.ResourceLogs{Resource: rl.GetResource(), SchemaUrl: rl.GetSchemaUrl()})
+	}
+	last := kl.resourceLogs[len(kl.resourceLogs)-1]
+	if kl.sl != sl {
+		kl.sl = sl
+		last.ScopeLogs = append(last.ScopeLogs, &logspb.ScopeLogs{Scope: sl.GetScope(), SchemaUrl: sl.GetSchemaUrl()})
+	}
+	scope := last.ScopeLogs[len(last.ScopeLogs)-1]
+	scope.LogRecords = append(scope.LogRecords, lr)
+}
+
+// keyedLogs splits protoLogs by the key of each of their logs.
+func (c *Client) keyedLogs(protoLogs []*logspb.ResourceLogs) []*keyedLogs {
+	var all []*keyedLogs
+	byKey := make(map[string]*keyedLogs)
+	for _, rl := range protoLogs {
+		for _, sl := range rl.GetScopeLogs() {
+			keys := c.scopeKeys(rl, sl)
+			for i, lr := range sl.GetLogRecords() {
+				key := keys[i]
+				kl, ok := byKey[string(key)]
+				if !ok {
+					kl = &keyedLogs{key: key}
+					byKey[string(key)] = kl
+					all = append(all, kl)
+				}
+				kl.add(rl, sl, lr)
+			}
+		}
+	}
+	return all
+}
+
+// scopeKeys returns the keys of the logs of the scope logs sl of the resource
+// logs rl. The logs are decoded together, or one by one if one of them cannot
+// be decoded so that only that one has no key.
+func (c *Client) scopeKeys(rl *logspb.ResourceLogs, sl *logspb.ScopeLogs) [][]byte {
+	logRecords := sl.GetLogRecords()
+	keys := make([][]byte, len(logRecords))
+	records, err := otlplogs.FromProto(scopeResourceLogs(rl, sl, logRecords))
+	if err == nil && len(records) == len(logRecords) {
+		for i, record := range records {
+			keys[i] = c.cfg.key(record)
+		}
+		return keys
+	}
+	for i, lr := range logRecords {
+		records, err := otlplogs.FromProto(scopeResourceLogs(rl, sl, []*logspb.LogRecord{lr}))
+		if err == nil && len(records) == 1 {
+			keys[i] = c.cfg.key(records[0])
+		}
+	}
+	return keys
+}
+
+// scopeResourceLogs returns the resource logs with only logRecords of the
+// scope logs sl of the resource logs rl.
+func scopeResourceLogs(rl *logspb.ResourceLogs, sl *logspb.ScopeLogs, logRecords []*logspb.LogRecord) []*logspb.ResourceLogs {
+	return []*logspb.ResourceLogs{{
+		Resource:  rl.GetResource(),
+		SchemaUrl: rl.GetSchemaUrl(),
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope:      sl.GetScope(),
+			SchemaUrl:  sl.GetSchemaUrl(),
+			LogRecords: logRecords,
+		}},
+	}}
+}
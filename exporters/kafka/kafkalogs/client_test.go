@@ -0,0 +1,249 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkalogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	sdklogs "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"testing"
+)
+
+// mockProducer queues the produced messages until they are flushed.
+type mockProducer struct {
+	brokers    []string
+	produceErr error
+
+	mu       sync.Mutex
+	queued   []Message
+	produced []Message
+	closed   bool
+}
+
+func (p *mockProducer) Produce(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.produceErr != nil {
+		return p.produceErr
+	}
+	p.queued = append(p.queued, msg)
+	return nil
+}
+
+func (p *mockProducer) Flush(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.produced = append(p.produced, p.queued...)
+	p.queued = nil
+	return nil
+}
+
+func (p *mockProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *mockProducer) messages() (queued, produced []Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.queued...), append([]Message(nil), p.produced...)
+}
+
+// withMockProducer returns the option creating p as the producer.
+func withMockProducer(p *mockProducer) Option {
+	return WithProducer(func(brokers []string) (Producer, error) {
+		p.brokers = brokers
+		return p, nil
+	})
+}
+
+func serviceKey(r sdklogs.ReadableLogRecord) []byte {
+	name, ok := r.Resource().Set().Value("service.name")
+	if !ok {
+		return nil
+	}
+	return []byte(name.AsString())
+}
+
+func TestClient(t *testing.T) {
+	p := &mockProducer{}
+	client := NewClient(
+		withMockProducer(p),
+		WithBrokers("kafka-1:9092", "kafka-2:9092"),
+		WithTopic("logs"),
+		WithKey(serviceKey),
+	)
+	ctx := context.Background()
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(client))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, p.brokers)
+
+	body := "user logged in"
+	records := logstest.LogRecordStubs{{
+		Body:     body,
+		Resource: resource.NewSchemaless(attribute.String("service.name", "auth")),
+	}}.Snapshots()
+	require.NoError(t, exp.Export(ctx, records))
+	require.NoError(t, exp.Export(ctx, records))
+
+	queued, produced := p.messages()
+	require.Len(t, queued, 2)
+	assert.Empty(t, produced)
+	msg := queued[0]
+	assert.Equal(t, "logs", msg.Topic)
+	assert.Equal(t, []byte("auth"), msg.Key)
+	var req collogspb.ExportLogsServiceRequest
+	require.NoError(t, proto.Unmarshal(msg.Value, &req))
+	lrs := req.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()
+	require.Len(t, lrs, 1)
+	assert.Equal(t, body, lrs[0].GetBody().GetStringValue())
+
+	require.NoError(t, exp.Shutdown(ctx))
+	queued, produced = p.messages()
+	assert.Empty(t, queued)
+	assert.Len(t, produced, 2, "shutdown flushes the producer")
+	assert.True(t, p.closed)
+}
+
+func TestClientKeyPerRecord(t *testing.T) {
+	p := &mockProducer{}
+	client := NewClient(withMockProducer(p), WithKey(func(r sdklogs.ReadableLogRecord) []byte {
+		for _, kv := range *r.Attributes() {
+			if kv.Key == "tenant" {
+				return []byte(kv.Value.AsString())
+			}
+		}
+		return nil
+	}))
+	ctx := context.Background()
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(client))
+	require.NoError(t, err)
+
+	res := resource.NewSchemaless(attribute.String("service.name", "auth"))
+	stub := func(body, tenant string) logstest.LogRecordStub {
+		attrs := []attribute.KeyValue{attribute.String("tenant", tenant)}
+		return logstest.LogRecordStub{Body: body, Resource: res, Attributes: &attrs}
+	}
+	records := logstest.LogRecordStubs{
+		stub("first", "acme"),
+		stub("second", "globex"),
+		stub("third", "acme"),
+	}.Snapshots()
+	require.NoError(t, exp.Export(ctx, records))
+
+	queued, _ := p.messages()
+	require.Len(t, queued, 2, "one message per key")
+	bodies := func(msg Message) []string {
+		var req collogspb.ExportLogsServiceRequest
+		require.NoError(t, proto.Unmarshal(msg.Value, &req))
+		var got []string
+		for _, rl := range req.GetResourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, lr := range sl.GetLogRecords() {
+					got = append(got, lr.GetBody().GetStringValue())
+				}
+			}
+		}
+		return got
+	}
+	assert.Equal(t, []byte("acme"), queued[0].Key)
+	assert.Equal(t, []string{"first", "third"}, bodies(queued[0]))
+	assert.Equal(t, []byte("globex"), queued[1].Key)
+	assert.Equal(t, []string{"second"}, bodies(queued[1]))
+	require.NoError(t, exp.Shutdown(ctx))
+}
+
+func TestClientKeyUndecodedRecord(t *testing.T) {
+	p := &mockProducer{}
+	client := NewClient(withMockProducer(p), WithKey(func(r sdklogs.ReadableLogRecord) []byte {
+		return []byte(fmt.Sprint(r.Body()))
+	}))
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+
+	body := func(s string) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+	}
+	protoLogs := []*logspb.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{
+			LogRecords: []*logspb.LogRecord{
+				{Body: body("acme")},
+				{Body: body("globex"), TraceId: []byte{1}},
+				{Body: body("acme")},
+			},
+		}},
+	}}
+	require.NoError(t, client.UploadLogs(ctx, protoLogs))
+
+	queued, _ := p.messages()
+	require.Len(t, queued, 2, "one message per key")
+	assert.Equal(t, []byte("acme"), queued[0].Key)
+	assert.Nil(t, queued[1].Key, "the record failing to decode has no key")
+	require.NoError(t, client.Stop(ctx))
+}
+
+func TestClientDefaults(t *testing.T) {
+	p := &mockProducer{}
+	client := NewClient(withMockProducer(p))
+	require.NoError(t, client.Start(context.Background()))
+	assert.Equal(t, []string{DefaultBroker}, p.brokers)
+
+	require.NoError(t, client.UploadLogs(context.Background(), nil))
+	queued, _ := p.messages()
+	require.Len(t, queued, 1)
+	assert.Equal(t, DefaultTopic, queued[0].Topic)
+	assert.Nil(t, queued[0].Key)
+	require.NoError(t, client.Stop(context.Background()))
+}
+
+func TestClientNoProducer(t *testing.T) {
+	_, err := otlplogs.NewExporter(context.Background(), otlplogs.WithClient(NewClient()))
+	assert.ErrorIs(t, err, errNoProducer)
+}
+
+func TestClientProducerError(t *testing.T) {
+	factoryErr := errors.New("no broker available")
+	client := NewClient(WithProducer(func([]string) (Producer, error) { return nil, factoryErr }))
+	assert.ErrorIs(t, client.Start(context.Background()), factoryErr)
+
+	produceErr := errors.New("message too large")
+	p := &mockProducer{produceErr: produceErr}
+	client = NewClient(withMockProducer(p))
+	require.NoError(t, client.Start(context.Background()))
+	assert.ErrorIs(t, client.UploadLogs(context.Background(), nil), produceErr)
+	require.NoError(t, client.Stop(context.Background()))
+}
+
+func TestClientNotStarted(t *testing.T) {
+	client := NewClient(withMockProducer(&mockProducer{}))
+	assert.ErrorIs(t, client.UploadLogs(context.Background(), nil), errNotStarted)
+	assert.NoError(t, client.Stop(context.Background()))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafkalogs
+
+import (
+	"context"
+	sdklogs "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+)
+
+const (
+	// DefaultTopic is the topic the logs are produced to by default, the one
+	// consumed by default by the Kafka receiver of the collector.
+	DefaultTopic = "otlp_logs"
+	// DefaultBroker is the broker connected to by default.
+	DefaultBroker = "localhost:9092"
+)
+
+// Message is a Kafka message holding a batch of logs.
+type Message struct {
+	// Topic is the topic the message is produced to.
+	Topic string
+	// Key is the key of the message, nil for none.
+	Key []byte
+	// Value is the ExportLogsServiceRequest of the batch encoded as
+	// protobuf.
+	Value []byte
+}
+
+// Producer produces messages to Kafka. It is implemented by an adapter of a
+// Kafka client library.
+type Producer interface {
+	// Produce produces msg, or queues it for production. The messages of an
+	// upload are produced one after the other, but the uploads of concurrent
+	// exports may call it concurrently.
+	Produce(ctx context.Context, msg Message) error
+	// Flush waits for the queued messages to be produced.
+	Flush(ctx context.Context) error
+	// Close releases the resources of the producer. It is called once, after
+	// Flush, when the exporter shuts down.
+	Close() error
+}
+
+// config contains the options of the client.
+type config struct {
+	brokers     []string
+	topic       string
+	key         func(sdklogs.ReadableLogRecord) []byte
+	newProducer func(brokers []string) (Producer, error)
+}
+
+func newConfig(options ...Option) config {
+	cfg := config{
+		brokers: []string{DefaultBroker},
+		topic:   DefaultTopic,
+	}
+	for _, opt := range options {
+		cfg = opt.apply(cfg)
+	}
+	return cfg
+}
+
+// Option sets the value of an option for a client.
+type Option interface {
+	apply(config) config
+}
+
+type optionFunc func(config) config
+
+func (fn optionFunc) apply(cfg config) config {
+	return fn(cfg)
+}
+
+// WithBrokers sets the addresses of the Kafka brokers passed to the producer
+// factory, DefaultBroker by default.
+func WithBrokers(brokers ...string) Option {
+	return optionFunc(func(cfg config) config {
+		cfg.brokers = append([]string(nil), brokers...)
+		return cfg
+	})
+}
+
+// WithTopic sets the topic the logs are produced to, DefaultTopic by default.
+func WithTopic(topic string) Option {
+	return optionFunc(func(cfg config) config {
+		cfg.topic = topic
+		return cfg
+	})
+}
+
+// WithKey sets the function returning the key of the message of each log
+// record, e.g. the name of its service for the logs of a service to be
+// consumed in order. The logs of a batch are produced as one message per key.
+// key is called with the records decoded from their OTLP encoding, see
+// otlplogs.FromProto, which costs a conversion of each batch on top of the
+// encoding of its messages; the ones failing to decode have no key. The messages
+// have no key by default, spreading them over the partitions of the topic.
+func WithKey(key func(sdklogs.ReadableLogRecord) []byte) Option {
+	return optionFunc(func(cfg config) config {
+		cfg.key = key
+		return cfg
+	})
+}
+
+// WithProducer sets the factory creating the Producer connected to brokers when
+// the exporter starts. It is required, the exporter fails to start without it.
+func WithProducer(newProducer func(brokers []string) (Producer, error)) Option {
+	return optionFunc(func(cfg config) config {
+		cfg.newProducer = newProducer
+		return cfg
+	})
+}
@@ -0,0 +1,24 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kafkalogs provides an OTLP client producing the exported logs to a
+// Kafka topic, for a collector consuming them with its Kafka receiver. It is
+// used with otlplogs.NewExporter and otlplogs.WithClient.
+//
+// The package does not depend on a Kafka library: the messages are produced
+// by a Producer, an adapter of the Kafka client of the application, created
+// when the exporter starts by the factory set with WithProducer.
+package kafkalogs // Package kafkalogs import github.com/metoro-io/opentelemetry-logs-go/exporters/kafka/kafkalogs
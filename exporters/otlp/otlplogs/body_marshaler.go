@@ -0,0 +1,45 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogs
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/logstransform"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// BodyMarshaler converts the body of log records to the OTLP AnyValue sent
+// to the endpoint, e.g. to flatten nested maps for a backend not supporting
+// them. It is set with WithBodyMarshaler.
+type BodyMarshaler interface {
+	// MarshalBody returns the AnyValue of body, nil for no body. It is called
+	// concurrently for every exported log record.
+	MarshalBody(body any) *commonpb.AnyValue
+}
+
+// BodyMarshalerFunc is a function implementing BodyMarshaler.
+type BodyMarshalerFunc func(body any) *commonpb.AnyValue
+
+// MarshalBody calls fn(body).
+func (fn BodyMarshalerFunc) MarshalBody(body any) *commonpb.AnyValue {
+	return fn(body)
+}
+
+// DefaultBodyMarshaler is the BodyMarshaler used by default. Strings,
+// numbers, booleans and byte slices are converted to the matching AnyValue,
+// maps to a key-value list, slices and arrays to an array and structs to a
+// key-value list of their exported fields.
+var DefaultBodyMarshaler BodyMarshaler = BodyMarshalerFunc(logstransform.BodyToAnyValue)
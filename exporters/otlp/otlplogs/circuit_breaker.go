@@ -0,0 +1,98 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var errCircuitOpen = errors.New("circuit breaker open, export skipped")
+
+// circuitBreaker fast-fails the exports after consecutive failures, see
+// WithCircuitBreaker. A nil *circuitBreaker runs every export.
+type circuitBreaker struct {
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu sync.Mutex
+	// consecutive is the number of consecutive failed exports.
+	consecutive int
+	// openUntil is when an open circuit half-opens, zero if it is closed.
+	openUntil time.Time
+	// probing is true while the export testing the recovery of a
+	// half-open circuit runs.
+	probing bool
+}
+
+// newCircuitBreaker returns a circuitBreaker opening after failures
+// consecutive failures for cooldown, or nil if failures is not positive.
+func newCircuitBreaker(failures int, cooldown time.Duration) *circuitBreaker {
+	if failures <= 0 {
+		return nil
+	}
+	return &circuitBreaker{failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+// run calls export unless the circuit is open, in which case it returns
+// errCircuitOpen, and records its result.
+func (cb *circuitBreaker) run(export func() error) error {
+	if cb == nil {
+		return export()
+	}
+	if !cb.allow() {
+		return errCircuitOpen
+	}
+	err := export()
+	cb.record(err)
+	return err
+}
+
+// allow returns true if the circuit is closed, or if it is half-open and no
+// other export tests the recovery.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.openUntil.IsZero() {
+		return true
+	}
+	if cb.probing || cb.now().Before(cb.openUntil) {
+		return false
+	}
+	cb.probing = true
+	return true
+}
+
+// record closes the circuit after a successful export and opens it after the
+// last allowed failure or a failure testing the recovery.
+func (cb *circuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	probe := cb.probing
+	cb.probing = false
+	if err == nil {
+		cb.consecutive = 0
+		cb.openUntil = time.Time{}
+		return
+	}
+	cb.consecutive++
+	if probe || cb.consecutive >= cb.failures {
+		cb.openUntil = cb.now().Add(cb.cooldown)
+	}
+}
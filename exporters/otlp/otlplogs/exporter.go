@@ -19,17 +19,59 @@ package otlplogs
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/logstransform"
 	logssdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"go.opentelemetry.io/otel"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
 	"sync"
+	"time"
 )
 
+// maxRejectedRetries is the maximum number of times the log records rejected
+// by the endpoint are uploaded again, see WithRetryRejectedRecords.
+const maxRejectedRetries = 3
+
 var (
-	errAlreadyStarted = errors.New("already started")
+	errAlreadyStarted  = errors.New("already started")
+	errPayloadTooLarge = errors.New("log record exceeds the maximum payload size")
 )
 
+// ExportResult is the result of the export of a batch of logs, passed to the
+// callback set with WithExportCallback.
+type ExportResult struct {
+	// RecordCount is the number of log records exported in the batch.
+	RecordCount int
+	// Duration is the time taken by the export, including retries.
+	Duration time.Duration
+	// Err is the error of the export, nil on success.
+	Err error
+	// PartialSuccess is true if the endpoint rejected part of the batch.
+	PartialSuccess bool
+	// RejectedRecords is the number of log records rejected by the endpoint
+	// on a partial success.
+	RejectedRecords int64
+	// SkippedRecords is the number of log records not exported because they
+	// cannot be marshaled.
+	SkippedRecords int
+}
+
 type Exporter struct {
-	client Client
+	client         Client
+	exportCallback func(ExportResult)
+	bodyMarshaler  BodyMarshaler
+	// transform configures the transformation of the records to OTLP.
+	transform logstransform.Options
+	// maxPayloadSize is the maximum encoded size in bytes of an export
+	// request, 0 for no limit.
+	maxPayloadSize int
+	// breaker is nil unless the exports go through a circuit breaker.
+	breaker *circuitBreaker
+	// retryRejected uploads again the records rejected in a partial success.
+	retryRejected bool
 
 	mu      sync.RWMutex
 	started bool
@@ -51,6 +93,17 @@ func (e *Exporter) Start(ctx context.Context) error {
 	return err
 }
 
+// Connect triggers the connection of the client to the receiving endpoint,
+// e.g. to warm it up after a readiness gate, if the client supports it. A gRPC
+// client waits for the connection to be ready within ctx and returns an error
+// otherwise. It does nothing for the other clients.
+func (e *Exporter) Connect(ctx context.Context) error {
+	if c, ok := e.client.(interface{ Connect(context.Context) error }); ok {
+		return c.Connect(ctx)
+	}
+	return nil
+}
+
 func (e *Exporter) Shutdown(ctx context.Context) error {
 	e.mu.RLock()
 	started := e.started
@@ -73,17 +126,97 @@ func (e *Exporter) Shutdown(ctx context.Context) error {
 }
 
 // Export exports a batch of logs.
+//
+// The logs which cannot be marshaled, e.g. holding a string which is not
+// valid UTF-8, are not exported, their errors are reported to the global error
+// handler and the other logs are still exported.
 func (e *Exporter) Export(ctx context.Context, ll []logssdk.ReadableLogRecord) error {
-	protoLogs := logstransform.Logs(ll)
+	if len(ll) == 0 {
+		return nil
+	}
+	transform := e.transform
+	if e.exportCallback == nil {
+		transform.OnInvalid = otel.Handle
+		return e.breaker.run(func() error { return e.export(ctx, ll, transform) })
+	}
+
+	result := ExportResult{RecordCount: len(ll)}
+	ctx = internal.ContextWithPartialSuccessHandler(ctx, func(ps internal.PartialSuccess) {
+		result.PartialSuccess = true
+		result.RejectedRecords += ps.RejectedItems
+	})
+	transform.OnInvalid = func(err error) {
+		result.SkippedRecords++
+		otel.Handle(err)
+	}
+	start := time.Now()
+	result.Err = e.breaker.run(func() error { return e.export(ctx, ll, transform) })
+	result.Duration = time.Since(start)
+	e.exportCallback(result)
+	return result.Err
+}
+
+// export uploads ll transformed with transform, split in as many requests as
+// needed to keep each of them within maxPayloadSize by recursively halving it.
+// The records bigger than maxPayloadSize alone are dropped with an error, the
+// others are still uploaded.
+func (e *Exporter) export(ctx context.Context, ll []logssdk.ReadableLogRecord, transform logstransform.Options) error {
+	protoLogs := logstransform.LogsWithOptions(ll, transform)
 	if len(protoLogs) == 0 {
 		return nil
 	}
+	if e.maxPayloadSize <= 0 {
+		return e.upload(ctx, ll, protoLogs, transform)
+	}
 
-	err := e.client.UploadLogs(ctx, protoLogs)
-	if err != nil {
-		return err
+	size := payloadSize(protoLogs)
+	if size <= e.maxPayloadSize {
+		return e.upload(ctx, ll, protoLogs, transform)
 	}
-	return nil
+	if len(ll) == 1 {
+		return fmt.Errorf("%w: %d bytes, maximum %d bytes", errPayloadTooLarge, size, e.maxPayloadSize)
+	}
+	// The invalid records were reported by the transformation of ll.
+	transform.OnInvalid = nil
+	half := len(ll) / 2
+	return errors.Join(e.export(ctx, ll[:half], transform), e.export(ctx, ll[half:], transform))
+}
+
+// upload uploads protoLogs, the transformation of ll with transform. If the
+// rejected records are retried, the tail of ll as long as the number of
+// records rejected by the endpoint is transformed and uploaded again, until
+// none is rejected or after maxRejectedRetries retries.
+func (e *Exporter) upload(ctx context.Context, ll []logssdk.ReadableLogRecord, protoLogs []*logspb.ResourceLogs, transform logstransform.Options) error {
+	if !e.retryRejected {
+		return e.client.UploadLogs(ctx, protoLogs)
+	}
+	var rejected int64
+	ctx = internal.ContextWithPartialSuccessHandler(ctx, func(ps internal.PartialSuccess) {
+		rejected += ps.RejectedItems
+	})
+	// The invalid records were reported by the first transformation of ll.
+	transform.OnInvalid = nil
+	for retry := 0; ; retry++ {
+		rejected = 0
+		if err := e.client.UploadLogs(ctx, protoLogs); err != nil {
+			return err
+		}
+		if rejected <= 0 || retry == maxRejectedRetries {
+			return nil
+		}
+		if rejected < int64(len(ll)) {
+			ll = ll[len(ll)-int(rejected):]
+		}
+		protoLogs = logstransform.LogsWithOptions(ll, transform)
+		if len(protoLogs) == 0 {
+			return nil
+		}
+	}
+}
+
+// payloadSize returns the encoded size of the export request of protoLogs.
+func payloadSize(protoLogs []*logspb.ResourceLogs) int {
+	return proto.Size(&collogspb.ExportLogsServiceRequest{ResourceLogs: protoLogs})
 }
 
 // New creates new exporter with client
@@ -102,11 +235,65 @@ func NewExporter(ctx context.Context, options ...ExporterOption) (*Exporter, err
 	}
 
 	exp := &Exporter{
-		client: config.client,
+		client:         config.client,
+		exportCallback: config.exportCallback,
+		bodyMarshaler:  config.bodyMarshaler,
+		maxPayloadSize: config.maxPayloadSize,
+		breaker:        newCircuitBreaker(config.circuitBreakerFailures, config.circuitBreakerCooldown),
+		retryRejected:  config.retryRejectedRecords,
+	}
+	if exp.bodyMarshaler == nil {
+		exp.bodyMarshaler = DefaultBodyMarshaler
+	}
+	exp.transform = logstransform.Options{
+		Body:                         exp.bodyMarshaler.MarshalBody,
+		FlattenScopeAttributes:       config.flattenScopeAttributes,
+		DefaultTimestampFromObserved: config.defaultTimestampFromObserved,
+	}
+
+	if config.startTimeout <= 0 {
+		if err := exp.Start(ctx); err != nil {
+			return nil, err
+		}
+		exp.warmup(ctx, config.initialBatchTimeout)
+		return exp, nil
 	}
 
-	if err := exp.Start(ctx); err != nil {
-		return nil, err
+	startCtx, cancel := context.WithTimeout(ctx, config.startTimeout)
+	defer cancel()
+	// Start runs in its own goroutine so that clients not honoring ctx do not
+	// block NewExporter past the start timeout.
+	errCh := make(chan error, 1)
+	go func() { errCh <- exp.Start(startCtx) }()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return nil, err
+		}
+		exp.warmup(ctx, config.initialBatchTimeout)
+		return exp, nil
+	case <-startCtx.Done():
+		// The exporter is not returned, stop the client once it has started
+		// to release its resources.
+		go func() {
+			if <-errCh == nil {
+				_ = exp.Shutdown(context.Background())
+			}
+		}()
+		return nil, fmt.Errorf("exporter start: %w", startCtx.Err())
+	}
+}
+
+// warmup uploads an empty batch of logs within timeout, if positive, to set up
+// the connection to the endpoint before the first export. Its error is
+// reported to the global error handler, the exporter is usable anyway.
+func (e *Exporter) warmup(ctx context.Context, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := e.client.UploadLogs(ctx, []*logspb.ResourceLogs{}); err != nil {
+		otel.Handle(fmt.Errorf("exporter warmup: %w", err))
 	}
-	return exp, nil
 }
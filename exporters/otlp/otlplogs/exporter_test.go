@@ -3,23 +3,48 @@ package otlplogs_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogsgrpc"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogstest"
 	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
 )
 
 type client struct {
 	uploadErr error
+	// rejected is the number of records rejected in a partial success.
+	rejected int64
+	delay    time.Duration
+	// started, if set, is waited on by Start, ignoring its context.
+	started chan struct{}
+	// uploaded are the last uploaded logs.
+	uploaded []*logspb.ResourceLogs
+	// uploads are all the uploaded logs.
+	uploads [][]*logspb.ResourceLogs
 }
 
 var _ otlplogs.Client = &client{}
 
 func (c *client) Start(ctx context.Context) error {
+	if c.started != nil {
+		<-c.started
+	}
 	return nil
 }
 
@@ -28,6 +53,12 @@ func (c *client) Stop(ctx context.Context) error {
 }
 
 func (c *client) UploadLogs(ctx context.Context, protoLogs []*logspb.ResourceLogs) error {
+	time.Sleep(c.delay)
+	c.uploaded = protoLogs
+	c.uploads = append(c.uploads, protoLogs)
+	if c.rejected > 0 {
+		internal.HandlePartialSuccess(ctx, internal.LogRecordPartialSuccessError(c.rejected, "rejected"))
+	}
 	return c.uploadErr
 }
 
@@ -48,3 +79,496 @@ func TestExporterClientError(t *testing.T) {
 
 	assert.NoError(t, exp.Shutdown(ctx))
 }
+
+func TestExporterExportCallback(t *testing.T) {
+	body := "Log record"
+	logs := logstest.LogRecordStubs{{Body: &body}, {Body: &body}, {Body: &body}}.Snapshots()
+
+	tests := []struct {
+		name   string
+		client *client
+		want   otlplogs.ExportResult
+	}{
+		{
+			name:   "success",
+			client: &client{delay: 10 * time.Millisecond},
+			want:   otlplogs.ExportResult{RecordCount: 3},
+		},
+		{
+			name:   "failure",
+			client: &client{uploadErr: context.DeadlineExceeded},
+			want:   otlplogs.ExportResult{RecordCount: 3, Err: context.DeadlineExceeded},
+		},
+		{
+			name:   "partial success",
+			client: &client{rejected: 2},
+			want:   otlplogs.ExportResult{RecordCount: 3, PartialSuccess: true, RejectedRecords: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []otlplogs.ExportResult
+			ctx := context.Background()
+			exp, err := otlplogs.NewExporter(ctx,
+				otlplogs.WithClient(tt.client),
+				otlplogs.WithExportCallback(func(result otlplogs.ExportResult) {
+					results = append(results, result)
+				}),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, exp.Shutdown(ctx)) })
+
+			assert.ErrorIs(t, exp.Export(ctx, logs), tt.want.Err)
+			require.Len(t, results, 1)
+			got := results[0]
+			assert.GreaterOrEqual(t, got.Duration, tt.client.delay)
+			got.Duration = 0
+			assert.Equal(t, tt.want, got)
+
+			// Nothing is exported, the callback is not invoked.
+			require.NoError(t, exp.Export(ctx, nil))
+			assert.Len(t, results, 1)
+		})
+	}
+}
+
+func TestNewExporterStartTimeout(t *testing.T) {
+	c := &client{started: make(chan struct{})}
+	t.Cleanup(func() { close(c.started) })
+
+	start := time.Now()
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(c),
+		otlplogs.WithStartTimeout(50*time.Millisecond),
+	)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, exp)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewExporterStartTimeoutNotReached(t *testing.T) {
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(&client{}),
+		otlplogs.WithStartTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+	assert.NoError(t, exp.Shutdown(context.Background()))
+}
+
+// flattenBody flattens nested maps of the body in a single key-value list with
+// dot separated keys.
+func flattenBody(body any) *commonpb.AnyValue {
+	m, ok := body.(map[string]any)
+	if !ok {
+		return otlplogs.DefaultBodyMarshaler.MarshalBody(body)
+	}
+	var kvs []*commonpb.KeyValue
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if nested, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", nested)
+				continue
+			}
+			kvs = append(kvs, &commonpb.KeyValue{Key: prefix + k, Value: otlplogs.DefaultBodyMarshaler.MarshalBody(v)})
+		}
+	}
+	flatten("", m)
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvs}}}
+}
+
+func TestExporterBodyMarshaler(t *testing.T) {
+	body := map[string]any{
+		"msg": "request served",
+		"http": map[string]any{
+			"method":   "GET",
+			"response": map[string]any{"status": 200},
+		},
+	}
+	logs := logstest.LogRecordStubs{{Body: body}}.Snapshots()
+
+	tests := []struct {
+		name      string
+		marshaler otlplogs.BodyMarshaler
+		want      []string
+	}{
+		{name: "default", want: []string{"http", "msg"}},
+		{
+			name:      "flatten",
+			marshaler: otlplogs.BodyMarshalerFunc(flattenBody),
+			want:      []string{"http.method", "http.response.status", "msg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{}
+			opts := []otlplogs.ExporterOption{otlplogs.WithClient(c)}
+			if tt.marshaler != nil {
+				opts = append(opts, otlplogs.WithBodyMarshaler(tt.marshaler))
+			}
+			exp, err := otlplogs.NewExporter(context.Background(), opts...)
+			require.NoError(t, err)
+			require.NoError(t, exp.Export(context.Background(), logs))
+
+			require.Len(t, c.uploaded, 1)
+			kvs := c.uploaded[0].ScopeLogs[0].LogRecords[0].Body.GetKvlistValue().GetValues()
+			var keys []string
+			for _, kv := range kvs {
+				keys = append(keys, kv.Key)
+			}
+			sort.Strings(keys)
+			assert.Equal(t, tt.want, keys)
+			if tt.marshaler != nil {
+				assert.Equal(t, int64(200), kvs[1].Value.GetIntValue())
+			}
+		})
+	}
+}
+
+func TestExporterFlattenScopeAttributes(t *testing.T) {
+	logs := logstest.LogRecordStubs{{
+		InstrumentationScope: &instrumentation.Scope{Name: "github.com/example/pkg", Version: "v1.2.3"},
+	}}.Snapshots()
+
+	for _, flatten := range []bool{false, true} {
+		c := &client{}
+		opts := []otlplogs.ExporterOption{otlplogs.WithClient(c)}
+		if flatten {
+			opts = append(opts, otlplogs.WithFlattenScopeAttributes())
+		}
+		exp, err := otlplogs.NewExporter(context.Background(), opts...)
+		require.NoError(t, err)
+		require.NoError(t, exp.Export(context.Background(), logs))
+
+		require.Len(t, c.uploaded, 1)
+		sl := c.uploaded[0].ScopeLogs[0]
+		assert.Equal(t, "github.com/example/pkg", sl.Scope.GetName(), "the scope is kept")
+		var keys []string
+		for _, kv := range sl.LogRecords[0].Attributes {
+			keys = append(keys, kv.Key)
+		}
+		if flatten {
+			assert.Equal(t, []string{"otel.scope.name", "otel.scope.version"}, keys)
+		} else {
+			assert.Empty(t, keys)
+		}
+	}
+}
+
+func TestExporterMaxPayloadSize(t *testing.T) {
+	small := make([]string, 8)
+	for i := range small {
+		small[i] = fmt.Sprintf("record %d", i)
+	}
+	oversized := strings.Repeat("x", 2048)
+	var stubs logstest.LogRecordStubs
+	for i := range small {
+		stubs = append(stubs, logstest.LogRecordStub{Body: &small[i]})
+		if i == 4 {
+			stubs = append(stubs, logstest.LogRecordStub{Body: &oversized})
+		}
+	}
+	logs := stubs.Snapshots()
+
+	const maxPayloadSize = 200
+	c := &client{}
+	var results []otlplogs.ExportResult
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(c),
+		otlplogs.WithMaxPayloadSize(maxPayloadSize),
+		otlplogs.WithExportCallback(func(r otlplogs.ExportResult) { results = append(results, r) }),
+	)
+	require.NoError(t, err)
+	err = exp.Export(context.Background(), logs)
+	assert.ErrorContains(t, err, "log record exceeds the maximum payload size")
+	assert.ErrorContains(t, err, "maximum 200 bytes")
+
+	require.Greater(t, len(c.uploads), 1, "the batch is split")
+	var bodies []string
+	for _, upload := range c.uploads {
+		size := proto.Size(&collogspb.ExportLogsServiceRequest{ResourceLogs: upload})
+		assert.LessOrEqual(t, size, maxPayloadSize)
+		for _, lr := range uploadedRecords(upload) {
+			bodies = append(bodies, lr.Body.GetStringValue())
+		}
+	}
+	assert.Equal(t, small, bodies, "all the records but the oversized one are exported in order")
+
+	require.Len(t, results, 1, "the callback is called once per export")
+	assert.Equal(t, len(logs), results[0].RecordCount)
+	assert.Equal(t, err, results[0].Err)
+}
+
+func TestExporterMaxPayloadSizeNotExceeded(t *testing.T) {
+	body := "record"
+	logs := logstest.LogRecordStubs{{Body: &body}, {Body: &body}}.Snapshots()
+
+	c := &client{}
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(c),
+		otlplogs.WithMaxPayloadSize(1024),
+	)
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background(), logs))
+
+	require.Len(t, c.uploads, 1)
+	assert.Len(t, uploadedRecords(c.uploads[0]), 2)
+}
+
+func uploadedRecords(protoLogs []*logspb.ResourceLogs) []*logspb.LogRecord {
+	var records []*logspb.LogRecord
+	for _, rl := range protoLogs {
+		for _, sl := range rl.ScopeLogs {
+			records = append(records, sl.LogRecords...)
+		}
+	}
+	return records
+}
+
+func TestExporterCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	c := &client{uploadErr: errors.New("collector unavailable")}
+	exp, err := otlplogs.NewExporter(ctx,
+		otlplogs.WithClient(c),
+		otlplogs.WithCircuitBreaker(2, 50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	body := "Log record"
+	logs := logstest.LogRecordStubs{{Body: &body}}.Snapshots()
+	assert.ErrorIs(t, exp.Export(ctx, logs), c.uploadErr)
+	assert.ErrorIs(t, exp.Export(ctx, logs), c.uploadErr)
+	assert.Len(t, c.uploads, 2)
+
+	// The circuit is open: the exports fail without reaching the client.
+	err = exp.Export(ctx, logs)
+	assert.ErrorContains(t, err, "circuit breaker open")
+	assert.NotErrorIs(t, err, c.uploadErr)
+	assert.Len(t, c.uploads, 2)
+
+	// After the cooldown, a failed test of the recovery opens it again.
+	time.Sleep(60 * time.Millisecond)
+	assert.ErrorIs(t, exp.Export(ctx, logs), c.uploadErr)
+	assert.ErrorContains(t, exp.Export(ctx, logs), "circuit breaker open")
+	assert.Len(t, c.uploads, 3)
+
+	// A successful test of the recovery closes it.
+	time.Sleep(60 * time.Millisecond)
+	c.uploadErr = nil
+	assert.NoError(t, exp.Export(ctx, logs))
+	assert.NoError(t, exp.Export(ctx, logs))
+	assert.Len(t, c.uploads, 5)
+
+	assert.NoError(t, exp.Shutdown(ctx))
+}
+
+func TestExporterCircuitBreakerResetOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	c := &client{}
+	exp, err := otlplogs.NewExporter(ctx,
+		otlplogs.WithClient(c),
+		otlplogs.WithCircuitBreaker(2, time.Hour),
+	)
+	require.NoError(t, err)
+
+	body := "Log record"
+	logs := logstest.LogRecordStubs{{Body: &body}}.Snapshots()
+	for i := 0; i < 3; i++ {
+		c.uploadErr = errors.New("collector unavailable")
+		assert.Error(t, exp.Export(ctx, logs))
+		c.uploadErr = nil
+		assert.NoError(t, exp.Export(ctx, logs), "the failures are not consecutive")
+	}
+	assert.Len(t, c.uploads, 6)
+}
+
+func TestExporterSkipsInvalidRecords(t *testing.T) {
+	var handled []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	var results []otlplogs.ExportResult
+	ctx := context.Background()
+	c := &client{}
+	exp, err := otlplogs.NewExporter(ctx,
+		otlplogs.WithClient(c),
+		otlplogs.WithExportCallback(func(result otlplogs.ExportResult) { results = append(results, result) }),
+		otlplogs.WithMaxPayloadSize(1),
+	)
+	require.NoError(t, err)
+
+	first, invalid, last := "first", "invalid \xff", "last"
+	logs := logstest.LogRecordStubs{{Body: &first}, {Body: &invalid}, {Body: &last}}.Snapshots()
+	// The single records exceed the maximum payload size, which splits the
+	// batch without reporting the invalid record again.
+	assert.ErrorContains(t, exp.Export(ctx, logs), "exceeds the maximum payload size")
+	require.Len(t, handled, 1)
+	assert.ErrorContains(t, handled[0], "log record 1")
+	require.Len(t, results, 1)
+	assert.Equal(t, 1, results[0].SkippedRecords)
+
+	exp, err = otlplogs.NewExporter(ctx, otlplogs.WithClient(c))
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(ctx, logs))
+	records := uploadedRecords(c.uploaded)
+	require.Len(t, records, 2)
+	assert.Equal(t, first, records[0].Body.GetStringValue())
+	assert.Equal(t, last, records[1].Body.GetStringValue())
+	assert.Len(t, handled, 2)
+}
+
+func TestNewExporterInitialBatch(t *testing.T) {
+	c := otlplogstest.NewGRPCCollector(t)
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(otlplogsgrpc.NewClient(c.ClientOptions()...)),
+		otlplogs.WithInitialBatch(5*time.Second),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, exp.Shutdown(context.Background())) })
+
+	requests := c.Requests()
+	require.Len(t, requests, 1, "the warmup request is sent by NewExporter")
+	assert.Empty(t, requests[0].GetResourceLogs())
+
+	require.NoError(t, exp.Export(context.Background(), logstest.LogRecordStubs{{}}.Snapshots()))
+	assert.Len(t, c.Requests(), 2)
+}
+
+func TestNewExporterInitialBatchDisabled(t *testing.T) {
+	c := &client{}
+	exp, err := otlplogs.NewExporter(context.Background(), otlplogs.WithClient(c))
+	require.NoError(t, err)
+	assert.Empty(t, c.uploads)
+	assert.NoError(t, exp.Shutdown(context.Background()))
+}
+
+func TestNewExporterInitialBatchFailure(t *testing.T) {
+	var handled []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	uploadErr := errors.New("collector not ready")
+	c := &client{uploadErr: uploadErr}
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(c),
+		otlplogs.WithStartTimeout(time.Minute),
+		otlplogs.WithInitialBatch(time.Second),
+	)
+	require.NoError(t, err, "a failed warmup does not fail NewExporter")
+	require.NotNil(t, exp)
+	assert.Len(t, c.uploads, 1)
+	require.Len(t, handled, 1)
+	assert.ErrorIs(t, handled[0], uploadErr)
+	assert.NoError(t, exp.Shutdown(context.Background()))
+}
+
+// requestBodies returns the string bodies of the log records of each request.
+func requestBodies(requests []*collogspb.ExportLogsServiceRequest) [][]string {
+	var bodies [][]string
+	for _, req := range requests {
+		var b []string
+		for _, lr := range uploadedRecords(req.GetResourceLogs()) {
+			b = append(b, lr.Body.GetStringValue())
+		}
+		bodies = append(bodies, b)
+	}
+	return bodies
+}
+
+func TestExporterRetryRejectedRecords(t *testing.T) {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	bodies := []string{"a", "b", "c", "d", "e"}
+	var stubs logstest.LogRecordStubs
+	for i := range bodies {
+		stubs = append(stubs, logstest.LogRecordStub{Body: &bodies[i]})
+	}
+
+	tests := []struct {
+		name      string
+		retry     bool
+		responses []otlplogstest.HTTPResponse
+		want      [][]string
+	}{
+		{
+			name:  "rejected tail",
+			retry: true,
+			responses: []otlplogstest.HTTPResponse{
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 2}},
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+			},
+			want: [][]string{bodies, {"d", "e"}, {"e"}},
+		},
+		{
+			name:  "bounded",
+			retry: true,
+			responses: []otlplogstest.HTTPResponse{
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1}},
+			},
+			want: [][]string{bodies, {"e"}, {"e"}, {"e"}},
+		},
+		{
+			name:  "disabled",
+			retry: false,
+			responses: []otlplogstest.HTTPResponse{
+				{PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 2}},
+			},
+			want: [][]string{bodies},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := otlplogstest.NewHTTPCollector(t)
+			c.Respond(tt.responses...)
+			opts := []otlplogs.ExporterOption{otlplogs.WithClient(otlplogshttp.NewClient(c.ClientOptions()...))}
+			if tt.retry {
+				opts = append(opts, otlplogs.WithRetryRejectedRecords())
+			}
+			var results []otlplogs.ExportResult
+			opts = append(opts, otlplogs.WithExportCallback(func(r otlplogs.ExportResult) { results = append(results, r) }))
+			exp, err := otlplogs.NewExporter(context.Background(), opts...)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, exp.Shutdown(context.Background())) })
+
+			require.NoError(t, exp.Export(context.Background(), stubs.Snapshots()))
+			assert.Equal(t, tt.want, requestBodies(c.Requests()))
+			require.Len(t, results, 1)
+			assert.True(t, results[0].PartialSuccess, "the partial successes are still reported")
+		})
+	}
+}
+
+func TestFromProtoReplay(t *testing.T) {
+	ctx := context.Background()
+	c := &client{}
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(c))
+	require.NoError(t, err)
+
+	first, second := "first", "second"
+	scope := &instrumentation.Scope{Name: "replay"}
+	observed := time.Unix(1700000000, 0)
+	require.NoError(t, exp.Export(ctx, logstest.LogRecordStubs{
+		{Body: &first, ObservedTimestamp: observed, InstrumentationScope: scope},
+		{Body: &second, ObservedTimestamp: observed, InstrumentationScope: scope},
+	}.Snapshots()))
+	exported := c.uploaded
+
+	records, err := otlplogs.FromProto(exported)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, first, records[0].Body())
+	assert.Equal(t, &instrumentation.Scope{Name: "replay"}, records[1].InstrumentationScope())
+
+	// The converted records are exported again as they were first.
+	require.NoError(t, exp.Export(ctx, records))
+	assert.True(t, proto.Equal(
+		&collogspb.ExportLogsServiceRequest{ResourceLogs: exported},
+		&collogspb.ExportLogsServiceRequest{ResourceLogs: c.uploaded},
+	))
+}
@@ -0,0 +1,39 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogs
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/logstransform"
+	logssdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// FromProto transforms OTLP ResourceLogs, e.g. the ones of an
+// ExportLogsServiceRequest read back by a replay tool, into log records that
+// can be exported again. It reverses the encoding of the Exporter, which
+// loses some information: unset and zero fields are not distinguished, the
+// bodies are decoded as strings, int64, float64, bools, byte slices, []any and
+// map[string]any, and the attributes of the instrumentation scopes are not
+// encoded.
+//
+// A record which cannot be converted, because of a trace or span ID of the
+// wrong length or of an attribute value which is not a scalar or a slice of
+// scalars of the same type, is left out. The errors of all such records are
+// returned joined with the other records.
+func FromProto(resourceLogs []*logspb.ResourceLogs) ([]logssdk.ReadableLogRecord, error) {
+	return logstransform.FromProto(resourceLogs)
+}
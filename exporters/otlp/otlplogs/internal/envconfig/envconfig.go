@@ -71,13 +71,16 @@ func WithBool(n string, fn func(bool)) ConfigFn {
 	}
 }
 
-// WithDuration retrieves the specified config and passes it to ConfigFn as a duration.
+// WithDuration retrieves the specified config, an integer number of
+// milliseconds, and passes it to ConfigFn as a duration. A value that is not an
+// integer is ignored with a warning, so the default is kept instead of being
+// replaced by a zero duration.
 func WithDuration(n string, fn func(time.Duration)) func(e *EnvOptionsReader) {
 	return func(e *EnvOptionsReader) {
 		if v, ok := e.GetEnvValue(n); ok {
 			d, err := strconv.Atoi(v)
 			if err != nil {
-				global.Error(err, "parse duration", "input", v)
+				global.Warn("ignoring invalid duration, expected milliseconds", "key", keyWithNamespace(e.Namespace, n), "input", v)
 				return
 			}
 			fn(time.Duration(d) * time.Millisecond)
@@ -161,14 +164,20 @@ func keyWithNamespace(ns, key string) string {
 	return fmt.Sprintf("%s_%s", ns, key)
 }
 
+// stringToHeader parses a list of comma separated key=value pairs, as
+// recommended by the specification for OTEL_EXPORTER_OTLP_HEADERS. Keys and
+// values are percent-decoded, so a comma in a value can be encoded as %2C. A
+// value can also be double quoted, in which case the commas it contains do not
+// separate pairs.
 func stringToHeader(value string) map[string]string {
-	headersPairs := strings.Split(value, ",")
+	headersPairs := splitHeaders(value)
 	headers := make(map[string]string)
 
 	for _, header := range headersPairs {
 		n, v, found := strings.Cut(header, "=")
 		if !found {
-			global.Error(errors.New("missing '="), "parse headers", "input", header)
+			// The input is not logged, it may be a credential.
+			global.Error(errors.New("missing '="), "parse headers")
 			continue
 		}
 		name, err := url.QueryUnescape(n)
@@ -179,10 +188,14 @@ func stringToHeader(value string) map[string]string {
 		trimmedName := strings.TrimSpace(name)
 		value, err := url.QueryUnescape(v)
 		if err != nil {
-			global.Error(err, "escape header value", "value", v)
+			// The value is not logged, it may be a credential.
+			global.Error(err, "escape header value", "key", trimmedName)
 			continue
 		}
 		trimmedValue := strings.TrimSpace(value)
+		if len(trimmedValue) >= 2 && trimmedValue[0] == '"' && trimmedValue[len(trimmedValue)-1] == '"' {
+			trimmedValue = trimmedValue[1 : len(trimmedValue)-1]
+		}
 
 		headers[trimmedName] = trimmedValue
 	}
@@ -190,6 +203,27 @@ func stringToHeader(value string) map[string]string {
 	return headers
 }
 
+// splitHeaders splits value on the commas that are not between double quotes.
+func splitHeaders(value string) []string {
+	var (
+		pairs  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				pairs = append(pairs, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(pairs, value[start:])
+}
+
 func createCertPool(certBytes []byte) (*x509.CertPool, error) {
 	cp := x509.NewCertPool()
 	if ok := cp.AppendCertsFromPEM(certBytes); !ok {
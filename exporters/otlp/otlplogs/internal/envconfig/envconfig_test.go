@@ -434,6 +434,30 @@ func TestStringToHeader(t *testing.T) {
 				"isProduction": "false",
 			},
 		},
+		{
+			name:  "percent-encoded comma in value",
+			value: "x-list=a%2Cb%2Cc,userId=alice",
+			want: map[string]string{
+				"x-list": "a,b,c",
+				"userId": "alice",
+			},
+		},
+		{
+			name:  "quoted comma in value",
+			value: `x-list="a,b,c", userId=alice`,
+			want: map[string]string{
+				"x-list": "a,b,c",
+				"userId": "alice",
+			},
+		},
+		{
+			name:  "quoted empty value",
+			value: `x-empty="",userId=alice`,
+			want: map[string]string{
+				"x-empty": "",
+				"userId":  "alice",
+			},
+		},
 		{
 			name:  "invalid headers format",
 			value: "userId:alice",
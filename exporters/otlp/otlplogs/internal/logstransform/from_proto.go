@@ -0,0 +1,257 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstransform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	sdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"time"
+)
+
+// errInvalidProtoLogRecord is wrapped by the errors of the OTLP log records
+// which cannot be converted back to log records.
+var errInvalidProtoLogRecord = errors.New("OTLP log record cannot be converted")
+
+// FromProto transforms OTLP ResourceLogs, e.g. the ones of an
+// ExportLogsServiceRequest, back into log records, reversing LogsWithOptions.
+// It is exposed as otlplogs.FromProto.
+//
+// The encoding loses some information, which is not restored: unset and zero
+// fields are not distinguished, the pointers of the bodies are dereferenced,
+// the bodies are decoded as strings, int64, float64, bools, byte slices,
+// []any and map[string]any, and the attributes of the instrumentation scopes
+// are not encoded.
+//
+// A record which cannot be converted, because of a trace or span ID of the
+// wrong length or of an attribute value which is not a scalar or a slice of
+// scalars of the same type, is left out. The errors of all such records are
+// returned joined with the other records.
+func FromProto(resourceLogs []*logspb.ResourceLogs) ([]sdk.ReadableLogRecord, error) {
+	var (
+		records []sdk.ReadableLogRecord
+		errs    []error
+	)
+	for i, rl := range resourceLogs {
+		attrs, err := attributesFromProto(rl.GetResource().GetAttributes())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resource logs %d: resource: %w", i, err))
+			continue
+		}
+		res := resource.NewWithAttributes(rl.GetSchemaUrl(), attrs...)
+		for _, sl := range rl.GetScopeLogs() {
+			var scope *instrumentation.Scope
+			if sl.GetScope() != nil {
+				scope = &instrumentation.Scope{
+					Name:      sl.GetScope().GetName(),
+					Version:   sl.GetScope().GetVersion(),
+					SchemaURL: sl.GetSchemaUrl(),
+				}
+			}
+			for _, lr := range sl.GetLogRecords() {
+				record, err := logRecordFromProto(lr, res, scope)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("resource logs %d: %w", i, err))
+					continue
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	return records, errors.Join(errs...)
+}
+
+func logRecordFromProto(lr *logspb.LogRecord, res *resource.Resource, scope *instrumentation.Scope) (sdk.ReadableLogRecord, error) {
+	r := logstest.LogRecordStub{
+		Body:                 AnyValueToBody(lr.GetBody()),
+		Resource:             res,
+		InstrumentationScope: scope,
+		DroppedAttributes:    int(lr.GetDroppedAttributesCount()),
+	}
+	if ts := lr.GetTimeUnixNano(); ts != 0 {
+		t := time.Unix(0, int64(ts))
+		r.Timestamp = &t
+	}
+	if ts := lr.GetObservedTimeUnixNano(); ts != 0 {
+		r.ObservedTimestamp = time.Unix(0, int64(ts))
+	}
+	if id := lr.GetTraceId(); len(id) > 0 {
+		var traceID trace.TraceID
+		if len(id) != len(traceID) {
+			return nil, fmt.Errorf("%w: trace ID of %d bytes", errInvalidProtoLogRecord, len(id))
+		}
+		copy(traceID[:], id)
+		r.TraceId = &traceID
+	}
+	if id := lr.GetSpanId(); len(id) > 0 {
+		var spanID trace.SpanID
+		if len(id) != len(spanID) {
+			return nil, fmt.Errorf("%w: span ID of %d bytes", errInvalidProtoLogRecord, len(id))
+		}
+		copy(spanID[:], id)
+		r.SpanId = &spanID
+	}
+	if r.TraceId != nil || lr.GetFlags() != 0 {
+		flags := trace.TraceFlags(byte(lr.GetFlags()))
+		r.TraceFlags = &flags
+	}
+	if st := lr.GetSeverityText(); st != "" {
+		r.SeverityText = &st
+	}
+	if sn := lr.GetSeverityNumber(); sn != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		n := logs.SeverityNumber(sn)
+		r.SeverityNumber = &n
+	}
+	if en := lr.GetEventName(); en != "" {
+		r.EventName = &en
+	}
+	if len(lr.GetAttributes()) > 0 {
+		attrs, err := attributesFromProto(lr.GetAttributes())
+		if err != nil {
+			return nil, err
+		}
+		r.Attributes = &attrs
+	}
+	return r.Snapshot(), nil
+}
+
+// attributesFromProto transforms OTLP key-values into attributes.
+func attributesFromProto(kvs []*commonpb.KeyValue) ([]attribute.KeyValue, error) {
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		v, ok := attributeValueFromProto(kv.GetValue())
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported value of attribute %q", errInvalidProtoLogRecord, kv.GetKey())
+		}
+		attrs = append(attrs, attribute.KeyValue{Key: attribute.Key(kv.GetKey()), Value: v})
+	}
+	return attrs, nil
+}
+
+// attributeValueFromProto reverses Value. It returns false for the values
+// attributes cannot hold. An empty array is a string slice.
+func attributeValueFromProto(v *commonpb.AnyValue) (attribute.Value, bool) {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolValue(v.BoolValue), true
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Value(v.IntValue), true
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Value(v.DoubleValue), true
+	case *commonpb.AnyValue_StringValue:
+		return attribute.StringValue(v.StringValue), true
+	case *commonpb.AnyValue_ArrayValue:
+		return sliceValueFromProto(v.ArrayValue.GetValues())
+	default:
+		return attribute.Value{}, false
+	}
+}
+
+func sliceValueFromProto(values []*commonpb.AnyValue) (attribute.Value, bool) {
+	if len(values) == 0 {
+		return attribute.StringSliceValue(nil), true
+	}
+	switch values[0].GetValue().(type) {
+	case *commonpb.AnyValue_BoolValue:
+		s := make([]bool, len(values))
+		for i, v := range values {
+			b, ok := v.GetValue().(*commonpb.AnyValue_BoolValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			s[i] = b.BoolValue
+		}
+		return attribute.BoolSliceValue(s), true
+	case *commonpb.AnyValue_IntValue:
+		s := make([]int64, len(values))
+		for i, v := range values {
+			n, ok := v.GetValue().(*commonpb.AnyValue_IntValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			s[i] = n.IntValue
+		}
+		return attribute.Int64SliceValue(s), true
+	case *commonpb.AnyValue_DoubleValue:
+		s := make([]float64, len(values))
+		for i, v := range values {
+			f, ok := v.GetValue().(*commonpb.AnyValue_DoubleValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			s[i] = f.DoubleValue
+		}
+		return attribute.Float64SliceValue(s), true
+	case *commonpb.AnyValue_StringValue:
+		s := make([]string, len(values))
+		for i, v := range values {
+			str, ok := v.GetValue().(*commonpb.AnyValue_StringValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			s[i] = str.StringValue
+		}
+		return attribute.StringSliceValue(s), true
+	default:
+		return attribute.Value{}, false
+	}
+}
+
+// AnyValueToBody converts an OTLP AnyValue back to a log record body, nil for
+// no value. Arrays are converted to []any and key-value lists to
+// map[string]any, recursively.
+func AnyValueToBody(v *commonpb.AnyValue) any {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return v.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return v.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		values := v.ArrayValue.GetValues()
+		s := make([]any, len(values))
+		for i, e := range values {
+			s[i] = AnyValueToBody(e)
+		}
+		return s
+	case *commonpb.AnyValue_KvlistValue:
+		kvs := v.KvlistValue.GetValues()
+		m := make(map[string]any, len(kvs))
+		for _, kv := range kvs {
+			m[kv.GetKey()] = AnyValueToBody(kv.GetValue())
+		}
+		return m
+	default:
+		return nil
+	}
+}
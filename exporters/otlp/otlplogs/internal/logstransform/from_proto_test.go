@@ -0,0 +1,156 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstransform
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+	"testing"
+	"time"
+)
+
+func TestFromProtoRoundTrip(t *testing.T) {
+	ts := time.Unix(0, 1700000000000000001)
+	observed := time.Unix(0, 1700000000000000002)
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	spanID := trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+	flags := trace.FlagsSampled
+	severityText := "ERROR"
+	severityNumber := logs.ERROR
+	eventName := "user.login"
+	attrs := []attribute.KeyValue{
+		attribute.String("string", "value"),
+		attribute.Int64("int", 42),
+		attribute.Float64("float", 1.5),
+		attribute.Bool("bool", true),
+		attribute.StringSlice("strings", []string{"a", "b"}),
+		attribute.Int64Slice("ints", []int64{1, 2}),
+		attribute.Float64Slice("floats", []float64{1.5, 2.5}),
+		attribute.BoolSlice("bools", []bool{true, false}),
+	}
+	stubs := logstest.LogRecordStubs{
+		{
+			Timestamp:         &ts,
+			ObservedTimestamp: observed,
+			TraceId:           &traceID,
+			SpanId:            &spanID,
+			TraceFlags:        &flags,
+			SeverityText:      &severityText,
+			SeverityNumber:    &severityNumber,
+			EventName:         &eventName,
+			Body: map[string]any{
+				"message": "login failed",
+				"count":   int64(3),
+				"tags":    []any{"auth", true},
+				"raw":     []byte{1, 2},
+			},
+			Resource:             resource.NewSchemaless(attribute.String("service.name", "api")),
+			InstrumentationScope: &instrumentation.Scope{Name: "auth", Version: "v1.2.3", SchemaURL: "https://opentelemetry.io/schemas/1.24.0"},
+			Attributes:           &attrs,
+			DroppedAttributes:    2,
+		},
+		{
+			ObservedTimestamp: observed,
+			Body:              "plain",
+			Resource:          resource.NewSchemaless(attribute.String("service.name", "worker")),
+		},
+	}
+
+	// Through the wire encoding as a replay tool would read it.
+	b, err := proto.Marshal(&collogspb.ExportLogsServiceRequest{ResourceLogs: Logs(stubs.Snapshots())})
+	require.NoError(t, err)
+	var req collogspb.ExportLogsServiceRequest
+	require.NoError(t, proto.Unmarshal(b, &req))
+
+	records, err := FromProto(req.GetResourceLogs())
+	require.NoError(t, err)
+	require.Len(t, records, len(stubs))
+	// The records without a timestamp are exported with their observed one.
+	want := append(logstest.LogRecordStubs(nil), stubs...)
+	want[1].Timestamp = &observed
+	for i, record := range records {
+		assert.Equal(t, want[i], logstest.LogRecordStubFromReadableLogRecord(record), "record %d", i)
+	}
+
+	// The converted records are encoded as the original ones.
+	assert.True(t, proto.Equal(
+		&collogspb.ExportLogsServiceRequest{ResourceLogs: Logs(stubs.Snapshots())},
+		&collogspb.ExportLogsServiceRequest{ResourceLogs: Logs(records)},
+	))
+}
+
+func TestFromProtoEmpty(t *testing.T) {
+	records, err := FromProto(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestFromProtoInvalid(t *testing.T) {
+	valid := &logspb.LogRecord{Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "valid"}}}
+	resourceLogs := []*logspb.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{
+			LogRecords: []*logspb.LogRecord{
+				{TraceId: []byte{1, 2, 3}},
+				valid,
+				{TraceId: make([]byte, 16), SpanId: []byte{1}},
+				{Attributes: []*commonpb.KeyValue{{
+					Key:   "map",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{}}},
+				}}},
+				{Attributes: []*commonpb.KeyValue{{
+					Key: "mixed",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: []*commonpb.AnyValue{
+						{Value: &commonpb.AnyValue_StringValue{StringValue: "a"}},
+						{Value: &commonpb.AnyValue_IntValue{IntValue: 1}},
+					}}}},
+				}}},
+			},
+		}},
+	}}
+
+	records, err := FromProto(resourceLogs)
+	assert.ErrorIs(t, err, errInvalidProtoLogRecord)
+	assert.ErrorContains(t, err, "trace ID of 3 bytes")
+	assert.ErrorContains(t, err, "span ID of 1 bytes")
+	assert.ErrorContains(t, err, `unsupported value of attribute "map"`)
+	assert.ErrorContains(t, err, `unsupported value of attribute "mixed"`)
+	require.Len(t, records, 1, "the valid records are still converted")
+	assert.Equal(t, "valid", records[0].Body())
+}
+
+func TestAnyValueToBody(t *testing.T) {
+	assert.Nil(t, AnyValueToBody(nil))
+	body := map[string]any{
+		"string": "value",
+		"int":    int64(1),
+		"float":  1.5,
+		"bool":   false,
+		"bytes":  []byte{1},
+		"nested": map[string]any{"list": []any{"a", int64(2)}},
+	}
+	assert.Equal(t, body, AnyValueToBody(BodyToAnyValue(body)))
+}
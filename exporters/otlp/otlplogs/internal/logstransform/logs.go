@@ -17,7 +17,9 @@ limitations under the License.
 package logstransform
 
 import (
+	"fmt"
 	sdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"go.opentelemetry.io/otel/attribute"
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
 	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
@@ -28,47 +30,104 @@ import (
 	"time"
 )
 
+// Scope attribute keys of the records with flattened scope attributes.
+const (
+	ScopeNameKey    = attribute.Key("otel.scope.name")
+	ScopeVersionKey = attribute.Key("otel.scope.version")
+	scopePrefix     = "otel.scope."
+)
+
+// Options configures the transformation of log records.
+type Options struct {
+	// Body converts the body of the records, BodyToAnyValue if nil.
+	Body func(any) *commonpb.AnyValue
+	// FlattenScopeAttributes copies the name, version and attributes of the
+	// instrumentation scope onto the attributes of each record, prefixed by
+	// otel.scope., for backends ignoring the instrumentation scope.
+	FlattenScopeAttributes bool
+	// DefaultTimestampFromObserved sets the zero timestamp of the records to
+	// their observed timestamp instead of leaving it unset. The records
+	// without a timestamp always get their observed timestamp.
+	DefaultTimestampFromObserved bool
+	// OnInvalid receives the error of each record left out of the
+	// transformation because it cannot be marshaled, see LogsWithOptions.
+	OnInvalid func(error)
+}
+
 // Logs transforms OpenTelemetry LogRecord's into a OTLP ResourceLogs
 func Logs(sdl []sdk.ReadableLogRecord) []*logspb.ResourceLogs {
+	return LogsWithOptions(sdl, Options{})
+}
 
+// LogsWithOptions transforms OpenTelemetry LogRecord's into a OTLP
+// ResourceLogs as configured by opts.
+//
+// A record which cannot be marshaled, because converting it panics or because
+// it holds a string which is not valid UTF-8, is left out instead of failing
+// the marshaling of all the records. Its error is passed to opts.OnInvalid.
+func LogsWithOptions(sdl []sdk.ReadableLogRecord, opts Options) []*logspb.ResourceLogs {
 	var resourceLogs []*logspb.ResourceLogs
 
-	for _, sd := range sdl {
-
-		lr := logRecord(sd)
-
-		var is *commonpb.InstrumentationScope
-		var schemaURL = ""
-		if sd.InstrumentationScope() != nil {
-			is = &commonpb.InstrumentationScope{
-				Name:    sd.InstrumentationScope().Name,
-				Version: sd.InstrumentationScope().Version,
+	for i, sd := range sdl {
+		resourceLog, err := recordResourceLogs(sd, opts)
+		if err != nil {
+			if opts.OnInvalid != nil {
+				opts.OnInvalid(fmt.Errorf("log record %d: %w", i, err))
 			}
-			schemaURL = sd.InstrumentationScope().SchemaURL
+			continue
 		}
+		resourceLogs = append(resourceLogs, resourceLog)
+	}
 
-		// Create a log resource
-		resourceLog := &logspb.ResourceLogs{
-			Resource: &resourcepb.Resource{
-				Attributes: KeyValues(sd.Resource().Attributes()),
-			},
-			// provide a resource description if available
-			ScopeLogs: []*logspb.ScopeLogs{
-				{
-					Scope:      is,
-					SchemaUrl:  schemaURL,
-					LogRecords: []*logspb.LogRecord{lr},
-				},
-			},
+	return resourceLogs
+}
+
+// recordResourceLogs transforms sd into a OTLP ResourceLogs, or returns an
+// error if it cannot be marshaled.
+func recordResourceLogs(sd sdk.ReadableLogRecord, opts Options) (resourceLog *logspb.ResourceLogs, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidLogRecord, r)
 		}
+	}()
 
-		resourceLogs = append(resourceLogs, resourceLog)
+	lr := logRecord(sd, opts)
+
+	var is *commonpb.InstrumentationScope
+	var schemaURL = ""
+	if sd.InstrumentationScope() != nil {
+		is = &commonpb.InstrumentationScope{
+			Name:    sd.InstrumentationScope().Name,
+			Version: sd.InstrumentationScope().Version,
+		}
+		schemaURL = sd.InstrumentationScope().SchemaURL
 	}
 
-	return resourceLogs
+	// Create a log resource
+	resourceLog = &logspb.ResourceLogs{
+		Resource: &resourcepb.Resource{
+			Attributes: KeyValues(sd.Resource().Attributes()),
+		},
+		// provide a resource description if available
+		ScopeLogs: []*logspb.ScopeLogs{
+			{
+				Scope:      is,
+				SchemaUrl:  schemaURL,
+				LogRecords: []*logspb.LogRecord{lr},
+			},
+		},
+	}
+	if err := validateResourceLogs(resourceLog); err != nil {
+		return nil, err
+	}
+	return resourceLog, nil
 }
 
-func logRecord(record sdk.ReadableLogRecord) *logspb.LogRecord {
+func logRecord(record sdk.ReadableLogRecord, opts Options) *logspb.LogRecord {
+	body := opts.Body
+	if body == nil {
+		body = BodyToAnyValue
+	}
 	var traceIDBytes []byte
 	if record.TraceId() != nil {
 		tid := *record.TraceId()
@@ -87,39 +146,98 @@ func logRecord(record sdk.ReadableLogRecord) *logspb.LogRecord {
 	var ts time.Time
 	if record.Timestamp() != nil {
 		ts = *record.Timestamp()
+		if ts.IsZero() && opts.DefaultTimestampFromObserved {
+			ts = record.ObservedTimestamp()
+		}
 	} else {
 		ts = record.ObservedTimestamp()
 	}
+	var tsUnixNano uint64
+	if !ts.IsZero() {
+		tsUnixNano = uint64(ts.UnixNano())
+	}
 
 	var kv []*commonpb.KeyValue
 	if record.Attributes() != nil {
 		kv = KeyValues(*record.Attributes())
 	}
+	if opts.FlattenScopeAttributes {
+		kv = append(kv, KeyValues(scopeAttributes(record))...)
+	}
 
 	var st = ""
 	if record.SeverityText() != nil {
 		st = *record.SeverityText()
 	}
 
+	var en = ""
+	if record.EventName() != nil {
+		en = *record.EventName()
+	}
+
 	var sn = logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
 	if record.SeverityNumber() != nil {
 		sn = logspb.SeverityNumber(*record.SeverityNumber())
 	}
 
 	logRecord := &logspb.LogRecord{
-		TimeUnixNano:         uint64(ts.UnixNano()),
+		TimeUnixNano:         tsUnixNano,
 		ObservedTimeUnixNano: uint64(record.ObservedTimestamp().UnixNano()),
-		TraceId:              traceIDBytes,                   // provide the associated trace ID if available
-		SpanId:               spanIDBytes,                    // provide the associated span ID if available
-		Flags:                uint32(traceFlags),             // provide the associated trace flags
-		Body:                 valueToAnyValue(record.Body()), // provide the associated log body if available
-		Attributes:           kv,                             // provide additional log attributes if available
+		TraceId:              traceIDBytes,        // provide the associated trace ID if available
+		SpanId:               spanIDBytes,         // provide the associated span ID if available
+		Flags:                uint32(traceFlags),  // provide the associated trace flags
+		Body:                 body(record.Body()), // provide the associated log body if available
+		Attributes:           kv,                  // provide additional log attributes if available
 		SeverityText:         st,
 		SeverityNumber:       sn,
+		EventName:            en,
+
+		DroppedAttributesCount: uint32(record.DroppedAttributes()),
 	}
 	return logRecord
 }
 
+// scopeAttributes returns the instrumentation scope of record as record
+// attributes, leaving out the keys already set on the record.
+func scopeAttributes(record sdk.ReadableLogRecord) []attribute.KeyValue {
+	is := record.InstrumentationScope()
+	if is == nil {
+		return nil
+	}
+
+	set := make(map[attribute.Key]struct{})
+	if record.Attributes() != nil {
+		for _, kv := range *record.Attributes() {
+			set[kv.Key] = struct{}{}
+		}
+	}
+	var attrs []attribute.KeyValue
+	add := func(kv attribute.KeyValue) {
+		if _, ok := set[kv.Key]; !ok {
+			set[kv.Key] = struct{}{}
+			attrs = append(attrs, kv)
+		}
+	}
+
+	if is.Name != "" {
+		add(ScopeNameKey.String(is.Name))
+	}
+	if is.Version != "" {
+		add(ScopeVersionKey.String(is.Version))
+	}
+	for iter := is.Attributes.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		add(attribute.KeyValue{Key: scopePrefix + kv.Key, Value: kv.Value})
+	}
+	return attrs
+}
+
+// BodyToAnyValue converts the body of a log record to an OTLP AnyValue. Maps,
+// slices and structs are converted recursively.
+func BodyToAnyValue(body any) *commonpb.AnyValue {
+	return valueToAnyValue(body)
+}
+
 func valueToAnyValue(value any) *commonpb.AnyValue {
 	if value == nil {
 		return nil
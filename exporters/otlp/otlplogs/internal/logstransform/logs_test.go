@@ -20,8 +20,13 @@ import (
 	logssdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
 	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"math"
 	"testing"
 	"time"
@@ -88,7 +93,7 @@ func TestLogRecord(t *testing.T) {
 		Timestamp:         &logTime,
 		ObservedTimestamp: logTime,
 		Body:              &body,
-	}.Snapshot())
+	}.Snapshot(), Options{})
 
 	logTimestamp := uint64(1589932800 * 1e9)
 
@@ -371,3 +376,144 @@ func TestLogRecord(t *testing.T) {
 		ObservedTimeUnixNano: logTimestamp,
 	}, lr)
 }
+
+func TestLogRecordEventName(t *testing.T) {
+	eventName := "session.start"
+	body := "user logged in"
+	lr := logRecord(logstest.LogRecordStub{
+		ObservedTimestamp: time.Unix(1589932800, 0),
+		EventName:         &eventName,
+		Body:              &body,
+	}.Snapshot(), Options{})
+	assert.Equal(t, eventName, lr.GetEventName())
+
+	rawProto, err := proto.Marshal(lr)
+	require.NoError(t, err)
+	fromProto := &logspb.LogRecord{}
+	require.NoError(t, proto.Unmarshal(rawProto, fromProto))
+	assert.Equal(t, eventName, fromProto.GetEventName())
+
+	rawJSON, err := protojson.Marshal(lr)
+	require.NoError(t, err)
+	fromJSON := &logspb.LogRecord{}
+	require.NoError(t, protojson.Unmarshal(rawJSON, fromJSON))
+	assert.Equal(t, eventName, fromJSON.GetEventName())
+}
+
+func TestLogRecordWithoutEventName(t *testing.T) {
+	lr := logRecord(logstest.LogRecordStub{ObservedTimestamp: time.Unix(1589932800, 0)}.Snapshot(), Options{})
+	assert.Empty(t, lr.GetEventName())
+}
+
+func TestLogRecordDroppedAttributes(t *testing.T) {
+	lr := logRecord(logstest.LogRecordStub{
+		ObservedTimestamp: time.Unix(1589932800, 0),
+		DroppedAttributes: 3,
+	}.Snapshot(), Options{})
+	assert.Equal(t, uint32(3), lr.GetDroppedAttributesCount())
+}
+
+func TestLogRecordFlattenScopeAttributes(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("otel.scope.team", "record")}
+	stub := logstest.LogRecordStub{
+		ObservedTimestamp: time.Unix(1589932800, 0),
+		Attributes:        &attrs,
+		InstrumentationScope: &instrumentation.Scope{
+			Name:       "github.com/example/pkg",
+			Version:    "v1.2.3",
+			Attributes: attribute.NewSet(attribute.String("team", "scope"), attribute.Int("shard", 2)),
+		},
+	}.Snapshot()
+
+	flattened := logRecord(stub, Options{FlattenScopeAttributes: true})
+	assert.Equal(t, []*commonpb.KeyValue{
+		{Key: "otel.scope.team", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "record"}}},
+		{Key: "otel.scope.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "github.com/example/pkg"}}},
+		{Key: "otel.scope.version", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "v1.2.3"}}},
+		{Key: "otel.scope.shard", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 2}}},
+	}, flattened.Attributes)
+
+	lr := logRecord(stub, Options{})
+	assert.Equal(t, []*commonpb.KeyValue{
+		{Key: "otel.scope.team", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "record"}}},
+	}, lr.Attributes)
+
+	withoutScope := logRecord(logstest.LogRecordStub{ObservedTimestamp: time.Unix(1589932800, 0)}.Snapshot(), Options{FlattenScopeAttributes: true})
+	assert.Empty(t, withoutScope.Attributes)
+}
+
+func TestLogRecordDefaultTimestampFromObserved(t *testing.T) {
+	observed := time.Unix(1589932800, 0)
+	eventTime := time.Unix(1589932700, 0)
+	var zero time.Time
+
+	tests := []struct {
+		name      string
+		timestamp *time.Time
+		opts      Options
+		want      uint64
+	}{
+		{name: "no timestamp", want: uint64(observed.UnixNano())},
+		{name: "zero timestamp", timestamp: &zero, want: 0},
+		{name: "timestamp", timestamp: &eventTime, want: uint64(eventTime.UnixNano())},
+		{
+			name: "no timestamp from observed",
+			opts: Options{DefaultTimestampFromObserved: true},
+			want: uint64(observed.UnixNano()),
+		},
+		{
+			name:      "zero timestamp from observed",
+			timestamp: &zero,
+			opts:      Options{DefaultTimestampFromObserved: true},
+			want:      uint64(observed.UnixNano()),
+		},
+		{
+			name:      "timestamp kept",
+			timestamp: &eventTime,
+			opts:      Options{DefaultTimestampFromObserved: true},
+			want:      uint64(eventTime.UnixNano()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr := logRecord(logstest.LogRecordStub{Timestamp: tt.timestamp, ObservedTimestamp: observed}.Snapshot(), tt.opts)
+			assert.Equal(t, tt.want, lr.GetTimeUnixNano())
+			assert.Equal(t, uint64(observed.UnixNano()), lr.GetObservedTimeUnixNano())
+		})
+	}
+}
+
+func TestLogsWithOptionsInvalidRecords(t *testing.T) {
+	valid, invalidUTF8 := "valid", "invalid \xff"
+	attrs := []attribute.KeyValue{attribute.String("user.name", invalidUTF8)}
+	records := logstest.LogRecordStubs{
+		{Body: &valid},
+		{Body: &invalidUTF8},
+		{Body: &valid, Attributes: &attrs},
+		{Body: "panics"},
+		{Body: &valid},
+	}.Snapshots()
+
+	var errs []error
+	protoLogs := LogsWithOptions(records, Options{
+		Body: func(body any) *commonpb.AnyValue {
+			if body == "panics" {
+				panic("unsupported body")
+			}
+			return BodyToAnyValue(body)
+		},
+		OnInvalid: func(err error) { errs = append(errs, err) },
+	})
+
+	require.Len(t, protoLogs, 2)
+	_, err := proto.Marshal(&logspb.LogsData{ResourceLogs: protoLogs})
+	assert.NoError(t, err)
+
+	require.Len(t, errs, 3)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrInvalidLogRecord)
+	}
+	assert.EqualError(t, errs[0], "log record 1: log record cannot be marshaled: body is not valid UTF-8")
+	assert.EqualError(t, errs[1], "log record 2: log record cannot be marshaled: attribute user.name is not valid UTF-8")
+	assert.EqualError(t, errs[2], "log record 3: log record cannot be marshaled: unsupported body")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logstransform
+
+import (
+	"errors"
+	"fmt"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"unicode/utf8"
+)
+
+// ErrInvalidLogRecord is the error of a record which cannot be marshaled.
+var ErrInvalidLogRecord = errors.New("log record cannot be marshaled")
+
+// validateResourceLogs returns an error if a string of rl is not valid UTF-8,
+// which fails the marshaling of protobuf string fields.
+func validateResourceLogs(rl *logspb.ResourceLogs) error {
+	if err := validateKeyValues("resource attribute", rl.GetResource().GetAttributes()); err != nil {
+		return err
+	}
+	for _, sl := range rl.GetScopeLogs() {
+		if err := validateString("scope", sl.GetScope().GetName(), sl.GetScope().GetVersion(), sl.GetSchemaUrl()); err != nil {
+			return err
+		}
+		for _, lr := range sl.GetLogRecords() {
+			if err := validateString("severity text or event name", lr.GetSeverityText(), lr.GetEventName()); err != nil {
+				return err
+			}
+			if err := validateAnyValue("body", lr.GetBody()); err != nil {
+				return err
+			}
+			if err := validateKeyValues("attribute", lr.GetAttributes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateKeyValues(field string, kvs []*commonpb.KeyValue) error {
+	for _, kv := range kvs {
+		if err := validateString(field+" key", kv.GetKey()); err != nil {
+			return err
+		}
+		if err := validateAnyValue(field+" "+kv.GetKey(), kv.GetValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAnyValue(field string, v *commonpb.AnyValue) error {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return validateString(field, v.StringValue)
+	case *commonpb.AnyValue_ArrayValue:
+		for _, e := range v.ArrayValue.GetValues() {
+			if err := validateAnyValue(field, e); err != nil {
+				return err
+			}
+		}
+	case *commonpb.AnyValue_KvlistValue:
+		return validateKeyValues(field, v.KvlistValue.GetValues())
+	}
+	return nil
+}
+
+func validateString(field string, values ...string) error {
+	for _, s := range values {
+		if !utf8.ValidString(s) {
+			return fmt.Errorf("%w: %s is not valid UTF-8", ErrInvalidLogRecord, field)
+		}
+	}
+	return nil
+}
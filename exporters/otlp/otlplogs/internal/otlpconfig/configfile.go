@@ -0,0 +1,140 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"time"
+)
+
+// fileConfig is the content of a configuration file read by WithConfigFile.
+type fileConfig struct {
+	// Endpoint is a URL used as the OTEL_EXPORTER_OTLP_ENDPOINT variable.
+	Endpoint string `yaml:"endpoint"`
+	// Protocol is grpc, http/protobuf or http/json.
+	Protocol string `yaml:"protocol"`
+	// Headers are sent with each export.
+	Headers map[string]string `yaml:"headers"`
+	// Compression is gzip or none.
+	Compression string `yaml:"compression"`
+	// Timeout is a duration such as 10s.
+	Timeout string `yaml:"timeout"`
+}
+
+// configFileOption is the option returned by WithConfigFile. It does not
+// change the Config, it is looked up before the options are applied.
+type configFileOption struct {
+	path string
+}
+
+func (configFileOption) ApplyGRPCOption(cfg Config) Config { return cfg }
+func (configFileOption) ApplyHTTPOption(cfg Config) Config { return cfg }
+func (configFileOption) private()                          {}
+
+// WithConfigFile reads the endpoint, protocol, headers, compression and timeout
+// from the YAML or JSON file at path. They take precedence over the
+// environment variables, the other options take precedence over them wherever
+// they are passed. A file which cannot be read or parsed is reported as an
+// error and ignored.
+func WithConfigFile(path string) GenericOption {
+	return configFileOption{path: path}
+}
+
+// applyConfigFiles applies to cfg with apply the settings of the files of the
+// WithConfigFile options of opts, in order.
+func applyConfigFiles[T any](cfg Config, opts []T, apply func(GenericOption, Config) Config) Config {
+	for _, opt := range opts {
+		o, ok := any(opt).(configFileOption)
+		if !ok {
+			continue
+		}
+		fileOpts, err := readConfigFile(o.path)
+		if err != nil {
+			global.Error(err, "read config file", "file", o.path)
+			continue
+		}
+		for _, fo := range fileOpts {
+			cfg = apply(fo, cfg)
+		}
+	}
+	return cfg
+}
+
+// readConfigFile returns the options set by the configuration file at path,
+// read with DefaultEnvOptionsReader.
+func readConfigFile(path string) ([]GenericOption, error) {
+	b, err := DefaultEnvOptionsReader.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigFile(b)
+}
+
+// parseConfigFile returns the options set by the YAML or JSON configuration
+// file b. Unknown fields are errors, to report the misspelled ones.
+func parseConfigFile(b []byte) ([]GenericOption, error) {
+	var fc fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	var opts []GenericOption
+	if fc.Endpoint != "" {
+		u, err := parseEndpoint(fc.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", fc.Endpoint, err)
+		}
+		opts = append(opts, withEndpointScheme(u), withBaseEndpoint(u))
+	}
+	if fc.Protocol != "" {
+		switch p := Protocol(strings.ToLower(fc.Protocol)); p {
+		case ExporterProtocolGrpc, ExporterProtocolHttpProtobuf, ExporterProtocolHttpJson:
+			opts = append(opts, withProtocol(string(p)))
+		default:
+			return nil, fmt.Errorf("unknown protocol %q", fc.Protocol)
+		}
+	}
+	if fc.Headers != nil {
+		opts = append(opts, WithHeaders(fc.Headers))
+	}
+	if fc.Compression != "" {
+		switch strings.ToLower(fc.Compression) {
+		case "gzip":
+			opts = append(opts, WithCompression(GzipCompression))
+		case "none":
+			opts = append(opts, WithCompression(NoCompression))
+		default:
+			return nil, fmt.Errorf("unknown compression %q", fc.Compression)
+		}
+	}
+	if fc.Timeout != "" {
+		d, err := time.ParseDuration(fc.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("timeout: %w", err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	return opts, nil
+}
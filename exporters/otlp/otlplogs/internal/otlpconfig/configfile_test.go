@@ -0,0 +1,149 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/envconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const yamlConfigFile = `
+endpoint: https://collector.example.com:4318/otlp
+protocol: http/json
+headers:
+  x-tenant: team-a
+  x-environment: prod
+compression: gzip
+timeout: 3s
+`
+
+const jsonConfigFile = `{
+	"endpoint": "http://collector:4317",
+	"protocol": "grpc",
+	"headers": {"x-tenant": "team-b"},
+	"compression": "none",
+	"timeout": "1500ms"
+}`
+
+func withEnvOptionsReader(t *testing.T, e env, files fileReader) {
+	orig := DefaultEnvOptionsReader
+	DefaultEnvOptionsReader = envconfig.EnvOptionsReader{
+		GetEnv:    e.getEnv,
+		ReadFile:  files.readFile,
+		Namespace: "OTEL_EXPORTER_OTLP",
+	}
+	t.Cleanup(func() { DefaultEnvOptionsReader = orig })
+}
+
+func TestWithConfigFile(t *testing.T) {
+	withEnvOptionsReader(t, env{}, fileReader{"/etc/otel/config.yaml": []byte(yamlConfigFile)})
+
+	cfg := NewHTTPConfig(asHTTPOptions([]GenericOption{WithConfigFile("/etc/otel/config.yaml")})...)
+	assert.Equal(t, "collector.example.com:4318", cfg.Logs.Endpoint)
+	assert.Equal(t, "/otlp/v1/logs", cfg.Logs.URLPath)
+	assert.False(t, cfg.Logs.Insecure)
+	assert.Equal(t, ExporterProtocolHttpJson, cfg.Logs.Protocol)
+	assert.Equal(t, map[string]string{"x-tenant": "team-a", "x-environment": "prod"}, cfg.Logs.Headers)
+	assert.Equal(t, GzipCompression, cfg.Logs.Compression)
+	assert.Equal(t, 3*time.Second, cfg.Logs.Timeout)
+
+	cfg = NewGRPCConfig(asGRPCOptions([]GenericOption{WithConfigFile("/etc/otel/config.yaml")})...)
+	assert.Equal(t, "collector.example.com:4318/otlp", cfg.Logs.Endpoint)
+	assert.Equal(t, 3*time.Second, cfg.Logs.Timeout)
+}
+
+func TestWithConfigFileJSON(t *testing.T) {
+	withEnvOptionsReader(t, env{}, fileReader{"config.json": []byte(jsonConfigFile)})
+
+	cfg := NewGRPCConfig(asGRPCOptions([]GenericOption{WithConfigFile("config.json")})...)
+	assert.Equal(t, "collector:4317", cfg.Logs.Endpoint)
+	assert.True(t, cfg.Logs.Insecure)
+	assert.Equal(t, ExporterProtocolGrpc, cfg.Logs.Protocol)
+	assert.Equal(t, map[string]string{"x-tenant": "team-b"}, cfg.Logs.Headers)
+	assert.Equal(t, NoCompression, cfg.Logs.Compression)
+	assert.Equal(t, 1500*time.Millisecond, cfg.Logs.Timeout)
+}
+
+func TestWithConfigFilePrecedence(t *testing.T) {
+	withEnvOptionsReader(t, env{
+		"OTEL_EXPORTER_OTLP_TIMEOUT":  "9000",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "https://env:4318",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "x-env=1",
+	}, fileReader{"config.yaml": []byte("timeout: 3s\nendpoint: https://file:4318\n")})
+
+	// The options passed before the file still take precedence over it.
+	cfg := NewHTTPConfig(asHTTPOptions([]GenericOption{
+		WithTimeout(time.Second),
+		WithConfigFile("config.yaml"),
+	})...)
+	assert.Equal(t, time.Second, cfg.Logs.Timeout)
+	assert.Equal(t, "file:4318", cfg.Logs.Endpoint, "the file takes precedence over the environment")
+	assert.Equal(t, map[string]string{"x-env": "1"}, cfg.Logs.Headers, "the environment is still read")
+
+	cfg = NewHTTPConfig(asHTTPOptions([]GenericOption{
+		WithConfigFile("config.yaml"),
+		WithEndpoint("option:4318"),
+	})...)
+	assert.Equal(t, "option:4318", cfg.Logs.Endpoint)
+	assert.Equal(t, 3*time.Second, cfg.Logs.Timeout)
+}
+
+func TestWithConfigFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "missing", want: "file not found"},
+		{name: "malformed", content: "endpoint: [", want: "yaml"},
+		{name: "unknown field", content: "endpont: collector:4318", want: "endpont"},
+		{name: "unknown protocol", content: "protocol: thrift", want: "unknown protocol"},
+		{name: "unknown compression", content: "compression: zstd", want: "unknown compression"},
+		{name: "invalid timeout", content: "timeout: 10", want: "timeout"},
+		{name: "endpoint without host", content: "endpoint: https://", want: "endpoint has no host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fileReader{}
+			if tt.content != "" {
+				files["config.yaml"] = []byte(tt.content)
+			}
+			withEnvOptionsReader(t, env{}, files)
+			warnings := captureWarnings(t)
+
+			cfg := NewHTTPConfig(asHTTPOptions([]GenericOption{WithConfigFile("config.yaml")})...)
+			assert.Equal(t, NewHTTPConfig().Logs.Endpoint, cfg.Logs.Endpoint, "the file is ignored")
+			assert.Equal(t, DefaultTimeout, cfg.Logs.Timeout)
+			got := warnings()
+			require.Len(t, got, 1)
+			assert.Contains(t, got[0], "read config file")
+			assert.Contains(t, got[0], tt.want)
+		})
+	}
+}
+
+func TestWithConfigFileEmpty(t *testing.T) {
+	withEnvOptionsReader(t, env{}, fileReader{"config.yaml": nil})
+	warnings := captureWarnings(t)
+
+	cfg := NewHTTPConfig(asHTTPOptions([]GenericOption{WithConfigFile("config.yaml")})...)
+	assert.Empty(t, warnings())
+	assert.Equal(t, NewHTTPConfig().Logs, cfg.Logs)
+}
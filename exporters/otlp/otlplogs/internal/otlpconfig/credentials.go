@@ -0,0 +1,93 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
+	"net"
+	"strings"
+)
+
+// credentialHeaderHints are substrings of the lowercased names of headers
+// likely to carry credentials.
+var credentialHeaderHints = []string{"authorization", "api-key", "apikey", "token", "secret", "x-honeycomb-team"}
+
+// WithCredentialHeaders configures a direct export to a vendor endpoint
+// authenticating with headers, e.g. an API key: the headers are set, the
+// connection is secure and payloads are compressed with gzip. Options passed
+// after it can change this configuration, validateCredentials warns about the
+// risky ones.
+func WithCredentialHeaders(headers map[string]string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Logs.Headers = headers
+		cfg.Logs.Insecure = false
+		cfg.Logs.Compression = GzipCompression
+		cfg.Logs.CredentialHeaders = true
+		return cfg
+	})
+}
+
+// validateCredentials warns if credentials would be sent in clear text to a
+// remote endpoint, or if credential headers were asked but are not set.
+func validateCredentials(cfg Config) {
+	if cfg.Logs.CredentialHeaders {
+		if len(cfg.Logs.Headers) == 0 {
+			global.Warn("no credential headers set for a direct export", "endpoint", cfg.Logs.Endpoint)
+		}
+		for name, value := range cfg.Logs.Headers {
+			if strings.TrimSpace(value) == "" {
+				global.Warn("credential header has an empty value", "header", name)
+			}
+		}
+	}
+	if !cfg.Logs.Insecure || isLocalEndpoint(cfg.Logs.Endpoint) {
+		return
+	}
+	for name := range cfg.Logs.Headers {
+		if cfg.Logs.CredentialHeaders || isCredentialHeader(name) {
+			global.Warn("sending credential headers over an insecure connection to a remote endpoint", "endpoint", cfg.Logs.Endpoint, "header", name)
+			return
+		}
+	}
+}
+
+// isCredentialHeader returns true if the header name looks like it carries
+// credentials.
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range credentialHeaderHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalEndpoint returns true if endpoint is on the local host or in a
+// private network, where an insecure connection is commonly used to reach a
+// collector.
+func isLocalEndpoint(endpoint string) bool {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
@@ -0,0 +1,140 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"github.com/go-logr/logr/funcr"
+	"github.com/go-logr/stdr"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"sync"
+	"testing"
+)
+
+// captureWarnings returns the messages of the warnings logged by the test.
+func captureWarnings(t *testing.T) func() []string {
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	global.SetLogger(funcr.NewJSON(func(obj string) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, obj)
+	}, funcr.Options{Verbosity: 1}))
+	t.Cleanup(func() { global.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []GenericOption
+		want []string
+	}{
+		{
+			name: "direct export",
+			opts: []GenericOption{
+				WithEndpoint("api.honeycomb.io:443"),
+				WithCredentialHeaders(map[string]string{"x-honeycomb-team": "key"}),
+			},
+		},
+		{
+			name: "insecure after credential headers",
+			opts: []GenericOption{
+				WithEndpoint("api.honeycomb.io:443"),
+				WithCredentialHeaders(map[string]string{"x-honeycomb-team": "key"}),
+				WithInsecure(),
+			},
+			want: []string{"sending credential headers over an insecure connection to a remote endpoint"},
+		},
+		{
+			name: "insecure with credential-like headers",
+			opts: []GenericOption{
+				WithEndpoint("otlp.example.com:4317"),
+				WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+				WithInsecure(),
+			},
+			want: []string{"sending credential headers over an insecure connection to a remote endpoint"},
+		},
+		{
+			name: "insecure with other headers",
+			opts: []GenericOption{
+				WithEndpoint("otlp.example.com:4317"),
+				WithHeaders(map[string]string{"x-environment": "prod"}),
+				WithInsecure(),
+			},
+		},
+		{
+			name: "insecure to a local collector",
+			opts: []GenericOption{
+				WithEndpoint("127.0.0.1:4317"),
+				WithCredentialHeaders(map[string]string{"x-api-key": "key"}),
+				WithInsecure(),
+			},
+		},
+		{
+			name: "no credential headers",
+			opts: []GenericOption{
+				WithEndpoint("api.honeycomb.io:443"),
+				WithCredentialHeaders(nil),
+			},
+			want: []string{"no credential headers set for a direct export"},
+		},
+		{
+			name: "empty credential header",
+			opts: []GenericOption{
+				WithEndpoint("api.honeycomb.io:443"),
+				WithCredentialHeaders(map[string]string{"x-honeycomb-team": " "}),
+			},
+			want: []string{"credential header has an empty value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := captureWarnings(t)
+
+			cfg := NewHTTPConfig(asHTTPOptions(tt.opts)...)
+			got := warnings()
+			assert.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				assert.Contains(t, got[i], want)
+			}
+			if !cfg.Logs.Insecure {
+				assert.Equal(t, GzipCompression, cfg.Logs.Compression)
+			}
+		})
+	}
+}
+
+func TestWithCredentialHeadersGRPC(t *testing.T) {
+	warnings := captureWarnings(t)
+
+	cfg := NewGRPCConfig(asGRPCOptions([]GenericOption{
+		WithEndpoint("api.honeycomb.io:443"),
+		WithCredentialHeaders(map[string]string{"x-honeycomb-team": "key"}),
+		WithInsecure(),
+	})...)
+	assert.True(t, cfg.Logs.Insecure)
+	assert.Len(t, warnings(), 1)
+}
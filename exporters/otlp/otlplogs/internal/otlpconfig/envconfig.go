@@ -19,7 +19,9 @@ package otlpconfig
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/envconfig"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
 	"net/url"
 	"os"
 	"path"
@@ -85,18 +87,10 @@ func getOptionsFromEnv() []GenericOption {
 
 	tlsConf := &tls.Config{}
 	DefaultEnvOptionsReader.Apply(
-		envconfig.WithURL("ENDPOINT", func(u *url.URL) {
-			opts = append(opts, withEndpointScheme(u))
-			opts = append(opts, newSplitOption(func(cfg Config) Config {
-				cfg.Logs.Endpoint = u.Host
-				// For OTLP/HTTP endpoint URLs without a per-signal
-				// configuration, the passed endpoint is used as a base URL
-				// and the signals are sent to these paths relative to that.
-				cfg.Logs.URLPath = path.Join(u.Path, DefaultLogsPath)
-				return cfg
-			}, withEndpointForGRPC(u)))
+		withEnvEndpoint("ENDPOINT", func(u *url.URL) {
+			opts = append(opts, withEndpointScheme(u), withBaseEndpoint(u))
 		}),
-		envconfig.WithURL("LOGS_ENDPOINT", func(u *url.URL) {
+		withEnvEndpoint("LOGS_ENDPOINT", func(u *url.URL) {
 			opts = append(opts, withEndpointScheme(u))
 			opts = append(opts, newSplitOption(func(cfg Config) Config {
 				cfg.Logs.Endpoint = u.Host
@@ -136,13 +130,70 @@ func getOptionsFromEnv() []GenericOption {
 	return opts
 }
 
-func withEndpointScheme(u *url.URL) GenericOption {
-	switch strings.ToLower(u.Scheme) {
-	case "http", "unix":
-		return WithInsecure()
-	default:
-		return WithSecure()
+// withEnvEndpoint retrieves the endpoint URL n and passes it to fn, see
+// parseEndpoint.
+func withEnvEndpoint(n string, fn func(*url.URL)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		v, ok := e.GetEnvValue(n)
+		if !ok {
+			return
+		}
+		u, err := parseEndpoint(v)
+		if err != nil {
+			global.Error(err, "parse url", "input", v)
+			return
+		}
+		fn(u)
+	}
+}
+
+// parseEndpoint parses an endpoint URL. An endpoint without a scheme, e.g.
+// collector:4318, is parsed as a host so that it is not mistaken for a scheme:
+// it is returned with an empty scheme.
+func parseEndpoint(v string) (*url.URL, error) {
+	if !strings.Contains(v, "://") {
+		v = "//" + v
 	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" && u.Scheme != "unix" {
+		return nil, errEmptyEndpointHost
+	}
+	return u, nil
+}
+
+// withBaseEndpoint sets the endpoint to u, used as a base URL by OTLP/HTTP.
+func withBaseEndpoint(u *url.URL) GenericOption {
+	return newSplitOption(func(cfg Config) Config {
+		cfg.Logs.Endpoint = u.Host
+		// For OTLP/HTTP endpoint URLs without a per-signal
+		// configuration, the passed endpoint is used as a base URL
+		// and the signals are sent to these paths relative to that.
+		cfg.Logs.URLPath = path.Join(u.Path, DefaultLogsPath)
+		return cfg
+	}, withEndpointForGRPC(u))
+}
+
+// errEmptyEndpointHost is reported for an endpoint URL without a host.
+var errEmptyEndpointHost = errors.New("endpoint has no host")
+
+// withEndpointScheme sets the security of the connection from the scheme of
+// the endpoint URL. An endpoint without a scheme keeps the security set with
+// OTEL_EXPORTER_OTLP_INSECURE or the options, secure by default.
+func withEndpointScheme(u *url.URL) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Logs.EndpointScheme = strings.ToLower(u.Scheme)
+		switch cfg.Logs.EndpointScheme {
+		case "":
+		case "http", "unix":
+			cfg.Logs.Insecure = true
+		default:
+			cfg.Logs.Insecure = false
+		}
+		return cfg
+	})
 }
 
 func withEndpointForGRPC(u *url.URL) func(cfg Config) Config {
@@ -17,7 +17,11 @@ limitations under the License.
 package otlpconfig
 
 import (
+	stdgzip "compress/gzip"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/retry"
 	"go.opentelemetry.io/otel"
@@ -26,8 +30,11 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 	"net/http"
 	"path"
+	"slices"
 	"strings"
 	"time"
 )
@@ -39,6 +46,12 @@ const (
 	// DefaultTimeout is a default max waiting time for the backend to process
 	// each logs batch.
 	DefaultTimeout time.Duration = 10 * time.Second
+	// disabledKeepalivesIdleTimeout is the time after the last export when a
+	// gRPC connection with disabled keepalives goes idle and is closed.
+	disabledKeepalivesIdleTimeout = time.Second
+	// defaultMinConnectTimeout is the minimum time given by gRPC to a
+	// connection attempt by default.
+	defaultMinConnectTimeout = 20 * time.Second
 )
 
 type (
@@ -52,10 +65,52 @@ type (
 		Timeout     time.Duration
 		URLPath     string
 
+		// CredentialHeaders is true if Headers carry the credentials of a
+		// direct export, see WithCredentialHeaders.
+		CredentialHeaders bool
+		// EndpointScheme is the scheme of the endpoint URL read from the
+		// environment, empty if the endpoint was set with WithEndpoint.
+		EndpointScheme string
+		// TLSServerName overrides the name verified in the certificate of the
+		// endpoint, see WithTLSServerName.
+		TLSServerName string
+		// TimeoutPerAttempt bounds each attempt of an export, zero if only
+		// Timeout bounds the export, see WithTimeoutPerAttempt.
+		TimeoutPerAttempt time.Duration
+		// DisableKeepalives closes the connections once the exports are done
+		// rather than keeping them open for the next ones.
+		DisableKeepalives bool
+		// SensitiveHeaderKeys are the names of headers whose values are
+		// redacted on top of the credential headers, see RedactError.
+		SensitiveHeaderKeys []string
+
 		// gRPC configurations
 		GRPCCredentials credentials.TransportCredentials
 
+		// HTTP configurations
 		HTTPClient *http.Client
+		// GzipCompressionLevel is the level used by the gzip.Writer when
+		// Compression is GzipCompression.
+		GzipCompressionLevel int
+		// CompressionThreshold is the size in bytes under which payloads are
+		// sent uncompressed even if Compression is GzipCompression.
+		CompressionThreshold int
+		// RequestEditors are run in order on each HTTP request before it is
+		// sent.
+		RequestEditors []func(*http.Request) error
+		// ProtobufFallback switches from JSON to protobuf payloads when the
+		// endpoint rejects JSON with a 415 status.
+		ProtobufFallback bool
+		// StreamingBody streams the encoded payloads with a chunked transfer
+		// encoding rather than encoding them in memory before the request.
+		StreamingBody bool
+		// RoundTripperWrappers wrap the transport of the HTTP client, the
+		// first one being the outermost.
+		RoundTripperWrappers []func(http.RoundTripper) http.RoundTripper
+		// MaxIdleConnsPerHost and MaxConnsPerHost tune the connection pool of
+		// the default transport, 0 keeps the net/http defaults.
+		MaxIdleConnsPerHost int
+		MaxConnsPerHost     int
 	}
 
 	Config struct {
@@ -65,10 +120,46 @@ type (
 		RetryConfig retry.Config
 
 		// gRPC configurations
+		// ReconnectionPeriod is the base delay of the backoff between the
+		// connection attempts, ConnectTimeout the minimum time given to each
+		// of them. 0 keeps the gRPC defaults.
 		ReconnectionPeriod time.Duration
+		ConnectTimeout     time.Duration
 		ServiceConfig      string
 		DialOptions        []grpc.DialOption
 		GRPCConn           *grpc.ClientConn
+
+		// ReconnectBackoff, if set, is the backoff between the connection
+		// attempts, in place of the one derived from ReconnectionPeriod.
+		ReconnectBackoff *backoff.Config
+
+		// MaxCallSendMsgSize and MaxCallRecvMsgSize are the maximum sizes in
+		// bytes of the messages sent and received, 0 keeps the gRPC defaults.
+		MaxCallSendMsgSize int
+		MaxCallRecvMsgSize int
+		// InitialWindowSize and InitialConnWindowSize are the HTTP/2 flow
+		// control windows of the streams and the connection, 0 keeps the
+		// gRPC defaults.
+		InitialWindowSize     int32
+		InitialConnWindowSize int32
+		// OutgoingMetadataFunc returns metadata added to each export.
+		OutgoingMetadataFunc func(context.Context) metadata.MD
+		// UnaryInterceptors are chained around the export RPC, in order.
+		UnaryInterceptors []grpc.UnaryClientInterceptor
+		// Target, if set, is the gRPC target dialed verbatim in place of
+		// Logs.Endpoint.
+		Target string
+		// GRPCCompressor, if set, is the name of the registered gRPC
+		// compressor used in place of Logs.Compression.
+		GRPCCompressor string
+		// StatsHandlers are notified of the stats of the export RPCs.
+		StatsHandlers []stats.Handler
+		// WaitForReady makes the export RPCs wait for a ready connection
+		// within their deadline instead of failing fast.
+		WaitForReady bool
+
+		// DebugLogger, if set, is passed internal diagnostics of the client.
+		DebugLogger func(format string, args ...any)
 	}
 )
 
@@ -89,18 +180,27 @@ func CleanPath(urlPath string, defaultPath string) string {
 func NewHTTPConfig(opts ...HTTPOption) Config {
 	cfg := Config{
 		Logs: SignalConfig{
-			Endpoint:    fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorHTTPPort),
-			URLPath:     DefaultLogsPath,
-			Compression: NoCompression,
-			Timeout:     DefaultTimeout,
+			Endpoint:             fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorHTTPPort),
+			URLPath:              DefaultLogsPath,
+			Compression:          NoCompression,
+			Timeout:              DefaultTimeout,
+			GzipCompressionLevel: stdgzip.DefaultCompression,
 		},
 		RetryConfig: retry.DefaultConfig,
 	}
-	cfg = ApplyHTTPEnvConfigs(cfg)
+	if !ignoresEnv(opts) {
+		cfg = ApplyHTTPEnvConfigs(cfg)
+	}
+	cfg = applyConfigFiles(cfg, opts, GenericOption.ApplyHTTPOption)
 	for _, opt := range opts {
 		cfg = opt.ApplyHTTPOption(cfg)
 	}
 	cfg.Logs.URLPath = CleanPath(cfg.Logs.URLPath, DefaultLogsPath)
+	validateCredentials(cfg)
+	validateTransportSecurity(cfg)
+	if cfg.Logs.TLSServerName != "" {
+		cfg.Logs.TLSCfg = withServerName(cfg.Logs.TLSCfg, cfg.Logs.TLSServerName)
+	}
 	return cfg
 }
 
@@ -133,12 +233,29 @@ func NewGRPCConfig(opts ...GRPCOption) Config {
 			Timeout:     DefaultTimeout,
 		},
 		RetryConfig: retry.DefaultConfig,
-		DialOptions: []grpc.DialOption{grpc.WithUserAgent(GetUserAgentHeader())},
 	}
-	cfg = ApplyGRPCEnvConfigs(cfg)
+	if !ignoresEnv(opts) {
+		cfg = ApplyGRPCEnvConfigs(cfg)
+	}
+	cfg = applyConfigFiles(cfg, opts, GenericOption.ApplyGRPCOption)
 	for _, opt := range opts {
 		cfg = opt.ApplyGRPCOption(cfg)
 	}
+	if cfg.Target != "" {
+		cfg.Logs.Endpoint = cfg.Target
+		cfg.Logs.EndpointScheme = ""
+	}
+
+	validateCredentials(cfg)
+	validateTransportSecurity(cfg)
+	if cfg.Logs.TLSServerName != "" && !cfg.Logs.Insecure {
+		cfg.Logs = withGRPCServerName(cfg.Logs)
+	}
+
+	// The dial options set with WithDialOption are appended last so that they
+	// take precedence over the ones derived from the configuration.
+	userDialOptions := cfg.DialOptions
+	cfg.DialOptions = []grpc.DialOption{grpc.WithUserAgent(GetUserAgentHeader())}
 
 	if cfg.ServiceConfig != "" {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultServiceConfig(cfg.ServiceConfig))
@@ -154,23 +271,66 @@ func NewGRPCConfig(opts ...GRPCOption) Config {
 		cfg.Logs.GRPCCredentials = creds
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithTransportCredentials(creds))
 	}
-	if cfg.Logs.Compression == GzipCompression {
+	if cfg.GRPCCompressor != "" {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(cfg.GRPCCompressor)))
+	} else if cfg.Logs.Compression == GzipCompression {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
 	}
-	if len(cfg.DialOptions) != 0 {
-		cfg.DialOptions = append(cfg.DialOptions, cfg.DialOptions...)
+	if cfg.WaitForReady {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
 	}
-	if cfg.ReconnectionPeriod != 0 {
-		p := grpc.ConnectParams{
-			Backoff:           backoff.DefaultConfig,
-			MinConnectTimeout: cfg.ReconnectionPeriod,
-		}
+	if cfg.MaxCallSendMsgSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(cfg.MaxCallSendMsgSize)))
+	}
+	if cfg.MaxCallRecvMsgSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.MaxCallRecvMsgSize)))
+	}
+	if cfg.InitialWindowSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithInitialWindowSize(cfg.InitialWindowSize))
+	}
+	if cfg.InitialConnWindowSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithInitialConnWindowSize(cfg.InitialConnWindowSize))
+	}
+	if p, ok := connectParams(cfg); ok {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithConnectParams(p))
 	}
+	if len(cfg.UnaryInterceptors) > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithChainUnaryInterceptor(cfg.UnaryInterceptors...))
+	}
+	if cfg.Logs.DisableKeepalives {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithIdleTimeout(disabledKeepalivesIdleTimeout))
+	}
+	for _, h := range cfg.StatsHandlers {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithStatsHandler(h))
+	}
+	cfg.DialOptions = append(cfg.DialOptions, userDialOptions...)
 
 	return cfg
 }
 
+// connectParams returns the connection parameters set by ReconnectionPeriod,
+// ReconnectBackoff and ConnectTimeout, and false if none is set. The unset ones
+// keep their gRPC defaults.
+func connectParams(cfg Config) (grpc.ConnectParams, bool) {
+	if cfg.ReconnectionPeriod <= 0 && cfg.ReconnectBackoff == nil && cfg.ConnectTimeout <= 0 {
+		return grpc.ConnectParams{}, false
+	}
+	p := grpc.ConnectParams{
+		Backoff:           backoff.DefaultConfig,
+		MinConnectTimeout: defaultMinConnectTimeout,
+	}
+	if cfg.ReconnectBackoff != nil {
+		p.Backoff = *cfg.ReconnectBackoff
+	} else if cfg.ReconnectionPeriod > 0 {
+		p.Backoff.BaseDelay = cfg.ReconnectionPeriod
+		p.Backoff.MaxDelay = max(p.Backoff.MaxDelay, cfg.ReconnectionPeriod)
+	}
+	if cfg.ConnectTimeout > 0 {
+		p.MinConnectTimeout = cfg.ConnectTimeout
+	}
+	return p, true
+}
+
 type (
 	// GenericOption applies an option to the HTTP or gRPC driver.
 	GenericOption interface {
@@ -275,11 +435,30 @@ func NewGRPCOption(fn func(cfg Config) Config) GRPCOption {
 	return &grpcOption{fn: fn}
 }
 
+// withoutEnvOption is the option returned by WithoutEnvironmentConfig. It does
+// not change the Config, it is looked up before the environment is read.
+type withoutEnvOption struct{}
+
+func (withoutEnvOption) ApplyGRPCOption(cfg Config) Config { return cfg }
+func (withoutEnvOption) ApplyHTTPOption(cfg Config) Config { return cfg }
+func (withoutEnvOption) private()                          {}
+
+// ignoresEnv returns true if opts contains WithoutEnvironmentConfig.
+func ignoresEnv[T any](opts []T) bool {
+	for _, opt := range opts {
+		if _, ok := any(opt).(withoutEnvOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // Generic Options
 
 func WithEndpoint(endpoint string) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Logs.Endpoint = endpoint
+		cfg.Logs.EndpointScheme = ""
 		return cfg
 	})
 }
@@ -305,16 +484,126 @@ func WithRetry(rc retry.Config) GenericOption {
 	})
 }
 
+// WithRetryDisabled disables the retry configuration set so far, keeping its
+// intervals.
+func WithRetryDisabled() GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.Enabled = false
+		return cfg
+	})
+}
+
+// WithRetryInitialInterval sets only the InitialInterval of the retry
+// configuration set so far.
+func WithRetryInitialInterval(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.InitialInterval = d
+		return cfg
+	})
+}
+
+// WithRetryMaxInterval sets only the MaxInterval of the retry configuration
+// set so far.
+func WithRetryMaxInterval(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.MaxInterval = d
+		return cfg
+	})
+}
+
 func WithTLSClientConfig(tlsCfg *tls.Config) GenericOption {
 	return newSplitOption(func(cfg Config) Config {
 		cfg.Logs.TLSCfg = tlsCfg.Clone()
 		return cfg
 	}, func(cfg Config) Config {
+		cfg.Logs.TLSCfg = tlsCfg.Clone()
 		cfg.Logs.GRPCCredentials = credentials.NewTLS(tlsCfg)
 		return cfg
 	})
 }
 
+// WithTLSServerName sets the name verified in the certificate of the endpoint,
+// when it is reached through an IP address or a proxy. It is applied to the
+// TLS configuration and credentials set by the other options.
+func WithTLSServerName(name string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Logs.TLSServerName = name
+		return cfg
+	})
+}
+
+// withServerName returns a copy of tlsCfg, or a new configuration if nil,
+// verifying name.
+func withServerName(tlsCfg *tls.Config, name string) *tls.Config {
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{}
+	} else {
+		tlsCfg = tlsCfg.Clone()
+	}
+	tlsCfg.ServerName = name
+	return tlsCfg
+}
+
+// withGRPCServerName overrides the server name of the gRPC credentials,
+// creating the default TLS credentials if none were set.
+func withGRPCServerName(sc SignalConfig) SignalConfig {
+	if sc.TLSCfg != nil {
+		sc.TLSCfg = withServerName(sc.TLSCfg, sc.TLSServerName)
+	}
+	if sc.GRPCCredentials == nil {
+		sc.GRPCCredentials = credentials.NewTLS(withServerName(nil, sc.TLSServerName))
+		return sc
+	}
+	sc.GRPCCredentials = sc.GRPCCredentials.Clone()
+	// nolint:staticcheck // ignoring OverrideServerName is deprecated ERR because the credentials may not come from a TLS configuration.
+	_ = sc.GRPCCredentials.OverrideServerName(sc.TLSServerName)
+	return sc
+}
+
+// WithRootCAs appends the PEM encoded certificates to the root CAs of the
+// effective TLS configuration. Unlike WithTLSClientConfig it does not replace
+// the configuration, so multiple calls accumulate trust anchors.
+func WithRootCAs(certPEM ...[]byte) GenericOption {
+	appendRootCAs := func(cfg Config) Config {
+		if cfg.Logs.TLSCfg == nil {
+			cfg.Logs.TLSCfg = &tls.Config{}
+		} else {
+			cfg.Logs.TLSCfg = cfg.Logs.TLSCfg.Clone()
+		}
+		if cfg.Logs.TLSCfg.RootCAs == nil {
+			cfg.Logs.TLSCfg.RootCAs = x509.NewCertPool()
+		} else {
+			cfg.Logs.TLSCfg.RootCAs = cfg.Logs.TLSCfg.RootCAs.Clone()
+		}
+		for _, pem := range certPEM {
+			if ok := cfg.Logs.TLSCfg.RootCAs.AppendCertsFromPEM(pem); !ok {
+				otel.Handle(errors.New("failed to append certificate to the cert pool"))
+			}
+		}
+		return cfg
+	}
+	return newSplitOption(appendRootCAs, func(cfg Config) Config {
+		cfg = appendRootCAs(cfg)
+		cfg.Logs.GRPCCredentials = credentials.NewTLS(cfg.Logs.TLSCfg)
+		return cfg
+	})
+}
+
+// WithDebugLogger sets the function passed internal diagnostics of the
+// client.
+func WithDebugLogger(fn func(format string, args ...any)) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.DebugLogger = fn
+		return cfg
+	})
+}
+
+// WithoutEnvironmentConfig makes the Config be built from the defaults and the
+// other options only, ignoring the OTEL_EXPORTER_OTLP_* environment variables.
+func WithoutEnvironmentConfig() GenericOption {
+	return withoutEnvOption{}
+}
+
 func WithInsecure() GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Logs.Insecure = true
@@ -336,13 +625,30 @@ func WithHeaders(headers map[string]string) GenericOption {
 	})
 }
 
+// WithTimeout sets the export timeout. A zero or negative duration, which
+// would make every export fail immediately, sets the DefaultTimeout instead.
 func WithTimeout(duration time.Duration) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
+		if duration <= 0 {
+			duration = DefaultTimeout
+		}
 		cfg.Logs.Timeout = duration
 		return cfg
 	})
 }
 
+// WithTimeoutPerAttempt sets the timeout of each attempt of an export. A zero
+// or negative duration disables it.
+func WithTimeoutPerAttempt(duration time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if duration < 0 {
+			duration = 0
+		}
+		cfg.Logs.TimeoutPerAttempt = duration
+		return cfg
+	})
+}
+
 func WithProtocol(protocol Protocol) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Logs.Protocol = protocol
@@ -350,6 +656,91 @@ func WithProtocol(protocol Protocol) GenericOption {
 	})
 }
 
+// WithGzipCompressionLevel sets the level of the gzip.Writer used for HTTP
+// payloads. Levels outside the range accepted by compress/gzip are reported
+// and ignored.
+func WithGzipCompressionLevel(level int) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		if level < stdgzip.HuffmanOnly || level > stdgzip.BestCompression {
+			otel.Handle(fmt.Errorf("invalid gzip compression level: %d, using %d", level, cfg.Logs.GzipCompressionLevel))
+			return cfg
+		}
+		cfg.Logs.GzipCompressionLevel = level
+		return cfg
+	})
+}
+
+// WithCompressionThreshold sets the size in bytes under which HTTP payloads
+// are not compressed. A zero or negative size compresses all payloads.
+func WithCompressionThreshold(size int) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.CompressionThreshold = size
+		return cfg
+	})
+}
+
+// WithProtobufFallback enables the switch from JSON to protobuf payloads when
+// the endpoint rejects JSON.
+func WithProtobufFallback() HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.ProtobufFallback = true
+		return cfg
+	})
+}
+
+// WithStreamingBody enables the streaming of the HTTP payloads.
+func WithStreamingBody() HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.StreamingBody = true
+		return cfg
+	})
+}
+
+// WithRoundTripperWrapper appends fn to the functions wrapping the transport of
+// the HTTP client.
+func WithRoundTripperWrapper(fn func(http.RoundTripper) http.RoundTripper) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.RoundTripperWrappers = append(slices.Clip(cfg.Logs.RoundTripperWrappers), fn)
+		return cfg
+	})
+}
+
+// WithRequestEditorFunc appends fn to the functions run on each HTTP request
+// before it is sent.
+func WithRequestEditorFunc(fn func(*http.Request) error) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.RequestEditors = append(slices.Clip(cfg.Logs.RequestEditors), fn)
+		return cfg
+	})
+}
+
+// WithDisableKeepalives closes the connections to the endpoint once the
+// exports are done, see SignalConfig.DisableKeepalives.
+func WithDisableKeepalives() GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Logs.DisableKeepalives = true
+		return cfg
+	})
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections to the
+// endpoint kept by the default transport, see SignalConfig.MaxIdleConnsPerHost.
+func WithMaxIdleConnsPerHost(n int) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.MaxIdleConnsPerHost = n
+		return cfg
+	})
+}
+
+// WithMaxConnsPerHost sets the maximum number of connections to the endpoint
+// opened by the default transport, see SignalConfig.MaxConnsPerHost.
+func WithMaxConnsPerHost(n int) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Logs.MaxConnsPerHost = n
+		return cfg
+	})
+}
+
 func WithHTTPClient(c *http.Client) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Logs.HTTPClient = c
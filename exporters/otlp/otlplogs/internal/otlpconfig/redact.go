@@ -0,0 +1,95 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"slices"
+	"strings"
+)
+
+// redacted replaces the sensitive header values in error messages.
+const redacted = "[REDACTED]"
+
+// WithSensitiveHeaderKeys adds names of headers whose values are redacted
+// from the errors and diagnostics of the exporter, on top of the headers
+// likely to carry credentials, e.g. Authorization or API keys.
+func WithSensitiveHeaderKeys(keys []string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Logs.SensitiveHeaderKeys = append(slices.Clip(cfg.Logs.SensitiveHeaderKeys), keys...)
+		return cfg
+	})
+}
+
+// isSensitiveHeader returns true if the value of the header must be redacted.
+func (sc SignalConfig) isSensitiveHeader(name string) bool {
+	if isCredentialHeader(name) {
+		return true
+	}
+	for _, key := range sc.SensitiveHeaderKeys {
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactError returns err with the values of the sensitive headers of sc
+// replaced by [REDACTED] in its message, e.g. when an endpoint echoes the
+// credentials it rejected. The returned error wraps err, err is returned as is
+// if its message contains no sensitive value.
+func (sc SignalConfig) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redactedMsg := sc.Redact(msg)
+	if redactedMsg == msg {
+		return err
+	}
+	return &redactedError{err: err, msg: redactedMsg}
+}
+
+// Redact returns s with the values of the sensitive headers of sc replaced by
+// [REDACTED]. The credentials of a value with an authentication scheme, e.g.
+// "Bearer token", are also redacted on their own.
+func (sc SignalConfig) Redact(s string) string {
+	for name, value := range sc.Headers {
+		if !sc.isSensitiveHeader(name) {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, value, redacted)
+		if _, credentials, ok := strings.Cut(value, " "); ok {
+			if credentials = strings.TrimSpace(credentials); credentials != "" {
+				s = strings.ReplaceAll(s, credentials, redacted)
+			}
+		}
+	}
+	return s
+}
+
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,91 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRedactError(t *testing.T) {
+	errRejected := errors.New("rejected")
+	sc := SignalConfig{
+		Headers: map[string]string{
+			"Authorization": "Bearer s3cr3t",
+			"X-Api-Key":     "k3y",
+			"X-Custom-Key":  "cust0m",
+			"X-Environment": "prod",
+		},
+		SensitiveHeaderKeys: []string{"x-custom-key"},
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil"},
+		{
+			name: "no sensitive value",
+			err:  errors.New("failed to send: 500 Internal Server Error in prod"),
+			want: "failed to send: 500 Internal Server Error in prod",
+		},
+		{
+			name: "authorization header",
+			err:  errors.New("invalid header Authorization: Bearer s3cr3t"),
+			want: "invalid header Authorization: [REDACTED]",
+		},
+		{
+			name: "authorization credentials",
+			err:  errors.New("unknown token s3cr3t"),
+			want: "unknown token [REDACTED]",
+		},
+		{
+			name: "api key and sensitive key",
+			err:  errors.New("x-api-key=k3y x-custom-key=cust0m"),
+			want: "x-api-key=[REDACTED] x-custom-key=[REDACTED]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sc.RedactError(tt.err)
+			if tt.err == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.EqualError(t, got, tt.want)
+		})
+	}
+
+	wrapped := sc.RedactError(errors.Join(errRejected, errors.New("token s3cr3t")))
+	assert.ErrorIs(t, wrapped, errRejected, "the redacted error wraps the original")
+	assert.NotContains(t, wrapped.Error(), "s3cr3t")
+}
+
+func TestWithSensitiveHeaderKeys(t *testing.T) {
+	cfg := NewHTTPConfig(asHTTPOptions([]GenericOption{
+		WithHeaders(map[string]string{"X-Custom-Key": "cust0m"}),
+		WithSensitiveHeaderKeys([]string{"X-Custom-Key"}),
+		WithSensitiveHeaderKeys([]string{"X-Other"}),
+	})...)
+	assert.Equal(t, []string{"X-Custom-Key", "X-Other"}, cfg.Logs.SensitiveHeaderKeys)
+	assert.EqualError(t, cfg.Logs.RedactError(errors.New("key cust0m")), "key [REDACTED]")
+
+	unset := NewHTTPConfig(asHTTPOptions([]GenericOption{WithHeaders(map[string]string{"X-Custom-Key": "cust0m"})})...)
+	assert.EqualError(t, unset.Logs.RedactError(errors.New("key cust0m")), "key cust0m")
+}
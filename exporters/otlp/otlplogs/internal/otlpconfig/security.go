@@ -0,0 +1,51 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+var errConflictingSecurity = errors.New("conflicting transport security configuration")
+
+// validateTransportSecurity reports, with otel.Handle, the configurations
+// asking for both a secure and an insecure connection and which one is used.
+// The Insecure setting applied last, by WithInsecure, WithSecure or the
+// environment, decides the connection, except for gRPC transport credentials
+// which take precedence over it. The scheme of a gRPC target is a resolver
+// scheme and is not checked.
+func validateTransportSecurity(cfg Config) {
+	scheme := cfg.Logs.EndpointScheme
+	if s, _, found := strings.Cut(cfg.Logs.Endpoint, "://"); found && cfg.Target == "" {
+		scheme = strings.ToLower(s)
+	}
+
+	switch {
+	case cfg.Logs.Insecure && cfg.Logs.GRPCCredentials != nil:
+		otel.Handle(fmt.Errorf("%w: insecure connection and transport credentials both set, using the transport credentials", errConflictingSecurity))
+	case cfg.Logs.Insecure && cfg.Logs.TLSCfg != nil:
+		otel.Handle(fmt.Errorf("%w: insecure connection and TLS configuration both set, using an insecure connection and ignoring the TLS configuration", errConflictingSecurity))
+	case cfg.Logs.Insecure && scheme == "https":
+		otel.Handle(fmt.Errorf("%w: insecure connection set for the https endpoint %q, using an insecure connection", errConflictingSecurity, cfg.Logs.Endpoint))
+	case !cfg.Logs.Insecure && scheme == "http":
+		otel.Handle(fmt.Errorf("%w: secure connection set for the http endpoint %q, using a secure connection", errConflictingSecurity, cfg.Logs.Endpoint))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlpconfig
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/credentials"
+)
+
+// captureErrors returns the errors passed to otel.Handle during the test.
+func captureErrors(t *testing.T) func() []error {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+	return func() []error { return errs }
+}
+
+func TestValidateTransportSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		opts     []GenericOption
+		insecure bool
+		want     string
+	}{
+		{name: "default"},
+		{name: "https endpoint", env: "https://collector:4318"},
+		{name: "http endpoint", env: "http://collector:4318", insecure: true},
+		{name: "insecure", opts: []GenericOption{WithInsecure()}, insecure: true},
+		{
+			name:     "insecure with https endpoint",
+			env:      "https://collector:4318",
+			opts:     []GenericOption{WithInsecure()},
+			insecure: true,
+			want:     `insecure connection set for the https endpoint "collector:4318", using an insecure connection`,
+		},
+		{
+			name:     "insecure with https scheme in endpoint",
+			opts:     []GenericOption{WithEndpoint("https://collector:4318"), WithInsecure()},
+			insecure: true,
+			want:     "insecure connection set for the https endpoint",
+		},
+		{
+			name:     "insecure with endpoint replacing https endpoint",
+			env:      "https://collector:4318",
+			opts:     []GenericOption{WithEndpoint("localhost:4318"), WithInsecure()},
+			insecure: true,
+		},
+		{
+			name:     "insecure with TLS config",
+			opts:     []GenericOption{WithTLSClientConfig(&tls.Config{ServerName: "collector"}), WithInsecure()},
+			insecure: true,
+			want:     "insecure connection and TLS configuration both set, using an insecure connection and ignoring the TLS configuration",
+		},
+		{
+			name: "secure with http endpoint",
+			env:  "http://collector:4318",
+			opts: []GenericOption{WithSecure()},
+			want: `secure connection set for the http endpoint "collector:4318", using a secure connection`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.env)
+			errs := captureErrors(t)
+
+			cfg := NewHTTPConfig(asHTTPOptions(tt.opts)...)
+			assert.Equal(t, tt.insecure, cfg.Logs.Insecure)
+			got := errs()
+			if tt.want == "" {
+				assert.Empty(t, got)
+				return
+			}
+			if assert.Len(t, got, 1) {
+				assert.True(t, errors.Is(got[0], errConflictingSecurity))
+				assert.Contains(t, got[0].Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransportSecurityGRPC(t *testing.T) {
+	t.Run("insecure with TLS config", func(t *testing.T) {
+		errs := captureErrors(t)
+		NewGRPCConfig(asGRPCOptions([]GenericOption{WithTLSClientConfig(&tls.Config{}), WithInsecure()})...)
+		if assert.Len(t, errs(), 1) {
+			assert.Contains(t, errs()[0].Error(), "insecure connection and transport credentials both set, using the transport credentials")
+		}
+	})
+
+	t.Run("insecure with transport credentials", func(t *testing.T) {
+		errs := captureErrors(t)
+		NewGRPCConfig(WithInsecure(), NewGRPCOption(func(cfg Config) Config {
+			cfg.Logs.GRPCCredentials = credentials.NewTLS(&tls.Config{})
+			return cfg
+		}))
+		assert.Len(t, errs(), 1)
+	})
+
+	t.Run("TLS config", func(t *testing.T) {
+		errs := captureErrors(t)
+		NewGRPCConfig(asGRPCOptions([]GenericOption{WithTLSClientConfig(&tls.Config{})})...)
+		assert.Empty(t, errs())
+	})
+}
@@ -14,7 +14,11 @@
 
 package internal
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+)
 
 // PartialSuccess represents the underlying error for all handling
 // OTLP partial success messages.  Use `errors.Is(err,
@@ -46,3 +50,29 @@ func LogRecordPartialSuccessError(itemsRejected int64, errorMessage string) erro
 		RejectedKind:  "logs",
 	}
 }
+
+type partialSuccessHandlerKey struct{}
+
+// ContextWithPartialSuccessHandler returns a copy of parent in which handler
+// is called by HandlePartialSuccess, after the handler of parent if any.
+func ContextWithPartialSuccessHandler(parent context.Context, handler func(PartialSuccess)) context.Context {
+	if prev, ok := parent.Value(partialSuccessHandlerKey{}).(func(PartialSuccess)); ok {
+		next := handler
+		handler = func(ps PartialSuccess) {
+			prev(ps)
+			next(ps)
+		}
+	}
+	return context.WithValue(parent, partialSuccessHandlerKey{}, handler)
+}
+
+// HandlePartialSuccess reports a partial success error to the OTel error
+// handler and to the partial success handler of ctx, if any.
+func HandlePartialSuccess(ctx context.Context, err error) {
+	otel.Handle(err)
+	if ps, ok := err.(PartialSuccess); ok {
+		if handler, ok := ctx.Value(partialSuccessHandlerKey{}).(func(PartialSuccess)); ok {
+			handler(ps)
+		}
+	}
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package internal
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 )
 
 func requireErrorString(t *testing.T, expect string, err error) {
@@ -42,3 +45,33 @@ func TestPartialSuccessFormat(t *testing.T) {
 	requireErrorString(t, "what happened (10 logs rejected)", LogRecordPartialSuccessError(10, "what happened"))
 	requireErrorString(t, "what happened (15 logs rejected)", LogRecordPartialSuccessError(15, "what happened"))
 }
+
+func TestHandlePartialSuccess(t *testing.T) {
+	var handled []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	var got []PartialSuccess
+	ctx := ContextWithPartialSuccessHandler(context.Background(), func(ps PartialSuccess) {
+		got = append(got, ps)
+	})
+
+	HandlePartialSuccess(ctx, LogRecordPartialSuccessError(3, "rejected"))
+	HandlePartialSuccess(context.Background(), LogRecordPartialSuccessError(1, "no handler"))
+	HandlePartialSuccess(ctx, errors.New("not a partial success"))
+
+	require.Len(t, handled, 3)
+	require.Equal(t, []PartialSuccess{{ErrorMessage: "rejected", RejectedItems: 3, RejectedKind: "logs"}}, got)
+}
+
+func TestContextWithPartialSuccessHandlerNested(t *testing.T) {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	var calls []string
+	ctx := ContextWithPartialSuccessHandler(context.Background(), func(PartialSuccess) { calls = append(calls, "outer") })
+	ctx = ContextWithPartialSuccessHandler(ctx, func(PartialSuccess) { calls = append(calls, "inner") })
+
+	HandlePartialSuccess(ctx, LogRecordPartialSuccessError(1, "rejected"))
+	require.Equal(t, []string{"outer", "inner"}, calls)
+}
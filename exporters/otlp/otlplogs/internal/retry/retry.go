@@ -68,6 +68,10 @@ type EvaluateFunc func(error) (bool, time.Duration)
 // RequestFunc returns a RequestFunc using the evaluate function to determine
 // if requests can be retried and based on the exponential backoff
 // configuration of c.
+//
+// Each call of the returned RequestFunc has its own backoff state: the first
+// retry of a request always waits for about InitialInterval, regardless of the
+// retries of the previous requests.
 func (c Config) RequestFunc(evaluate EvaluateFunc) RequestFunc {
 	if !c.Enabled {
 		return func(ctx context.Context, fn func(context.Context) error) error {
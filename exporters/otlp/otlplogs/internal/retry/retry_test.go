@@ -155,6 +155,51 @@ func TestBackoffRetry(t *testing.T) {
 	}), assert.AnError)
 }
 
+func TestBackoffResetBetweenRequests(t *testing.T) {
+	ev := func(error) (bool, time.Duration) { return true, 0 }
+
+	initial := 100 * time.Millisecond
+	reqFunc := Config{
+		Enabled:         true,
+		InitialInterval: initial,
+		MaxInterval:     time.Hour,
+		// Never stop retrying.
+		MaxElapsedTime: 0,
+	}.RequestFunc(ev)
+
+	origWait := waitFunc
+	var delays []time.Duration
+	waitFunc = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { waitFunc = origWait })
+
+	// failing returns a request failing n times before succeeding.
+	failing := func(n int) func(context.Context) error {
+		return func(context.Context) error {
+			if n > 0 {
+				n--
+				return assert.AnError
+			}
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, reqFunc(ctx, failing(8)))
+	if assert.Len(t, delays, 8) {
+		assert.Greater(t, delays[7], 5*initial, "backoff not increased")
+	}
+
+	delays = nil
+	assert.NoError(t, reqFunc(ctx, failing(1)))
+	delta := math.Ceil(float64(initial) * backoff.DefaultRandomizationFactor)
+	if assert.Len(t, delays, 1) {
+		assert.InDelta(t, initial, delays[0], delta, "backoff not reset after a successful request")
+	}
+}
+
 func TestBackoffRetryCanceledContext(t *testing.T) {
 	ev := func(error) (bool, time.Duration) { return true, 0 }
 
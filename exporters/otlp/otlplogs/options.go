@@ -20,10 +20,26 @@ import (
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlpconfig"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogsgrpc"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
+	"time"
 )
 
 type ExporterConfig struct {
-	client Client
+	client         Client
+	exportCallback func(ExportResult)
+	startTimeout   time.Duration
+	bodyMarshaler  BodyMarshaler
+
+	flattenScopeAttributes       bool
+	defaultTimestampFromObserved bool
+
+	maxPayloadSize int
+
+	circuitBreakerFailures int
+	circuitBreakerCooldown time.Duration
+
+	initialBatchTimeout time.Duration
+
+	retryRejectedRecords bool
 }
 
 type ExporterOption interface {
@@ -65,3 +81,120 @@ func WithClient(client Client) ExporterOption {
 		return cfg
 	})
 }
+
+// WithExportCallback sets a callback invoked once after each export of a
+// batch of logs with the result of the export. It is called synchronously by
+// Export and must not block.
+func WithExportCallback(callback func(ExportResult)) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.exportCallback = callback
+		return cfg
+	})
+}
+
+// WithStartTimeout bounds the time NewExporter waits for the client to start,
+// e.g. for a gRPC client dialing with grpc.WithBlock, before returning an
+// error. It is unrelated to the timeout of exports. A zero or negative duration,
+// the default, does not bound the start other than by the context passed to
+// NewExporter.
+func WithStartTimeout(timeout time.Duration) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.startTimeout = timeout
+		return cfg
+	})
+}
+
+// WithBodyMarshaler sets the BodyMarshaler converting the body of exported log
+// records. DefaultBodyMarshaler is used if unset.
+func WithBodyMarshaler(marshaler BodyMarshaler) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.bodyMarshaler = marshaler
+		return cfg
+	})
+}
+
+// WithFlattenScopeAttributes copies the instrumentation scope of exported log
+// records onto their attributes, for backends ignoring the instrumentation
+// scope: its name and version as the otel.scope.name and otel.scope.version
+// attributes, and its attributes prefixed by otel.scope. The attributes of a
+// record win on conflict. It is disabled by default.
+func WithFlattenScopeAttributes() ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.flattenScopeAttributes = true
+		return cfg
+	})
+}
+
+// WithDefaultTimestampFromObserved sets the zero timestamp of the exported log
+// records, as some bridges emit, to their observed timestamp, for backends
+// requiring the time of the event. By default, a zero timestamp is left unset
+// and only the observed timestamp of the record is exported. The records
+// emitted without a timestamp are always exported with their observed
+// timestamp as timestamp.
+func WithDefaultTimestampFromObserved() ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.defaultTimestampFromObserved = true
+		return cfg
+	})
+}
+
+// WithMaxPayloadSize sets the maximum encoded size in bytes of the requests
+// sent by the client. A batch of logs exceeding it is split in as many
+// requests as needed, by recursively halving it, instead of failing. A log
+// record exceeding it alone is not exported, Export then returns an error after
+// exporting the other records. A zero or negative size, the default, does not
+// limit the size of the requests.
+//
+// The size is the one of the uncompressed protobuf request, sending the same
+// logs as JSON takes more bytes.
+func WithMaxPayloadSize(size int) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.maxPayloadSize = size
+		return cfg
+	})
+}
+
+// WithCircuitBreaker makes the exporter fast-fail its exports for cooldown
+// after failures consecutive failed exports, instead of paying the retries and
+// timeout of the client while the endpoint is down. Once cooldown has elapsed,
+// a single export tests the recovery of the endpoint: the exports resume if it
+// succeeds, they fast-fail for another cooldown otherwise. A zero or negative
+// failures, the default, disables the circuit breaker.
+//
+// The fast-failed logs are not exported, Export returns an error for them.
+func WithCircuitBreaker(failures int, cooldown time.Duration) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.circuitBreakerFailures = failures
+		cfg.circuitBreakerCooldown = cooldown
+		return cfg
+	})
+}
+
+// WithInitialBatch makes NewExporter upload an empty batch of logs once the
+// client has started, within timeout, so that the connection to the endpoint
+// is set up before the first export instead of delaying it. A failed warmup,
+// e.g. because the collector is not ready yet, is reported to the global error
+// handler and NewExporter still returns the exporter. A zero or negative
+// timeout, the default, disables the warmup.
+func WithInitialBatch(timeout time.Duration) ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.initialBatchTimeout = timeout
+		return cfg
+	})
+}
+
+// WithRetryRejectedRecords makes the exporter upload again the log records
+// rejected by the endpoint in a partial success, up to 3 times per batch. The
+// endpoint only reports how many records it rejected, not which ones, so the
+// rejected records are assumed to be the last ones of the request: if the
+// endpoint rejected others, the records it accepted are sent again and
+// duplicated at the backend. The retries are immediate, it is meant for
+// endpoints rejecting records of a batch over a transient limit, not for
+// invalid records, which are rejected again. Partial successes are not retried
+// by default.
+func WithRetryRejectedRecords() ExporterOption {
+	return exporterOptionFunc(func(cfg ExporterConfig) ExporterConfig {
+		cfg.retryRejectedRecords = true
+		return cfg
+	})
+}
@@ -19,6 +19,7 @@ package otlplogsgrpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -26,13 +27,13 @@ import (
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlpconfig"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/retry"
 
-	"go.opentelemetry.io/otel"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -41,8 +42,16 @@ type grpcClient struct {
 	endpoint      string
 	dialOpts      []grpc.DialOption
 	metadata      metadata.MD
+	metadataFunc  func(context.Context) metadata.MD
 	exportTimeout time.Duration
 	requestFunc   retry.RequestFunc
+	debugf        func(format string, args ...any)
+
+	// attemptTimeout bounds each attempt of an export, zero if unset.
+	attemptTimeout time.Duration
+	// redactError redacts the sensitive header values from the errors of
+	// the exports.
+	redactError func(error) error
 
 	// stopCtx is used as a parent context for all exports. Therefore, when it
 	// is canceled with the stopFunc all exports are canceled.
@@ -73,6 +82,23 @@ func NewClient(opts ...Option) *grpcClient {
 		stopCtx:       ctx,
 		stopFunc:      cancel,
 		conn:          cfg.GRPCConn,
+		debugf:        cfg.DebugLogger,
+		metadataFunc:  cfg.OutgoingMetadataFunc,
+
+		attemptTimeout: cfg.Logs.TimeoutPerAttempt,
+		redactError:    cfg.Logs.RedactError,
+	}
+
+	if c.debugf != nil {
+		compression := "none"
+		if cfg.Logs.Compression == otlpconfig.GzipCompression {
+			compression = "gzip"
+		}
+		if c.conn != nil {
+			c.debugf("otlplogsgrpc: exporting using the provided connection to %s", c.conn.Target())
+		} else {
+			c.debugf("otlplogsgrpc: exporting to %s with %s compression", c.endpoint, compression)
+		}
 	}
 
 	if len(cfg.Logs.Headers) > 0 {
@@ -106,6 +132,33 @@ func (c *grpcClient) Start(ctx context.Context) error {
 	return nil
 }
 
+// Connect triggers the connection to the collector, which is otherwise only
+// established by the first export, and waits for it to be ready. It returns an
+// error if the connection is not ready when ctx is done, e.g. if the collector
+// is unreachable, or if the grpcClient is not started.
+func (c *grpcClient) Connect(ctx context.Context) error {
+	c.tscMu.RLock()
+	started := c.tsc != nil
+	c.tscMu.RUnlock()
+	if !started {
+		return errShutdown
+	}
+
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errShutdown
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connect to %s: %w, connection state %s", c.conn.Target(), ctx.Err(), state)
+		}
+	}
+}
+
 var errAlreadyStopped = errors.New("the grpcClient is already stopped")
 
 // Stop shuts down the grpcClient.
@@ -194,16 +247,35 @@ func (c *grpcClient) UploadLogs(ctx context.Context, protoLogs []*logspb.Resourc
 	ctx, cancel := c.exportContext(ctx)
 	defer cancel()
 
-	return c.requestFunc(ctx, func(iCtx context.Context) error {
+	var attempt, count int
+	if c.debugf != nil {
+		count = internal.LogRecordCount(protoLogs)
+	}
+	err := c.requestFunc(ctx, func(iCtx context.Context) error {
+		attempt++
+		if c.debugf != nil {
+			c.debugf("otlplogsgrpc: export attempt %d of %d log records", attempt, count)
+		}
+		if c.attemptTimeout > 0 {
+			var cancel context.CancelFunc
+			iCtx, cancel = context.WithTimeout(iCtx, c.attemptTimeout)
+			defer cancel()
+		}
 		resp, err := c.tsc.Export(iCtx, &collogspb.ExportLogsServiceRequest{
 			ResourceLogs: protoLogs,
 		})
+		if c.debugf != nil {
+			c.debugf("otlplogsgrpc: export attempt %d: %s", attempt, status.Code(err))
+		}
 		if resp != nil && resp.PartialSuccess != nil {
 			msg := resp.PartialSuccess.GetErrorMessage()
 			n := resp.PartialSuccess.GetRejectedLogRecords()
 			if n != 0 || msg != "" {
+				if c.debugf != nil {
+					c.debugf("otlplogsgrpc: partial success: %d log records rejected: %s", n, msg)
+				}
 				err := internal.LogRecordPartialSuccessError(n, msg)
-				otel.Handle(err)
+				internal.HandlePartialSuccess(iCtx, err)
 			}
 		}
 		// nil is converted to OK.
@@ -213,6 +285,8 @@ func (c *grpcClient) UploadLogs(ctx context.Context, protoLogs []*logspb.Resourc
 		}
 		return err
 	})
+	// The endpoint may echo the credentials it rejected.
+	return c.redactError(err)
 }
 
 // exportContext returns a copy of parent with an appropriate deadline and
@@ -233,8 +307,12 @@ func (c *grpcClient) exportContext(parent context.Context) (context.Context, con
 		ctx, cancel = context.WithCancel(parent)
 	}
 
-	if c.metadata.Len() > 0 {
-		ctx = metadata.NewOutgoingContext(ctx, c.metadata)
+	md := c.metadata
+	if c.metadataFunc != nil {
+		md = metadata.Join(c.metadata, c.metadataFunc(parent))
+	}
+	if md.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
 	// Unify the grpcClient stopCtx with the parent.
@@ -2,6 +2,7 @@ package otlplogsgrpc_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlplogstest"
@@ -10,8 +11,11 @@ import (
 	sdklogs "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
 	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"io"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -22,7 +26,17 @@ import (
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	"go.uber.org/goleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestMain(m *testing.M) {
@@ -397,6 +411,85 @@ func TestPartialSuccess(t *testing.T) {
 	require.Contains(t, errs[0].Error(), "2 logs rejected")
 }
 
+// compressionStatsHandler is a gRPC server stats handler recording the
+// compression of the response headers it is notified of.
+type compressionStatsHandler struct {
+	mu          sync.Mutex
+	compression []string
+}
+
+func (h *compressionStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *compressionStatsHandler) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if header, ok := s.(*stats.OutHeader); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.compression = append(h.compression, header.Compression)
+	}
+}
+
+func (h *compressionStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *compressionStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestCompressedResponses(t *testing.T) {
+	for _, compressor := range []string{"none", gzip.Name} {
+		t.Run(compressor, func(t *testing.T) {
+			var errs []error
+			otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) }))
+			t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+			var accepted []string
+			handler := &compressionStatsHandler{}
+			ln := bufconn.Listen(1 << 20)
+			srv := grpc.NewServer(
+				grpc.StatsHandler(handler),
+				grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+					accepted, _ = grpc.ClientSupportedCompressors(ctx)
+					if err := grpc.SetSendCompressor(ctx, gzip.Name); err != nil {
+						return nil, err
+					}
+					return next(ctx, req)
+				}),
+			)
+			mc := makeMockCollector(t, &mockConfig{partial: &collogspb.ExportLogsPartialSuccess{
+				RejectedLogRecords: 1,
+				ErrorMessage:       "compressed response",
+			}})
+			collogspb.RegisterLogsServiceServer(srv, mc.logsSvc)
+			go func() { _ = srv.Serve(ln) }()
+			t.Cleanup(srv.Stop)
+
+			opts := []otlplogsgrpc.Option{
+				otlplogsgrpc.WithInsecure(),
+				otlplogsgrpc.WithEndpoint("bufnet"),
+				otlplogsgrpc.WithDialOption(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return ln.DialContext(ctx)
+				})),
+			}
+			if compressor == gzip.Name {
+				opts = append(opts, otlplogsgrpc.WithCompressor(gzip.Name))
+			}
+			ctx := context.Background()
+			exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(otlplogsgrpc.NewClient(opts...)))
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			require.NoError(t, exp.Export(ctx, roLogRecords))
+			assert.Contains(t, accepted, gzip.Name)
+			handler.mu.Lock()
+			assert.Equal(t, []string{gzip.Name}, handler.compression, "the response is compressed")
+			handler.mu.Unlock()
+			require.Len(t, errs, 1, "the partial success is decoded")
+			assert.Contains(t, errs[0].Error(), "compressed response")
+		})
+	}
+}
+
 func TestCustomUserAgent(t *testing.T) {
 	customUserAgent := "custom-user-agent"
 	mc := runMockCollector(t)
@@ -411,3 +504,462 @@ func TestCustomUserAgent(t *testing.T) {
 	headers := mc.getHeaders()
 	require.Contains(t, headers.Get("user-agent")[0], customUserAgent)
 }
+
+func TestGRPCMaxCallSendMsgSize(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithGRPCMaxCallSendMsgSize(8),
+		otlplogsgrpc.WithRetry(otlplogsgrpc.RetryConfig{Enabled: false}),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	err := exp.Export(ctx, roLogRecords)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Empty(t, mc.getLogRecords())
+
+	big := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithGRPCMaxCallSendMsgSize(1024*1024),
+		otlplogsgrpc.WithGRPCMaxCallRecvMsgSize(1024*1024),
+	)
+	t.Cleanup(func() { require.NoError(t, big.Shutdown(ctx)) })
+	require.NoError(t, big.Export(ctx, roLogRecords))
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestNewExporterStartTimeout(t *testing.T) {
+	// The listener accepts connections but never speaks HTTP/2, so a blocking
+	// dial waits until its context is done.
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	client := otlplogsgrpc.NewClient(
+		otlplogsgrpc.WithInsecure(),
+		otlplogsgrpc.WithEndpoint(ln.Addr().String()),
+		otlplogsgrpc.WithDialOption(grpc.WithBlock()),
+	)
+
+	start := time.Now()
+	exp, err := otlplogs.NewExporter(context.Background(),
+		otlplogs.WithClient(client),
+		otlplogs.WithStartTimeout(100*time.Millisecond),
+	)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, exp)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// handshakeCounter is insecure TransportCredentials counting client
+// handshakes.
+type handshakeCounter struct {
+	credentials.TransportCredentials
+	handshakes atomic.Int32
+}
+
+func (c *handshakeCounter) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	c.handshakes.Add(1)
+	return c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+}
+
+func TestWithTransportCredentials(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	creds := &handshakeCounter{TransportCredentials: insecure.NewCredentials()}
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint, otlplogsgrpc.WithTransportCredentials(creds))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	assert.Equal(t, int32(1), creds.handshakes.Load())
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+type tenantKey struct{}
+
+func TestWithOutgoingMetadataFunc(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	mc := makeMockCollector(t, &mockConfig{})
+	collogspb.RegisterLogsServiceServer(srv, mc.logsSvc)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	client := otlplogsgrpc.NewClient(
+		otlplogsgrpc.WithInsecure(),
+		otlplogsgrpc.WithEndpoint("bufnet"),
+		otlplogsgrpc.WithDialOption(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		})),
+		otlplogsgrpc.WithHeaders(map[string]string{"static": "header"}),
+		otlplogsgrpc.WithOutgoingMetadataFunc(func(ctx context.Context) metadata.MD {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return metadata.Pairs("x-tenant", tenant)
+		}),
+	)
+	ctx := context.Background()
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(client))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	for _, tenant := range []string{"a", "b"} {
+		require.NoError(t, exp.Export(context.WithValue(ctx, tenantKey{}, tenant), roLogRecords))
+		headers := mc.getHeaders()
+		assert.Equal(t, []string{tenant}, headers.Get("x-tenant"))
+		assert.Equal(t, []string{"header"}, headers.Get("static"))
+	}
+}
+
+// recordingStatsHandler is a gRPC stats handler recording the methods of the
+// RPCs it is notified of.
+type recordingStatsHandler struct {
+	mu      sync.Mutex
+	methods []string
+	handled int
+}
+
+type methodKey struct{}
+
+func (h *recordingStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, methodKey{}, info.FullMethodName)
+}
+
+func (h *recordingStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handled++
+	if _, ok := s.(*stats.End); ok {
+		method, _ := ctx.Value(methodKey{}).(string)
+		h.methods = append(h.methods, method)
+	}
+}
+
+func (h *recordingStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *recordingStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestWithStatsHandler(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	mc := makeMockCollector(t, &mockConfig{})
+	collogspb.RegisterLogsServiceServer(srv, mc.logsSvc)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	handler := &recordingStatsHandler{}
+	client := otlplogsgrpc.NewClient(
+		otlplogsgrpc.WithInsecure(),
+		otlplogsgrpc.WithEndpoint("bufnet"),
+		otlplogsgrpc.WithDialOption(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		})),
+		otlplogsgrpc.WithStatsHandler(handler),
+	)
+	ctx := context.Background()
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(client))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	assert.Greater(t, handler.handled, 1)
+	assert.Equal(t, []string{"/opentelemetry.proto.collector.logs.v1.LogsService/Export"}, handler.methods)
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestWithUnaryInterceptors(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	var calls []string
+	interceptor := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			calls = append(calls, name+" "+method)
+			return invoker(metadata.AppendToOutgoingContext(ctx, "x-token", name), method, req, reply, cc, opts...)
+		}
+	}
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithUnaryInterceptors(interceptor("auth")),
+		otlplogsgrpc.WithUnaryInterceptors(interceptor("metrics")),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	const method = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	assert.Equal(t, []string{"auth " + method, "metrics " + method}, calls)
+	assert.Equal(t, []string{"auth", "metrics"}, mc.getHeaders().Get("x-token"))
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestConnect(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	connectCtx, cancel := contextWithTimeout(ctx, t, 10*time.Second)
+	defer cancel()
+	require.NoError(t, exp.Connect(connectCtx))
+	// The connection is reused by the exports.
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestConnectUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	endpoint := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, endpoint)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	connectCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	err = exp.Connect(connectCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, endpoint)
+}
+
+func TestConnectNotStarted(t *testing.T) {
+	client := otlplogsgrpc.NewClient(otlplogsgrpc.WithInsecure())
+	assert.Error(t, client.Connect(context.Background()))
+}
+
+func TestWithTarget(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	// The target is only resolvable by this resolver, the export succeeds if
+	// it is passed to the dialer unchanged.
+	r := manual.NewBuilderWithScheme("otlptest")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: mc.endpoint}}})
+	var dialed []string
+	r.BuildCallback = func(target resolver.Target, _ resolver.ClientConn, _ resolver.BuildOptions) {
+		dialed = append(dialed, target.URL.String())
+	}
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, "unused:4317",
+		otlplogsgrpc.WithTarget("otlptest:///collector"),
+		otlplogsgrpc.WithDialOption(grpc.WithResolvers(r)),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Equal(t, []string{"otlptest:///collector"}, dialed)
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestWithRetryDisabled(t *testing.T) {
+	mc := runMockCollectorWithConfig(t, &mockConfig{
+		endpoint: "localhost:0",
+		errors: []error{
+			status.Error(codes.Unavailable, "unavailable"),
+			status.Error(codes.Unavailable, "unavailable"),
+		},
+	})
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint, otlplogsgrpc.WithRetryDisabled())
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.Equal(t, codes.Unavailable, status.Code(exp.Export(ctx, roLogRecords)))
+	mc.logsSvc.mu.RLock()
+	defer mc.logsSvc.mu.RUnlock()
+	assert.Equal(t, 1, mc.logsSvc.requests)
+}
+
+func TestWithTimeoutPerAttempt(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	var attempts int
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts++
+		if _, ok := ctx.Deadline(); !ok {
+			return status.Error(codes.Internal, "attempt without deadline")
+		}
+		if attempts == 1 {
+			// The first attempt is slow, it is cut off by its own timeout.
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithTimeout(time.Minute),
+		otlplogsgrpc.WithTimeoutPerAttempt(50*time.Millisecond),
+		otlplogsgrpc.WithRetry(otlplogsgrpc.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+		otlplogsgrpc.WithUnaryInterceptors(interceptor),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	start := time.Now()
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestRedactedHeadersInErrors(t *testing.T) {
+	mc := runMockCollectorWithConfig(t, &mockConfig{
+		endpoint: "localhost:0",
+		errors:   []error{status.Error(codes.Unauthenticated, "invalid token s3cr3t")},
+	})
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithHeaders(map[string]string{"authorization": "Bearer s3cr3t"}),
+		otlplogsgrpc.WithRetryDisabled(),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	err := exp.Export(ctx, roLogRecords)
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "the redacted error keeps its status")
+	assert.Contains(t, err.Error(), "invalid token [REDACTED]")
+	assert.NotContains(t, err.Error(), "s3cr3t")
+}
+
+// countingCompressor is a gRPC compressor sending the data uncompressed and
+// counting the compressed messages.
+type countingCompressor struct{ compressed atomic.Int32 }
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (c *countingCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	c.compressed.Add(1)
+	return nopWriteCloser{w}, nil
+}
+
+func (c *countingCompressor) Decompress(r io.Reader) (io.Reader, error) { return r, nil }
+
+func (c *countingCompressor) Name() string { return "otlptest-counting" }
+
+var testCompressor = &countingCompressor{}
+
+func init() {
+	encoding.RegisterCompressor(testCompressor)
+}
+
+func TestWithGRPCCompressor(t *testing.T) {
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	var compressors []string
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		for _, opt := range opts {
+			if c, ok := opt.(grpc.CompressorCallOption); ok {
+				compressors = append(compressors, c.CompressorType)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint,
+		otlplogsgrpc.WithCompressor("gzip"),
+		otlplogsgrpc.WithGRPCCompressor(testCompressor.Name()),
+		otlplogsgrpc.WithUnaryInterceptors(interceptor),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	compressed := testCompressor.compressed.Load()
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	assert.Equal(t, []string{testCompressor.Name()}, compressors)
+	assert.Equal(t, compressed+1, testCompressor.compressed.Load())
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestWithGRPCCompressorUnregistered(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { errs = append(errs, err) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	mc := runMockCollector(t)
+	t.Cleanup(func() { require.NoError(t, mc.stop()) })
+
+	ctx := context.Background()
+	exp := newGRPCExporter(t, ctx, mc.endpoint, otlplogsgrpc.WithGRPCCompressor("unregistered"))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unregistered gRPC compressor: 'unregistered'")
+	assert.Len(t, mc.getLogRecords(), 1)
+}
+
+func TestWithWaitForReady(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	mc := makeMockCollector(t, &mockConfig{})
+	collogspb.RegisterLogsServiceServer(srv, mc.logsSvc)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	// The collector is unreachable until reachable is set.
+	var reachable atomic.Bool
+	newExporter := func(t *testing.T, opts ...otlplogsgrpc.Option) *otlplogs.Exporter {
+		client := otlplogsgrpc.NewClient(append([]otlplogsgrpc.Option{
+			otlplogsgrpc.WithInsecure(),
+			otlplogsgrpc.WithEndpoint("bufnet"),
+			otlplogsgrpc.WithReconnectionPeriod(10 * time.Millisecond),
+			otlplogsgrpc.WithRetryDisabled(),
+			otlplogsgrpc.WithDialOption(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				if !reachable.Load() {
+					return nil, errors.New("collector unreachable")
+				}
+				return ln.DialContext(ctx)
+			})),
+		}, opts...)...)
+		exp, err := otlplogs.NewExporter(context.Background(), otlplogs.WithClient(client))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+		return exp
+	}
+	ctx := context.Background()
+
+	t.Run("fail fast", func(t *testing.T) {
+		exp := newExporter(t, otlplogsgrpc.WithTimeout(10*time.Second))
+		start := time.Now()
+		assert.Equal(t, codes.Unavailable, status.Code(exp.Export(ctx, roLogRecords)))
+		assert.Less(t, time.Since(start), 5*time.Second, "the export does not wait for its deadline")
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		exp := newExporter(t, otlplogsgrpc.WithWaitForReady(true), otlplogsgrpc.WithTimeout(100*time.Millisecond))
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(exp.Export(ctx, roLogRecords)))
+	})
+
+	t.Run("wait for ready", func(t *testing.T) {
+		exp := newExporter(t, otlplogsgrpc.WithWaitForReady(true), otlplogsgrpc.WithTimeout(10*time.Second))
+		time.AfterFunc(100*time.Millisecond, func() { reachable.Store(true) })
+		require.NoError(t, exp.Export(ctx, roLogRecords))
+		assert.Len(t, mc.getLogRecords(), 1)
+	})
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package otlplogsgrpc
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlpconfig"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/retry"
@@ -36,6 +42,12 @@ type Option interface {
 func asGRPCOptions(opts []Option) []otlpconfig.GRPCOption {
 	converted := make([]otlpconfig.GRPCOption, len(opts))
 	for i, o := range opts {
+		// Wrapped options are passed as is so that otlpconfig can recognize
+		// the ones it looks up, like WithoutEnvironmentConfig.
+		if w, ok := o.(wrappedOption); ok {
+			converted[i] = w.GRPCOption
+			continue
+		}
 		converted[i] = otlpconfig.NewGRPCOption(o.applyGRPCOption)
 	}
 	return converted
@@ -56,11 +68,74 @@ func (w wrappedOption) applyGRPCOption(cfg otlpconfig.Config) otlpconfig.Config
 	return w.ApplyGRPCOption(cfg)
 }
 
+// WithDebugLogger sets a function passed internal diagnostics of the client:
+// the endpoint and compression used, each export attempt with its outcome and
+// the partial successes returned by the collector. It is meant to find out why
+// logs do not reach the collector. Diagnostics are off by default and nothing
+// is formatted when no function is set.
+func WithDebugLogger(fn func(format string, args ...any)) Option {
+	return wrappedOption{otlpconfig.WithDebugLogger(fn)}
+}
+
+// WithCredentialHeaders configures a direct export to a vendor accepting OTLP
+// without a collector, authenticating with headers such as an API key:
+//
+//	WithEndpoint("api.honeycomb.io:443"),
+//	WithCredentialHeaders(map[string]string{
+//		"x-honeycomb-team":    apiKey,
+//		"x-honeycomb-dataset": dataset,
+//	}),
+//
+// It sets the headers, a secure connection and gzip compression. A warning is
+// logged if no header or an empty header is passed, or if an option passed
+// after it, or the environment, makes the connection to a remote endpoint
+// insecure. The same warning is logged for any header looking like
+// credentials set with WithHeaders.
+func WithCredentialHeaders(headers map[string]string) Option {
+	return wrappedOption{otlpconfig.WithCredentialHeaders(headers)}
+}
+
+// WithConfigFile reads the configuration of the exporter from the YAML or JSON
+// file at path, e.g. mounted from a Kubernetes ConfigMap:
+//
+//	endpoint: collector:4317
+//	headers:
+//	  x-tenant: team-a
+//	compression: gzip
+//	timeout: 10s
+//
+// The endpoint is a URL handled like the OTEL_EXPORTER_OTLP_ENDPOINT
+// environment variable, the compression gzip or none and the timeout a
+// duration. All the fields are optional, the protocol field is ignored by the
+// gRPC client.
+//
+// The settings of the file take precedence over the environment variables, the
+// other options take precedence over them wherever they are passed. A file
+// which cannot be read or parsed, or with an unknown field, is reported to the
+// global logger and ignored.
+func WithConfigFile(path string) Option {
+	return wrappedOption{otlpconfig.WithConfigFile(path)}
+}
+
+// WithoutEnvironmentConfig makes the options passed in code the only source of
+// configuration: the OTEL_EXPORTER_OTLP_* environment variables are ignored and
+// any setting that is not passed uses its default value. This is useful for
+// libraries embedding the exporter that do not want the environment of the host
+// to change their configuration.
+func WithoutEnvironmentConfig() Option {
+	return wrappedOption{otlpconfig.WithoutEnvironmentConfig()}
+}
+
 // WithInsecure disables grpcClient transport security for the exporter's gRPC
 // connection just like grpc.WithInsecure()
 // (https://pkg.go.dev/google.golang.org/grpc#WithInsecure) does. Note, by
 // default, grpcClient security is required unless WithInsecure is used.
 //
+// It takes precedence over an https endpoint set with the
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variable, but not over TLS or
+// transport credentials. Such conflicts are reported to the OpenTelemetry
+// error handler.
+//
 // This option has no effect if WithGRPCConn is used.
 func WithInsecure() Option {
 	return wrappedOption{otlpconfig.WithInsecure()}
@@ -74,8 +149,29 @@ func WithEndpoint(endpoint string) Option {
 	return wrappedOption{otlpconfig.WithEndpoint(endpoint)}
 }
 
+// WithTarget sets the gRPC target the exporter will connect to, passed
+// verbatim to the dialer, e.g. "xds:///collector" or
+// "dns://resolver:53/collector:4317" to use a specific name resolver. It takes
+// precedence over WithEndpoint and the OTEL_EXPORTER_OTLP_ENDPOINT
+// environment variable.
+//
+// The scheme of the target is a name resolver scheme: unlike the one of an
+// endpoint URL, it is not used to tell if the connection is insecure. Use
+// WithInsecure, WithTLSCredentials or the OTEL_EXPORTER_OTLP_INSECURE
+// environment variable to set the security of the connection.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithTarget(target string) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.Target = target
+		return cfg
+	})}
+}
+
 // WithReconnectionPeriod set the minimum amount of time between connection
-// attempts to the target endpoint.
+// attempts to the target endpoint. It is the base delay of the exponential
+// backoff between the attempts, the other backoff parameters keep their gRPC
+// defaults.
 //
 // This option has no effect if WithGRPCConn is used.
 func WithReconnectionPeriod(rp time.Duration) Option {
@@ -85,6 +181,54 @@ func WithReconnectionPeriod(rp time.Duration) Option {
 	})}
 }
 
+// WithReconnectBackoff sets the exponential backoff between the connection
+// attempts to the target endpoint, e.g. to reconnect less aggressively over a
+// high-latency link: the delay after the first failed attempt is BaseDelay and
+// it is multiplied by Multiplier after each failed attempt up to MaxDelay,
+// randomized by Jitter. By default, gRPC uses backoff.DefaultConfig. It takes
+// precedence over WithReconnectionPeriod.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithReconnectBackoff(config backoff.Config) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.ReconnectBackoff = &config
+		return cfg
+	})}
+}
+
+// WithConnectTimeout sets the minimum amount of time given to each connection
+// attempt to the target endpoint before it fails, 20 seconds by default. It is
+// unrelated to the delay between the attempts, see WithReconnectionPeriod, and
+// to the timeout of the exports.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.ConnectTimeout = timeout
+		return cfg
+	})}
+}
+
+// WithWaitForReady sets whether the exports wait for the connection to the
+// target endpoint to be ready. By default, gRPC fails fast: an export fails
+// with an Unavailable error as soon as the connection is in a transient
+// failure, e.g. while the endpoint is unreachable, and it is retried after
+// the backoff of WithRetry.
+//
+// With wait for ready, an export attempt instead blocks until the connection
+// is ready or until its deadline, the one of WithTimeoutPerAttempt or else the
+// one of WithTimeout, failing then with a DeadlineExceeded error. An
+// unreachable endpoint then holds each export for up to WithTimeout, which
+// should be kept below the export timeout of the batch processor.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithWaitForReady(waitForReady bool) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.WaitForReady = waitForReady
+		return cfg
+	})}
+}
+
 func compressorToCompression(compressor string) otlpconfig.Compression {
 	if compressor == "gzip" {
 		return otlpconfig.GzipCompression
@@ -106,11 +250,39 @@ func WithCompressor(compressor string) Option {
 	return wrappedOption{otlpconfig.WithCompression(compressorToCompression(compressor))}
 }
 
+// WithGRPCCompressor sets the gRPC compressor used to send requests by its
+// name, e.g. a zstd compressor, in place of the compression set with
+// WithCompressor or the OTEL_EXPORTER_OTLP_COMPRESSION environment variable.
+// The compressor must be registered with encoding.RegisterCompressor before
+// the client is created, an unregistered one is reported to the OpenTelemetry
+// error handler and ignored.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCCompressor(name string) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		if encoding.GetCompressor(name) == nil {
+			otel.Handle(fmt.Errorf("unregistered gRPC compressor: '%s', ignoring it", name))
+			return cfg
+		}
+		cfg.GRPCCompressor = name
+		return cfg
+	})}
+}
+
 // WithHeaders will send the provided headers with each gRPC requests.
 func WithHeaders(headers map[string]string) Option {
 	return wrappedOption{otlpconfig.WithHeaders(headers)}
 }
 
+// WithSensitiveHeaderKeys adds the names of headers set with WithHeaders
+// whose values are redacted from the errors returned by the exports and from
+// the diagnostics of the client. The values of Authorization and of the other
+// headers likely to carry credentials, e.g. API keys or tokens, are always
+// redacted. Names are case-insensitive and multiple calls accumulate.
+func WithSensitiveHeaderKeys(keys []string) Option {
+	return wrappedOption{otlpconfig.WithSensitiveHeaderKeys(keys)}
+}
+
 // WithTLSCredentials allows the connection to use TLS credentials when
 // talking to the server. It takes in grpc.TransportCredentials instead of say
 // a Certificate file or a tls.Certificate, because the retrieving of these
@@ -125,7 +297,43 @@ func WithTLSCredentials(creds credentials.TransportCredentials) Option {
 	})}
 }
 
-// WithServiceConfig defines the default gRPC service config used.
+// WithTransportCredentials is an alias of WithTLSCredentials for the
+// credentials that are not TLS ones, e.g. ALTS, xDS or a custom credentials
+// provider.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return WithTLSCredentials(creds)
+}
+
+// WithTLSServerName sets the name verified in the certificate of the
+// collector, when it is reached through an IP address or a proxy. It applies
+// to the TLS configuration set by the other options or to the default one.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithTLSServerName(name string) Option {
+	return wrappedOption{otlpconfig.WithTLSServerName(name)}
+}
+
+// WithRootCAs appends the PEM encoded certificates to the root CAs used to
+// verify the collector. Multiple calls accumulate, so a corporate CA and a
+// vendor CA can be trusted at the same time.
+//
+// This option replaces any credentials set with WithTLSCredentials and has no
+// effect if WithGRPCConn is used.
+func WithRootCAs(certPEM ...[]byte) Option {
+	return wrappedOption{otlpconfig.WithRootCAs(certPEM...)}
+}
+
+// WithServiceConfig defines the default gRPC service config used. The
+// serviceConfig is a JSON service config
+// (https://github.com/grpc/grpc/blob/master/doc/service_config.md) and can be
+// used to enable gRPC level retries or load balancing policies such as
+// round_robin.
+//
+// gRPC level retries are independent of the retry policy set with WithRetry.
+// Enabling both may result in a failed export being retried by gRPC for each
+// attempt made by the exporter.
 //
 // This option has no effect if WithGRPCConn is used.
 func WithServiceConfig(serviceConfig string) Option {
@@ -135,6 +343,105 @@ func WithServiceConfig(serviceConfig string) Option {
 	})}
 }
 
+// WithGRPCMaxCallSendMsgSize sets the maximum size in bytes of the export
+// requests sent to the target endpoint. Exports of larger batches fail. Use
+// the WithMaxExportBatchBytes option of the batch processor with a lower value
+// to keep batches within this size, or the otlplogs.WithMaxPayloadSize option
+// of the exporter with this size to split the larger ones.
+//
+// If unset, the gRPC default of math.MaxInt32 is used.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCMaxCallSendMsgSize(size int) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.MaxCallSendMsgSize = size
+		return cfg
+	})}
+}
+
+// WithGRPCMaxCallRecvMsgSize sets the maximum size in bytes of the responses
+// received from the target endpoint.
+//
+// If unset, the gRPC default of 4MB is used.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCMaxCallRecvMsgSize(size int) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.MaxCallRecvMsgSize = size
+		return cfg
+	})}
+}
+
+// WithInitialWindowSize sets the HTTP/2 flow control window in bytes of each
+// stream of the connection to the target endpoint. It disables the dynamic
+// window estimation of gRPC (BDP). Raising it can improve the throughput of
+// exports over links with a high bandwidth and a high latency, at the cost of
+// up to this much memory buffered per stream. Values lower than 64KiB are
+// ignored by gRPC.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithInitialWindowSize(size int32) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.InitialWindowSize = size
+		return cfg
+	})}
+}
+
+// WithInitialConnWindowSize sets the HTTP/2 flow control window in bytes of
+// the connection to the target endpoint, shared by all its streams. It
+// disables the dynamic window estimation of gRPC (BDP). Raising it can improve
+// the throughput of exports over links with a high bandwidth and a high
+// latency, at the cost of up to this much memory buffered for the connection.
+// Values lower than 64KiB are ignored by gRPC.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithInitialConnWindowSize(size int32) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.InitialConnWindowSize = size
+		return cfg
+	})}
+}
+
+// WithOutgoingMetadataFunc sets a function returning metadata sent with each
+// export, e.g. a tenant or a short-lived token. It is called once per export
+// with the context passed to the exporter, and its metadata is merged with the
+// headers set with WithHeaders.
+func WithOutgoingMetadataFunc(fn func(context.Context) metadata.MD) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.OutgoingMetadataFunc = fn
+		return cfg
+	})}
+}
+
+// WithUnaryInterceptors adds interceptors chained around the export RPC, e.g.
+// to inject auth tokens or record metrics with existing gRPC middleware. The
+// interceptors run in the order they are passed, and multiple calls
+// accumulate. They run within each attempt, a retried export calls them again.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.UnaryInterceptors = append(slices.Clip(cfg.UnaryInterceptors), interceptors...)
+		return cfg
+	})}
+}
+
+// WithStatsHandler adds a gRPC stats handler notified of the export RPCs, e.g.
+// the otelgrpc client handler recording metrics and traces of each export.
+// Multiple calls accumulate.
+//
+// A handler emitting logs through the LoggerProvider using this exporter
+// causes new exports for each export, make sure it does not log the export
+// RPCs.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithStatsHandler(h stats.Handler) Option {
+	return wrappedOption{otlpconfig.NewGRPCOption(func(cfg otlpconfig.Config) otlpconfig.Config {
+		cfg.StatsHandlers = append(slices.Clip(cfg.StatsHandlers), h)
+		return cfg
+	})}
+}
+
 // WithDialOption sets explicit grpc.DialOptions to use when making a
 // connection. The options here are appended to the internal grpc.DialOptions
 // used so they will take precedence over any other internal grpc.DialOptions
@@ -163,16 +470,39 @@ func WithGRPCConn(conn *grpc.ClientConn) Option {
 	})}
 }
 
+// WithDisableKeepalives lets the connection to the endpoint go idle, closing
+// it, shortly after the last export rather than keeping it open for the next
+// exports, e.g. in a short-lived process sending a single batch before it
+// exits. The next export opens a new connection. The connection is closed on
+// shutdown in any case.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithDisableKeepalives() Option {
+	return wrappedOption{otlpconfig.WithDisableKeepalives()}
+}
+
 // WithTimeout sets the max amount of time a grpcClient will attempt to export a
 // batch of logs. This takes precedence over any retry settings defined with
 // WithRetry, once this time limit has been reached the export is abandoned
 // and the batch of logs is dropped.
 //
-// If unset, the default timeout will be set to 10 seconds.
+// If unset, or set to a zero or negative duration, the default timeout will be
+// set to 10 seconds.
 func WithTimeout(duration time.Duration) Option {
 	return wrappedOption{otlpconfig.WithTimeout(duration)}
 }
 
+// WithTimeoutPerAttempt sets the max amount of time of each attempt to export
+// a batch of logs. An attempt reaching it fails with a DeadlineExceeded status
+// and is retried with a fresh timeout, as long as the retry policy set with
+// WithRetry and the overall timeout set with WithTimeout allow it.
+//
+// If unset, or set to a zero or negative duration, only WithTimeout bounds the
+// attempts.
+func WithTimeoutPerAttempt(duration time.Duration) Option {
+	return wrappedOption{otlpconfig.WithTimeoutPerAttempt(duration)}
+}
+
 // WithRetry sets the retry policy for transient retryable errors that may be
 // returned by the target endpoint when exporting a batch of logs.
 //
@@ -189,3 +519,27 @@ func WithTimeout(duration time.Duration) Option {
 func WithRetry(settings RetryConfig) Option {
 	return wrappedOption{otlpconfig.WithRetry(retry.Config(settings))}
 }
+
+// WithRetryDisabled disables the retries of failed exports: an export fails
+// with the error of its first attempt, e.g. when a queue in front of the
+// exporter retries them. It is a shorthand for WithRetry with a disabled
+// RetryConfig.
+func WithRetryDisabled() Option {
+	return wrappedOption{otlpconfig.WithRetryDisabled()}
+}
+
+// WithRetryInitialInterval sets the time to wait after the first failure of an
+// export before retrying it, leaving the other settings of the retry policy,
+// the default one or the one set with WithRetry, unchanged. Pass it after
+// WithRetry, which replaces the whole retry policy.
+func WithRetryInitialInterval(d time.Duration) Option {
+	return wrappedOption{otlpconfig.WithRetryInitialInterval(d)}
+}
+
+// WithRetryMaxInterval sets the upper bound of the exponentially increasing
+// time to wait between the retries of an export, leaving the other settings of
+// the retry policy, the default one or the one set with WithRetry, unchanged.
+// Pass it after WithRetry, which replaces the whole retry policy.
+func WithRetryMaxInterval(d time.Duration) Option {
+	return wrappedOption{otlpconfig.WithRetryMaxInterval(d)}
+}
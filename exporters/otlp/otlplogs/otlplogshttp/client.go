@@ -20,10 +20,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlpconfig"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/retry"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
 	"go.opentelemetry.io/otel"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
@@ -35,6 +37,7 @@ import (
 	"net/url"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -71,8 +74,27 @@ type httpClient struct {
 	generalCfg  otlpconfig.Config
 	requestFunc retry.RequestFunc
 	client      *http.Client
+	gzPool      *sync.Pool
 	stopCh      chan struct{}
 	stopOnce    sync.Once
+
+	// protobufFallback is set once the endpoint rejected JSON payloads and
+	// protobuf is used instead, see WithProtobufFallback.
+	protobufFallback atomic.Bool
+}
+
+// newGzipPool returns a pool of gzip.Writer using the given compression level.
+// The level must already be validated.
+func newGzipPool(level int) *sync.Pool {
+	if level == gzip.DefaultCompression {
+		return &gzPool
+	}
+	return &sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		},
+	}
 }
 
 // NewClient creates a new HTTP logs httpClient.
@@ -91,12 +113,31 @@ func NewClient(opts ...Option) *httpClient {
 			Transport: ourTransport,
 			Timeout:   cfg.Logs.Timeout,
 		}
-		if cfg.Logs.TLSCfg != nil {
+		if cfg.Logs.TLSCfg != nil || cfg.Logs.DisableKeepalives || cfg.Logs.MaxIdleConnsPerHost > 0 || cfg.Logs.MaxConnsPerHost > 0 {
 			transport := ourTransport.Clone()
 			transport.TLSClientConfig = cfg.Logs.TLSCfg
+			transport.DisableKeepAlives = cfg.Logs.DisableKeepalives
+			transport.MaxIdleConnsPerHost = max(cfg.Logs.MaxIdleConnsPerHost, 0)
+			transport.MaxConnsPerHost = max(cfg.Logs.MaxConnsPerHost, 0)
 			client.Transport = transport
 		}
 	}
+	if len(cfg.Logs.RoundTripperWrappers) > 0 {
+		client = wrapTransport(client, cfg.Logs.RoundTripperWrappers)
+	}
+
+	if cfg.DebugLogger != nil {
+		compression := "none"
+		if Compression(cfg.Logs.Compression) == GzipCompression {
+			compression = "gzip"
+		}
+		scheme := "https"
+		if cfg.Logs.Insecure {
+			scheme = "http"
+		}
+		cfg.DebugLogger("otlplogshttp: exporting to %s://%s%s using %s with %s compression",
+			scheme, cfg.Logs.Endpoint, cfg.Logs.URLPath, cfg.Logs.Protocol, compression)
+	}
 
 	stopCh := make(chan struct{})
 	return &httpClient{
@@ -106,7 +147,24 @@ func NewClient(opts ...Option) *httpClient {
 		requestFunc: cfg.RetryConfig.RequestFunc(evaluate),
 		stopCh:      stopCh,
 		client:      client,
+		gzPool:      newGzipPool(cfg.Logs.GzipCompressionLevel),
+	}
+}
+
+// wrapTransport returns a copy of client, so that a client set with
+// WithHTTPClient is not changed, with its transport wrapped by wrappers, the
+// first one being the outermost.
+func wrapTransport(client *http.Client, wrappers []func(http.RoundTripper) http.RoundTripper) *http.Client {
+	c := *client
+	rt := c.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
 	}
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		rt = wrappers[i](rt)
+	}
+	c.Transport = rt
+	return &c
 }
 
 // Start does nothing in a HTTP httpClient.
@@ -120,6 +178,12 @@ func (d *httpClient) Start(ctx context.Context) error {
 	return nil
 }
 
+// Connect does nothing in a HTTP httpClient, connections are established by
+// the exports.
+func (d *httpClient) Connect(ctx context.Context) error {
+	return nil
+}
+
 // Stop shuts down the httpClient and interrupt any in-flight request.
 func (d *httpClient) Stop(ctx context.Context) error {
 	d.stopOnce.Do(func() {
@@ -169,11 +233,25 @@ func (d *httpClient) contextWithStop(ctx context.Context) (context.Context, cont
 	return ctx, cancel
 }
 
-func (d *httpClient) newRequest(body []byte) (request, error) {
+// errUnsupportedJSON is returned by an export of JSON payloads rejected by the
+// endpoint when falling back to protobuf is enabled.
+var errUnsupportedJSON = errors.New("JSON payloads are not supported by the endpoint")
+
+// protocol returns the protocol used to encode the payloads.
+func (d *httpClient) protocol() otlpconfig.Protocol {
+	if d.protobufFallback.Load() {
+		return otlpconfig.ExporterProtocolHttpProtobuf
+	}
+	return d.cfg.Protocol
+}
+
+// newHTTPRequest returns a request without body to the endpoint, with the
+// headers of the protocol.
+func (d *httpClient) newHTTPRequest(protocol otlpconfig.Protocol) (*http.Request, error) {
 	u := url.URL{Scheme: d.getScheme(), Host: d.cfg.Endpoint, Path: d.cfg.URLPath}
 	r, err := http.NewRequest(http.MethodPost, u.String(), nil)
 	if err != nil {
-		return request{Request: r}, err
+		return r, err
 	}
 
 	r.Header.Set("User-Agent", otlpconfig.GetUserAgentHeader())
@@ -181,15 +259,56 @@ func (d *httpClient) newRequest(body []byte) (request, error) {
 	for k, v := range d.cfg.Headers {
 		r.Header.Set(k, v)
 	}
-	switch d.cfg.Protocol {
+	switch protocol {
 	case otlpconfig.ExporterProtocolHttpJson:
 		r.Header.Set("Content-Type", contentTypeJson)
 	default:
 		r.Header.Set("Content-Type", contentTypeProto)
 	}
+	return r, nil
+}
+
+// compression returns the compression of a payload of size bytes.
+func (d *httpClient) compression(size int) Compression {
+	compression := Compression(d.cfg.Compression)
+	if compression == GzipCompression && size < d.cfg.CompressionThreshold {
+		compression = NoCompression
+	}
+	return compression
+}
+
+// newStreamingRequest returns a request whose body is encoded from exportLogs
+// while it is sent, see WithStreamingBody.
+func (d *httpClient) newStreamingRequest(exportLogs *collogspb.ExportLogsServiceRequest, protocol otlpconfig.Protocol) (request, error) {
+	r, err := d.newHTTPRequest(protocol)
+	if err != nil {
+		return request{Request: r}, err
+	}
+	// The length is unknown, the body is sent with a chunked encoding.
+	r.ContentLength = -1
+
+	stream := &bodyStream{encode: func(w io.Writer) error {
+		if protocol == otlpconfig.ExporterProtocolHttpJson {
+			return writeJSONLogs(w, exportLogs.ResourceLogs)
+		}
+		return writeProtoLogs(w, exportLogs.ResourceLogs)
+	}}
+	if d.compression(proto.Size(exportLogs)) == GzipCompression {
+		r.Header.Set("Content-Encoding", "gzip")
+		stream.gzPool = d.gzPool
+	}
+	return request{Request: r, bodyReader: stream.reader, stream: stream}, nil
+}
+
+func (d *httpClient) newRequest(body []byte, protocol otlpconfig.Protocol) (request, error) {
+	r, err := d.newHTTPRequest(protocol)
+	if err != nil {
+		return request{Request: r}, err
+	}
 
 	req := request{Request: r}
-	switch Compression(d.cfg.Compression) {
+	compression := d.compression(len(body))
+	switch compression {
 	case NoCompression:
 		r.ContentLength = (int64)(len(body))
 		req.bodyReader = bodyReader(body)
@@ -198,8 +317,8 @@ func (d *httpClient) newRequest(body []byte) (request, error) {
 		r.ContentLength = -1
 		r.Header.Set("Content-Encoding", "gzip")
 
-		gz := gzPool.Get().(*gzip.Writer)
-		defer gzPool.Put(gz)
+		gz := d.gzPool.Get().(*gzip.Writer)
+		defer d.gzPool.Put(gz)
 
 		var b bytes.Buffer
 		gz.Reset(&b)
@@ -238,11 +357,21 @@ type request struct {
 
 	// bodyReader allows the same body to be used for multiple requests.
 	bodyReader func() io.ReadCloser
+	// stream is the streamed body, nil unless WithStreamingBody is used.
+	stream *bodyStream
+}
+
+// closeBody stops the encodings of a streamed body once a request is done.
+func (r *request) closeBody() {
+	if r.stream != nil {
+		r.stream.close()
+	}
 }
 
 // reset reinitializes the request Body and uses ctx for the request.
 func (r *request) reset(ctx context.Context) {
 	r.Body = r.bodyReader()
+	r.GetBody = func() (io.ReadCloser, error) { return r.bodyReader(), nil }
 	r.Request = r.Request.WithContext(ctx)
 }
 
@@ -269,37 +398,76 @@ func (d *httpClient) UploadLogs(ctx context.Context, protoLogs []*logspb.Resourc
 		ResourceLogs: protoLogs,
 	}
 
-	// Serialize the OTLP logs payload
-	var rawRequest []byte
-	switch d.cfg.Protocol {
-	case otlpconfig.ExporterProtocolHttpJson:
-		rawRequest, _ = protojson.MarshalOptions{
-			UseProtoNames: false,
-		}.Marshal(exportLogs)
-	default:
-		rawRequest, _ = proto.Marshal(exportLogs)
+	protocol := d.protocol()
+	var (
+		request request
+		err     error
+	)
+	if d.cfg.StreamingBody {
+		request, err = d.newStreamingRequest(exportLogs, protocol)
+	} else {
+		// Serialize the OTLP logs payload
+		var rawRequest []byte
+		switch protocol {
+		case otlpconfig.ExporterProtocolHttpJson:
+			rawRequest, _ = protojson.MarshalOptions{
+				UseProtoNames: false,
+			}.Marshal(exportLogs)
+		default:
+			rawRequest, _ = proto.Marshal(exportLogs)
+		}
+		request, err = d.newRequest(rawRequest, protocol)
+	}
+	if err != nil {
+		return err
 	}
 
 	ctx, cancel := d.contextWithStop(ctx)
 	defer cancel()
 
-	request, err := d.newRequest(rawRequest)
-	if err != nil {
-		return err
+	debugf := d.generalCfg.DebugLogger
+	var attempt, count int
+	if debugf != nil {
+		count = internal.LogRecordCount(protoLogs)
 	}
-
-	return d.requestFunc(ctx, func(ctx context.Context) error {
+	err = d.requestFunc(ctx, func(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
 
+		attempt++
+		if debugf != nil {
+			debugf("otlplogshttp: export attempt %d of %d log records to %s", attempt, count, request.URL.Redacted())
+		}
+		parent := ctx
+		if timeout := d.cfg.TimeoutPerAttempt; timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
 		request.reset(ctx)
+		for _, edit := range d.cfg.RequestEditors {
+			if err := edit(request.Request); err != nil {
+				return fmt.Errorf("edit request: %w", err)
+			}
+		}
 		resp, err := d.client.Do(request.Request)
+		request.closeBody()
 		if err != nil {
+			if debugf != nil {
+				debugf("otlplogshttp: export attempt %d failed: %v", attempt, d.cfg.RedactError(err))
+			}
+			if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// Only this attempt timed out, retry it.
+				return retryableError{}
+			}
 			return err
 		}
+		if debugf != nil {
+			debugf("otlplogshttp: export attempt %d: %s", attempt, resp.Status)
+		}
 
 		if resp != nil && resp.Body != nil {
 			defer func() {
@@ -320,7 +488,7 @@ func (d *httpClient) UploadLogs(ctx context.Context, protoLogs []*logspb.Resourc
 
 			if respData.Len() != 0 {
 				var respProto collogspb.ExportLogsServiceResponse
-				switch d.cfg.Protocol {
+				switch protocol {
 				case otlpconfig.ExporterProtocolHttpJson:
 					if err := protojson.Unmarshal(respData.Bytes(), &respProto); err != nil {
 						return err
@@ -337,27 +505,48 @@ func (d *httpClient) UploadLogs(ctx context.Context, protoLogs []*logspb.Resourc
 					msg := respProto.PartialSuccess.GetErrorMessage()
 					n := respProto.PartialSuccess.GetRejectedLogRecords()
 					if n != 0 || msg != "" {
+						if debugf != nil {
+							debugf("otlplogshttp: partial success: %d log records rejected: %s", n, msg)
+						}
 						err := internal.LogRecordPartialSuccessError(n, msg)
-						otel.Handle(err)
+						internal.HandlePartialSuccess(ctx, err)
 					}
 				}
 			}
 			return nil
 		case sc == http.StatusTooManyRequests, sc == http.StatusServiceUnavailable:
 			// Retry-able failures.  Drain the body to reuse the connection.
+			if debugf != nil {
+				debugf("otlplogshttp: export attempt %d failed with a retryable status", attempt)
+			}
 			if _, err := io.Copy(io.Discard, resp.Body); err != nil {
 				otel.Handle(err)
 			}
 			return newResponseError(resp.Header)
+		case sc == http.StatusUnsupportedMediaType && protocol == otlpconfig.ExporterProtocolHttpJson && d.cfg.ProtobufFallback:
+			if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+				otel.Handle(err)
+			}
+			return errUnsupportedJSON
 		default:
 			buffer := make([]byte, 4096)
 			_, _ = resp.Body.Read(buffer)
 			if len(buffer) == 0 {
-				return fmt.Errorf("failed to send to %s: %s", request.URL, resp.Status)
+				return fmt.Errorf("failed to send to %s: %s", request.URL.Redacted(), resp.Status)
 			}
-			return fmt.Errorf("failed to send to %s: %s\n%s", request.URL, resp.Status, buffer)
+			return fmt.Errorf("failed to send to %s: %s\n%s", request.URL.Redacted(), resp.Status, buffer)
 		}
 	})
+	if errors.Is(err, errUnsupportedJSON) {
+		// The warning is logged once, by the first of the concurrent exports
+		// rejected.
+		if d.protobufFallback.CompareAndSwap(false, true) {
+			global.Warn("the endpoint rejected JSON payloads, using protobuf", "url", request.URL.Redacted())
+		}
+		return d.UploadLogs(ctx, protoLogs)
+	}
+	// The endpoint may echo the credentials it rejected.
+	return d.cfg.RedactError(err)
 }
 
 // MarshalLog is the marshaling function used by the logging system to represent this Client.
@@ -0,0 +1,852 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogshttp_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	sdklogs "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var body = "Log Record 0"
+var roLogRecords = logstest.LogRecordStubs{{Body: &body}}.Snapshots()
+
+type httpCollector struct {
+	mu       sync.Mutex
+	headers  []http.Header
+	requests []*collogspb.ExportLogsServiceRequest
+	server   *httptest.Server
+
+	// chunked records whether the requests were sent with a chunked transfer
+	// encoding.
+	chunked []bool
+}
+
+func runHTTPCollector(t *testing.T) *httpCollector {
+	t.Helper()
+	c := &httpCollector{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			defer gz.Close()
+			reader = gz
+		}
+		raw, err := io.ReadAll(reader)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req := &collogspb.ExportLogsServiceRequest{}
+		unmarshal := proto.Unmarshal
+		if r.Header.Get("Content-Type") == "application/json" {
+			unmarshal = protojson.Unmarshal
+		}
+		if err := unmarshal(raw, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.headers = append(c.headers, r.Header.Clone())
+		c.requests = append(c.requests, req)
+		c.chunked = append(c.chunked, r.ContentLength == -1 && slices.Contains(r.TransferEncoding, "chunked"))
+		c.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+func (c *httpCollector) endpoint() string {
+	return strings.TrimPrefix(c.server.URL, "http://")
+}
+
+func (c *httpCollector) getHeaders() []http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.headers
+}
+
+func (c *httpCollector) getChunked() []bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chunked
+}
+
+func (c *httpCollector) getRequests() []*collogspb.ExportLogsServiceRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests
+}
+
+func newHTTPExporter(t *testing.T, ctx context.Context, endpoint string, additionalOpts ...otlplogshttp.Option) *otlplogs.Exporter {
+	opts := []otlplogshttp.Option{
+		otlplogshttp.WithInsecure(),
+		otlplogshttp.WithEndpoint(endpoint),
+	}
+
+	opts = append(opts, additionalOpts...)
+	client := otlplogshttp.NewClient(opts...)
+	exp, err := otlplogs.NewExporter(ctx, otlplogs.WithClient(client))
+	if err != nil {
+		t.Fatalf("failed to create a new collector exporter: %v", err)
+	}
+	return exp
+}
+
+func TestGzipCompressionLevel(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithCompression(otlplogshttp.GzipCompression),
+		otlplogshttp.WithGzipCompressionLevel(gzip.BestSpeed),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Equal(t, "gzip", headers[0].Get("Content-Encoding"))
+	requests := mc.getRequests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, body, requests[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body.GetStringValue())
+}
+
+func TestGzipCompressionLevelIgnoredWithoutCompression(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithGzipCompressionLevel(gzip.BestSpeed),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Empty(t, headers[0].Get("Content-Encoding"))
+}
+
+func TestInvalidGzipCompressionLevel(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithCompression(otlplogshttp.GzipCompression),
+		otlplogshttp.WithGzipCompressionLevel(42),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "invalid gzip compression level: 42")
+	assert.Len(t, mc.getRequests(), 1)
+}
+
+func TestWithoutEnvironmentConfig(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "env=true")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "gzip")
+
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(), otlplogshttp.WithoutEnvironmentConfig())
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Empty(t, headers[0].Get("env"))
+	assert.Empty(t, headers[0].Get("Content-Encoding"))
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	large := strings.Repeat("x", 4096)
+	largeLogRecords := logstest.LogRecordStubs{{Body: &large}}.Snapshots()
+
+	tests := []struct {
+		name     string
+		records  []sdklogs.ReadableLogRecord
+		encoding string
+	}{
+		{name: "small payload", records: roLogRecords, encoding: ""},
+		{name: "large payload", records: largeLogRecords, encoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := runHTTPCollector(t)
+
+			ctx := context.Background()
+			exp := newHTTPExporter(t, ctx, mc.endpoint(),
+				otlplogshttp.WithCompression(otlplogshttp.GzipCompression),
+				otlplogshttp.WithCompressionThreshold(1024),
+			)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+			require.NoError(t, exp.Export(ctx, tt.records))
+
+			headers := mc.getHeaders()
+			require.Len(t, headers, 1)
+			assert.Equal(t, tt.encoding, headers[0].Get("Content-Encoding"))
+			requests := mc.getRequests()
+			require.Len(t, requests, 1)
+			assert.Len(t, requests[0].ResourceLogs[0].ScopeLogs[0].LogRecords, 1)
+		})
+	}
+}
+
+func TestDebugLogger(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	debugf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"),
+		otlplogshttp.WithCompression(otlplogshttp.GzipCompression),
+		otlplogshttp.WithRetry(otlplogshttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+		otlplogshttp.WithDebugLogger(debugf),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"otlplogshttp: exporting to http://" + strings.TrimPrefix(server.URL, "http://") + "/v1/logs using http/protobuf with gzip compression",
+		"otlplogshttp: export attempt 1 of 1 log records to " + server.URL + "/v1/logs",
+		"otlplogshttp: export attempt 1: 503 Service Unavailable",
+		"otlplogshttp: export attempt 1 failed with a retryable status",
+		"otlplogshttp: export attempt 2 of 1 log records to " + server.URL + "/v1/logs",
+		"otlplogshttp: export attempt 2: 200 OK",
+	}
+	assert.Equal(t, want, lines)
+}
+
+func TestRequestEditorFunc(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	var order []string
+	editor := func(name string) func(*http.Request) error {
+		return func(r *http.Request) error {
+			order = append(order, name)
+			body, err := r.GetBody()
+			if err != nil {
+				return err
+			}
+			raw, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			r.Header.Set("X-Signature", fmt.Sprintf("%s-%d", name, len(raw)))
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithRequestEditorFunc(editor("first")),
+		otlplogshttp.WithRequestEditorFunc(editor("second")),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	assert.Equal(t, []string{"first", "second"}, order)
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Regexp(t, `^second-[1-9][0-9]*$`, headers[0].Get("X-Signature"))
+	// The body read by the editors is still sent.
+	assert.Len(t, mc.getRequests(), 1)
+}
+
+// countingRoundTripper counts the requests it passes to next and records its
+// name in order.
+type countingRoundTripper struct {
+	name  string
+	next  http.RoundTripper
+	count *atomic.Int64
+	order *[]string
+}
+
+func (rt countingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.count.Add(1)
+	*rt.order = append(*rt.order, rt.name)
+	return rt.next.RoundTrip(r)
+}
+
+func TestWithRoundTripperWrapper(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	var (
+		first, second atomic.Int64
+		order         []string
+	)
+	wrapper := func(name string, count *atomic.Int64) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return countingRoundTripper{name: name, next: next, count: count, order: &order}
+		}
+	}
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithRoundTripperWrapper(wrapper("first", &first)),
+		otlplogshttp.WithRoundTripperWrapper(wrapper("second", &second)),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Equal(t, int64(2), first.Load())
+	assert.Equal(t, int64(2), second.Load())
+	assert.Equal(t, []string{"first", "second", "first", "second"}, order)
+	assert.Len(t, mc.getRequests(), 2)
+}
+
+func TestWithRoundTripperWrapperHTTPClient(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	var (
+		count atomic.Int64
+		order []string
+	)
+	client := &http.Client{}
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithHTTPClient(client),
+		otlplogshttp.WithRoundTripperWrapper(func(next http.RoundTripper) http.RoundTripper {
+			return countingRoundTripper{name: "wrapper", next: next, count: &count, order: &order}
+		}),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Equal(t, int64(1), count.Load())
+	assert.Nil(t, client.Transport, "the client passed is not changed")
+}
+
+func TestRequestEditorFuncError(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithRequestEditorFunc(func(*http.Request) error { return errors.New("no credentials") }),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	err := exp.Export(ctx, roLogRecords)
+	assert.ErrorContains(t, err, "no credentials")
+	assert.Empty(t, mc.getRequests())
+}
+
+// contextWithSpan returns a context holding a remote span context with flags.
+func contextWithSpan(flags trace.TraceFlags) context.Context {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+	}))
+}
+
+func TestWithTraceContextInjection(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	ctx := contextWithSpan(trace.FlagsSampled)
+
+	exp := newHTTPExporter(t, ctx, mc.endpoint(), otlplogshttp.WithTraceContextInjection(propagation.TraceContext{}))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	// Without a span in the context, no trace context is injected.
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 2)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", headers[0].Get("traceparent"))
+	assert.Empty(t, headers[1].Get("traceparent"))
+}
+
+func TestWithTraceContextInjectionGlobalPropagator(t *testing.T) {
+	global := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(global) })
+
+	mc := runHTTPCollector(t)
+	ctx := contextWithSpan(0)
+
+	exp := newHTTPExporter(t, ctx, mc.endpoint(), otlplogshttp.WithTraceContextInjection(nil))
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", headers[0].Get("traceparent"))
+}
+
+func TestTraceContextNotInjectedByDefault(t *testing.T) {
+	global := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(global) })
+
+	mc := runHTTPCollector(t)
+	ctx := contextWithSpan(0)
+
+	exp := newHTTPExporter(t, ctx, mc.endpoint())
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	headers := mc.getHeaders()
+	require.Len(t, headers, 1)
+	assert.Empty(t, headers[0].Get("traceparent"))
+}
+
+func TestConnect(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint())
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Connect(ctx))
+	assert.Empty(t, mc.getRequests(), "connecting sends no request")
+}
+
+func TestWithRetryDisabled(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"), otlplogshttp.WithRetryDisabled())
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.Error(t, exp.Export(ctx, roLogRecords))
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestWithDisableKeepalives(t *testing.T) {
+	for _, disable := range []bool{false, true} {
+		t.Run(fmt.Sprintf("disabled=%t", disable), func(t *testing.T) {
+			var (
+				conns  atomic.Int32
+				closed atomic.Int32
+			)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				if r.Close {
+					closed.Add(1)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+			server.Start()
+			t.Cleanup(server.Close)
+
+			var opts []otlplogshttp.Option
+			if disable {
+				opts = append(opts, otlplogshttp.WithDisableKeepalives())
+			}
+			ctx := context.Background()
+			exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"), opts...)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			require.NoError(t, exp.Export(ctx, roLogRecords))
+			require.NoError(t, exp.Export(ctx, roLogRecords))
+			if disable {
+				assert.Equal(t, int32(2), closed.Load())
+				assert.Equal(t, int32(2), conns.Load())
+			} else {
+				assert.Zero(t, closed.Load())
+				assert.Equal(t, int32(1), conns.Load())
+			}
+		})
+	}
+}
+
+func TestWithConnsPerHost(t *testing.T) {
+	mc := runHTTPCollector(t)
+
+	var transport http.RoundTripper
+	capture := otlplogshttp.WithRoundTripperWrapper(func(next http.RoundTripper) http.RoundTripper {
+		transport = next
+		return next
+	})
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithMaxIdleConnsPerHost(64),
+		otlplogshttp.WithMaxConnsPerHost(128),
+		capture,
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+
+	require.IsType(t, &http.Transport{}, transport)
+	assert.Equal(t, 64, transport.(*http.Transport).MaxIdleConnsPerHost)
+	assert.Equal(t, 128, transport.(*http.Transport).MaxConnsPerHost)
+	assert.Equal(t, 100, transport.(*http.Transport).MaxIdleConns, "the other fields of the default transport are kept")
+
+	// The transport of a client set with WithHTTPClient is not tuned.
+	own := &http.Transport{}
+	exp = newHTTPExporter(t, ctx, mc.endpoint(),
+		otlplogshttp.WithHTTPClient(&http.Client{Transport: own}),
+		otlplogshttp.WithMaxIdleConnsPerHost(64),
+		otlplogshttp.WithMaxConnsPerHost(128),
+		capture,
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Same(t, own, transport)
+	assert.Zero(t, own.MaxIdleConnsPerHost)
+	assert.Zero(t, own.MaxConnsPerHost)
+}
+
+func TestRedactedHeadersInErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+		// The endpoint echoes the credentials it rejected.
+		_, _ = fmt.Fprintf(w, "invalid Authorization: %s, X-Tenant-Key: %s", r.Header.Get("Authorization"), r.Header.Get("X-Tenant-Key"))
+	}))
+	t.Cleanup(server.Close)
+
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	debugf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"),
+		otlplogshttp.WithHeaders(map[string]string{"Authorization": "Bearer s3cr3t", "X-Tenant-Key": "t3n4nt"}),
+		otlplogshttp.WithSensitiveHeaderKeys([]string{"x-tenant-key"}),
+		otlplogshttp.WithDebugLogger(debugf),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	err := exp.Export(ctx, roLogRecords)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+	assert.Contains(t, err.Error(), "invalid Authorization: [REDACTED], X-Tenant-Key: [REDACTED]")
+	assert.NotContains(t, err.Error(), "s3cr3t")
+	assert.NotContains(t, err.Error(), "t3n4nt")
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, line := range lines {
+		assert.NotContains(t, line, "s3cr3t")
+	}
+}
+
+func TestWithTimeoutPerAttempt(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if attempts.Add(1) == 1 {
+			// The first attempt is slow, it is cut off by its own timeout.
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"),
+		otlplogshttp.WithTimeoutPerAttempt(50*time.Millisecond),
+		otlplogshttp.WithRetry(otlplogshttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	start := time.Now()
+	require.NoError(t, exp.Export(ctx, roLogRecords))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestWithTimeoutPerAttemptRetryDisabled(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		attempts.Add(1)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"),
+		otlplogshttp.WithTimeoutPerAttempt(50*time.Millisecond),
+		otlplogshttp.WithRetryDisabled(),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.Error(t, exp.Export(ctx, roLogRecords))
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestProtobufFallback(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		contentTypes []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		contentType := r.Header.Get("Content-Type")
+		mu.Lock()
+		contentTypes = append(contentTypes, contentType)
+		mu.Unlock()
+		if contentType != "application/x-protobuf" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if err := proto.Unmarshal(raw, &collogspb.ExportLogsServiceRequest{}); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+
+	ctx := context.Background()
+	t.Run("disabled", func(t *testing.T) {
+		contentTypes = nil
+		exp := newHTTPExporter(t, ctx, endpoint, otlplogshttp.WithJsonProtocol())
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		assert.ErrorContains(t, exp.Export(ctx, roLogRecords), "415 Unsupported Media Type")
+		assert.Equal(t, []string{"application/json"}, contentTypes)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		contentTypes = nil
+		exp := newHTTPExporter(t, ctx, endpoint, otlplogshttp.WithJsonProtocol(), otlplogshttp.WithProtobufFallback())
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		require.NoError(t, exp.Export(ctx, roLogRecords))
+		require.NoError(t, exp.Export(ctx, roLogRecords))
+		// The choice of protobuf is remembered by the second export.
+		assert.Equal(t, []string{"application/json", "application/x-protobuf", "application/x-protobuf"}, contentTypes)
+	})
+}
+
+// largeLogRecords returns n log records with bodies of size bytes, spread over
+// two resources and scopes.
+func largeLogRecords(n, size int) []sdklogs.ReadableLogRecord {
+	resources := []*resource.Resource{
+		resource.NewSchemaless(attribute.String("service.name", "a")),
+		resource.NewSchemaless(attribute.String("service.name", "b")),
+	}
+	scopes := []*instrumentation.Scope{{Name: "first"}, {Name: "second", Version: "v1"}}
+	stubs := make(logstest.LogRecordStubs, n)
+	for i := range stubs {
+		body := strings.Repeat(fmt.Sprint(i%10), size)
+		severity := logs.INFO
+		stubs[i] = logstest.LogRecordStub{
+			Body:                 &body,
+			SeverityNumber:       &severity,
+			Attributes:           &[]attribute.KeyValue{attribute.Int("index", i)},
+			Resource:             resources[i%2],
+			InstrumentationScope: scopes[i/2%2],
+		}
+	}
+	return stubs.Snapshots()
+}
+
+func TestWithStreamingBody(t *testing.T) {
+	records := largeLogRecords(50, 1024)
+	for _, tt := range []struct {
+		name string
+		opts []otlplogshttp.Option
+	}{
+		{name: "protobuf"},
+		{name: "protobuf gzip", opts: []otlplogshttp.Option{otlplogshttp.WithCompression(otlplogshttp.GzipCompression)}},
+		{name: "json", opts: []otlplogshttp.Option{otlplogshttp.WithJsonProtocol()}},
+		{name: "json gzip", opts: []otlplogshttp.Option{otlplogshttp.WithJsonProtocol(), otlplogshttp.WithCompression(otlplogshttp.GzipCompression)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := runHTTPCollector(t)
+			ctx := context.Background()
+			buffered := newHTTPExporter(t, ctx, mc.endpoint(), tt.opts...)
+			t.Cleanup(func() { require.NoError(t, buffered.Shutdown(ctx)) })
+			streaming := newHTTPExporter(t, ctx, mc.endpoint(), append(tt.opts, otlplogshttp.WithStreamingBody())...)
+			t.Cleanup(func() { require.NoError(t, streaming.Shutdown(ctx)) })
+
+			require.NoError(t, buffered.Export(ctx, records))
+			require.NoError(t, streaming.Export(ctx, records))
+
+			requests := mc.getRequests()
+			require.Len(t, requests, 2)
+			assert.True(t, proto.Equal(requests[0], requests[1]), "the streamed payload differs")
+			assert.True(t, mc.getChunked()[1], "the streamed payload is chunked")
+		})
+	}
+}
+
+func TestWithStreamingBodyRetry(t *testing.T) {
+	mc := runHTTPCollector(t)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			// Consume half of the body before failing.
+			_, _ = io.CopyN(io.Discard, r.Body, 1024)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mc.server.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"),
+		otlplogshttp.WithStreamingBody(),
+		otlplogshttp.WithRetry(otlplogshttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+	)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	records := largeLogRecords(10, 1024)
+	require.NoError(t, exp.Export(ctx, records))
+	assert.Equal(t, int32(2), attempts.Load())
+	requests := mc.getRequests()
+	require.Len(t, requests, 1, "the retried request is encoded again")
+	var got int
+	for _, rl := range requests[0].ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			got += len(sl.LogRecords)
+		}
+	}
+	assert.Equal(t, len(records), got)
+}
+
+func TestWithStreamingBodyMemory(t *testing.T) {
+	const (
+		n    = 1000
+		size = 8 << 10
+	)
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		written, _ := io.Copy(io.Discard, r.Body)
+		received.Store(written)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	records := largeLogRecords(n, size)
+
+	allocated := func(opts ...otlplogshttp.Option) uint64 {
+		ctx := context.Background()
+		exp := newHTTPExporter(t, ctx, strings.TrimPrefix(server.URL, "http://"), opts...)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		require.NoError(t, exp.Export(ctx, records))
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+
+	buffered := allocated()
+	streamed := allocated(otlplogshttp.WithStreamingBody())
+	assert.Greater(t, received.Load(), int64(n*size))
+	// The buffered export holds the whole payload, the streamed one a log
+	// record at a time.
+	assert.Greater(t, buffered, uint64(n*size))
+	assert.Less(t, streamed, uint64(n*size/2))
+}
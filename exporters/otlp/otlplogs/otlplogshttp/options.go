@@ -20,6 +20,8 @@ import (
 	"crypto/tls"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/otlpconfig"
 	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/internal/retry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"net/http"
 	"time"
 )
@@ -45,6 +47,12 @@ type Option interface {
 func asHTTPOptions(opts []Option) []otlpconfig.HTTPOption {
 	converted := make([]otlpconfig.HTTPOption, len(opts))
 	for i, o := range opts {
+		// Wrapped options are passed as is so that otlpconfig can recognize
+		// the ones it looks up, like WithoutEnvironmentConfig.
+		if w, ok := o.(wrappedOption); ok {
+			converted[i] = w.HTTPOption
+			continue
+		}
 		converted[i] = otlpconfig.NewHTTPOption(o.applyHTTPOption)
 	}
 	return converted
@@ -76,6 +84,15 @@ func WithJsonProtocol() Option {
 	return wrappedOption{otlpconfig.WithProtocol(otlpconfig.ExporterProtocolHttpJson)}
 }
 
+// WithProtobufFallback makes a client using the http/json protocol switch to
+// http/protobuf when the endpoint answers an export with a 415 Unsupported
+// Media Type status, as collectors accepting only protobuf do. The rejected
+// logs are sent again as protobuf, a warning is logged and the following
+// exports use protobuf. It has no effect with the http/protobuf protocol.
+func WithProtobufFallback() Option {
+	return wrappedOption{otlpconfig.WithProtobufFallback()}
+}
+
 // WithProtobufProtocol will apply http/protobuf protocol to Http client
 func WithProtobufProtocol() Option {
 	return wrappedOption{otlpconfig.WithProtocol(otlpconfig.ExporterProtocolHttpProtobuf)}
@@ -86,10 +103,113 @@ func WithCompression(compression Compression) Option {
 	return wrappedOption{otlpconfig.WithCompression(otlpconfig.Compression(compression))}
 }
 
+// WithGzipCompressionLevel sets the gzip level (e.g. gzip.BestSpeed or
+// gzip.BestCompression) used when GzipCompression is enabled. It has no effect
+// when compression is NoCompression. Levels outside the range accepted by
+// compress/gzip are reported to the error handler and ignored.
+func WithGzipCompressionLevel(level int) Option {
+	return wrappedOption{otlpconfig.WithGzipCompressionLevel(level)}
+}
+
+// WithCompressionThreshold sets the size in bytes of the encoded payload under
+// which it is sent uncompressed, without a Content-Encoding header, even when
+// GzipCompression is enabled. Compressing small payloads wastes CPU and can
+// make them bigger. The default of 0 compresses all payloads.
+func WithCompressionThreshold(size int) Option {
+	return wrappedOption{otlpconfig.WithCompressionThreshold(size)}
+}
+
+// WithStreamingBody makes the client stream the payload of each export with a
+// chunked transfer encoding, encoding and compressing it one log record at a
+// time while it is sent, rather than holding the whole encoded payload in
+// memory. This bounds the memory used to export very large batches. A retried
+// export encodes the payload again.
+//
+// The compression threshold set with [WithCompressionThreshold] is compared
+// to the size of the payload encoded as protobuf. Endpoints must accept
+// chunked requests, which have no Content-Length header.
+func WithStreamingBody() Option {
+	return wrappedOption{otlpconfig.WithStreamingBody()}
+}
+
+// WithRequestEditorFunc adds a function editing each HTTP request just before
+// it is sent, once its body and headers are set, e.g. to sign it with AWS
+// SigV4. The body can be read again with the GetBody method of the request.
+// Multiple functions run in the order the options are passed. They run again
+// on each retry of a request. An error returned by a function aborts the
+// export without retrying.
+func WithRequestEditorFunc(fn func(*http.Request) error) Option {
+	return wrappedOption{otlpconfig.WithRequestEditorFunc(fn)}
+}
+
+// WithTraceContextInjection injects the trace context of the context passed to
+// Export, e.g. as the W3C traceparent header, in the headers of the export
+// requests, for gateways correlating the export calls themselves with traces.
+// It is unrelated to the trace context of the exported logs, which is part of
+// the payload. The context is injected by propagator, or by the global
+// propagator set with otel.SetTextMapPropagator at the time of the export if
+// propagator is nil. The trace context is not injected by default.
+//
+// The injection runs as a request editor, in order with the ones added with
+// WithRequestEditorFunc.
+func WithTraceContextInjection(propagator propagation.TextMapPropagator) Option {
+	return wrappedOption{otlpconfig.WithRequestEditorFunc(func(r *http.Request) error {
+		p := propagator
+		if p == nil {
+			p = otel.GetTextMapPropagator()
+		}
+		p.Inject(r.Context(), propagation.HeaderCarrier(r.Header))
+		return nil
+	})}
+}
+
+// WithRoundTripperWrapper wraps the transport of the HTTP client with fn, e.g.
+// to add metrics, tracing or authentication middleware without replacing the
+// client. Multiple wrappers compose in the order the options are passed, the
+// first one being the outermost: it sees each request first. Each retry of a
+// request goes through the wrappers again.
+//
+// The transport of a client set with [WithHTTPClient] is wrapped too, in a copy
+// of the client which is left unchanged.
+func WithRoundTripperWrapper(fn func(http.RoundTripper) http.RoundTripper) Option {
+	return wrappedOption{otlpconfig.WithRoundTripperWrapper(fn)}
+}
+
+// WithDisableKeepalives closes the connection to the endpoint after each
+// request rather than keeping it open for the next exports, e.g. in a
+// short-lived process sending a single batch before it exits.
+//
+// This option has no effect if [WithHTTPClient] is used.
+func WithDisableKeepalives() Option {
+	return wrappedOption{otlpconfig.WithDisableKeepalives()}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections to the
+// endpoint kept open for the next exports, 2 by default as in net/http. Raising
+// it avoids opening new connections when many exports run concurrently, e.g.
+// with the WithMaxConcurrentExports option of the batch processor. A zero or
+// negative n keeps the default.
+//
+// This option has no effect if [WithHTTPClient] is used.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return wrappedOption{otlpconfig.WithMaxIdleConnsPerHost(n)}
+}
+
+// WithMaxConnsPerHost limits the number of connections to the endpoint, in
+// use or idle. The exports over the limit wait for a connection. A zero or
+// negative n, the default, does not limit the connections.
+//
+// This option has no effect if [WithHTTPClient] is used.
+func WithMaxConnsPerHost(n int) Option {
+	return wrappedOption{otlpconfig.WithMaxConnsPerHost(n)}
+}
+
 // WithHTTPClient sets the HTTP client to used by the exporter.
 //
 // This option will take precedence over [WithProxy], [WithTimeout],
-// [WithTLSClientConfig] options as well as OTEL_EXPORTER_OTLP_CERTIFICATE,
+// [WithDisableKeepalives], [WithMaxIdleConnsPerHost], [WithMaxConnsPerHost],
+// [WithTLSClientConfig] options as well as
+// OTEL_EXPORTER_OTLP_CERTIFICATE,
 // OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE, OTEL_EXPORTER_OTLP_TIMEOUT,
 // OTEL_EXPORTER_OTLP_TRACES_TIMEOUT environment variables.
 //
@@ -115,8 +235,86 @@ func WithTLSClientConfig(tlsCfg *tls.Config) Option {
 	return wrappedOption{otlpconfig.WithTLSClientConfig(tlsCfg)}
 }
 
+// WithTLSServerName sets the name verified in the certificate of the
+// collector, when it is reached through an IP address or a proxy. It applies
+// to the TLS configuration set by the other options or to the default one.
+func WithTLSServerName(name string) Option {
+	return wrappedOption{otlpconfig.WithTLSServerName(name)}
+}
+
+// WithRootCAs appends the PEM encoded certificates to the root CAs used to
+// verify the collector. Multiple calls accumulate, so a corporate CA and a
+// vendor CA can be trusted at the same time.
+func WithRootCAs(certPEM ...[]byte) Option {
+	return wrappedOption{otlpconfig.WithRootCAs(certPEM...)}
+}
+
+// WithDebugLogger sets a function passed internal diagnostics of the client:
+// the endpoint and compression used, each export attempt with its outcome and
+// the partial successes returned by the collector. It is meant to find out why
+// logs do not reach the collector. Diagnostics are off by default and nothing
+// is formatted when no function is set.
+func WithDebugLogger(fn func(format string, args ...any)) Option {
+	return wrappedOption{otlpconfig.WithDebugLogger(fn)}
+}
+
+// WithCredentialHeaders configures a direct export to a vendor accepting OTLP
+// without a collector, authenticating with headers such as an API key:
+//
+//	WithEndpoint("api.honeycomb.io:443"),
+//	WithCredentialHeaders(map[string]string{
+//		"x-honeycomb-team":    apiKey,
+//		"x-honeycomb-dataset": dataset,
+//	}),
+//
+// It sets the headers, a secure connection and gzip compression. A warning is
+// logged if no header or an empty header is passed, or if an option passed
+// after it, or the environment, makes the connection to a remote endpoint
+// insecure. The same warning is logged for any header looking like
+// credentials set with WithHeaders.
+func WithCredentialHeaders(headers map[string]string) Option {
+	return wrappedOption{otlpconfig.WithCredentialHeaders(headers)}
+}
+
+// WithConfigFile reads the configuration of the exporter from the YAML or JSON
+// file at path, e.g. mounted from a Kubernetes ConfigMap:
+//
+//	endpoint: https://collector:4318
+//	protocol: http/protobuf
+//	headers:
+//	  x-tenant: team-a
+//	compression: gzip
+//	timeout: 10s
+//
+// The endpoint is a URL handled like the OTEL_EXPORTER_OTLP_ENDPOINT
+// environment variable, the protocol is one of grpc, http/protobuf or
+// http/json, the compression gzip or none and the timeout a duration. All the
+// fields are optional.
+//
+// The settings of the file take precedence over the environment variables, the
+// other options take precedence over them wherever they are passed. A file
+// which cannot be read or parsed, or with an unknown field, is reported to the
+// global logger and ignored.
+func WithConfigFile(path string) Option {
+	return wrappedOption{otlpconfig.WithConfigFile(path)}
+}
+
+// WithoutEnvironmentConfig makes the options passed in code the only source of
+// configuration: the OTEL_EXPORTER_OTLP_* environment variables are ignored and
+// any setting that is not passed uses its default value. This is useful for
+// libraries embedding the exporter that do not want the environment of the host
+// to change their configuration.
+func WithoutEnvironmentConfig() Option {
+	return wrappedOption{otlpconfig.WithoutEnvironmentConfig()}
+}
+
 // WithInsecure tells the driver to connect to the collector using the
 // HTTP scheme, instead of HTTPS.
+//
+// It takes precedence over an https endpoint set with the
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variable and over any TLS
+// configuration, which is then ignored. Such conflicts are reported to the
+// OpenTelemetry error handler.
 func WithInsecure() Option {
 	return wrappedOption{otlpconfig.WithInsecure()}
 }
@@ -128,12 +326,36 @@ func WithHeaders(headers map[string]string) Option {
 	return wrappedOption{otlpconfig.WithHeaders(headers)}
 }
 
+// WithSensitiveHeaderKeys adds the names of headers set with [WithHeaders]
+// whose values are redacted from the errors returned by the exports and from
+// the diagnostics of the client. The values of Authorization and of the other
+// headers likely to carry credentials, e.g. API keys or tokens, are always
+// redacted. Names are case-insensitive and multiple calls accumulate.
+func WithSensitiveHeaderKeys(keys []string) Option {
+	return wrappedOption{otlpconfig.WithSensitiveHeaderKeys(keys)}
+}
+
 // WithTimeout tells the driver the max waiting time for the backend to process
-// each logs batch.  If unset, the default will be 10 seconds.
+// each logs batch.  If unset, the default will be 10 seconds. A zero or
+// negative duration also uses the default.
+//
+// The timeout is set on the HTTP client and bounds each request: a request
+// timing out fails the export without retrying. Use [WithTimeoutPerAttempt] to
+// retry slow requests.
 func WithTimeout(duration time.Duration) Option {
 	return wrappedOption{otlpconfig.WithTimeout(duration)}
 }
 
+// WithTimeoutPerAttempt sets the max waiting time of each attempt to export a
+// logs batch. An attempt reaching it is retried with a fresh timeout, as long
+// as the retry policy set with [WithRetry] allows it.
+//
+// If unset, or set to a zero or negative duration, attempts are only bounded
+// by [WithTimeout].
+func WithTimeoutPerAttempt(duration time.Duration) Option {
+	return wrappedOption{otlpconfig.WithTimeoutPerAttempt(duration)}
+}
+
 // WithRetry configures the retry policy for transient errors that may occurs
 // when exporting logs. An exponential back-off algorithm is used to ensure
 // endpoints are not overwhelmed with retries. If unset, the default retry
@@ -142,3 +364,27 @@ func WithTimeout(duration time.Duration) Option {
 func WithRetry(rc RetryConfig) Option {
 	return wrappedOption{otlpconfig.WithRetry(retry.Config(rc))}
 }
+
+// WithRetryDisabled disables the retries of failed exports: an export fails
+// with the error of its first attempt, e.g. when a queue in front of the
+// exporter retries them. It is a shorthand for WithRetry with a disabled
+// RetryConfig.
+func WithRetryDisabled() Option {
+	return wrappedOption{otlpconfig.WithRetryDisabled()}
+}
+
+// WithRetryInitialInterval sets the time to wait after the first failure of an
+// export before retrying it, leaving the other settings of the retry policy,
+// the default one or the one set with WithRetry, unchanged. Pass it after
+// WithRetry, which replaces the whole retry policy.
+func WithRetryInitialInterval(d time.Duration) Option {
+	return wrappedOption{otlpconfig.WithRetryInitialInterval(d)}
+}
+
+// WithRetryMaxInterval sets the upper bound of the exponentially increasing
+// time to wait between the retries of an export, leaving the other settings of
+// the retry policy, the default one or the one set with WithRetry, unchanged.
+// Pass it after WithRetry, which replaces the whole retry policy.
+func WithRetryMaxInterval(d time.Duration) Option {
+	return wrappedOption{otlpconfig.WithRetryMaxInterval(d)}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogshttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sync"
+	"unicode"
+)
+
+// Field numbers of the repeated messages of an ExportLogsServiceRequest.
+const (
+	resourceLogsField protowire.Number = 1
+	scopeLogsField    protowire.Number = 2
+	logRecordsField   protowire.Number = 2
+)
+
+// bodyStream is the streamed body of an export request, see
+// WithStreamingBody. Each reader encodes the payload again in a pipe while it
+// is read, so that a retried request does not reuse a consumed body.
+type bodyStream struct {
+	encode func(io.Writer) error
+	// gzPool is nil if the payload is not compressed.
+	gzPool *sync.Pool
+
+	mu      sync.Mutex
+	readers []*streamReader
+}
+
+// streamReader is the read end of the pipe of an encoding.
+type streamReader struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+// Close closes the pipe and waits for the encoding to stop, so that it never
+// outlives the export.
+func (r *streamReader) Close() error {
+	err := r.PipeReader.Close()
+	<-r.done
+	return err
+}
+
+// reader returns a new reader of the body, encoding it in a new goroutine.
+func (s *bodyStream) reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+	r := &streamReader{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		_ = pw.CloseWithError(s.write(pw))
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readers = append(s.readers, r)
+	return r
+}
+
+func (s *bodyStream) write(w io.Writer) error {
+	if s.gzPool == nil {
+		return s.encode(w)
+	}
+	gz := s.gzPool.Get().(*gzip.Writer)
+	defer s.gzPool.Put(gz)
+	gz.Reset(w)
+	if err := s.encode(gz); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// close closes the readers returned so far and waits for their encodings to
+// stop.
+func (s *bodyStream) close() {
+	s.mu.Lock()
+	readers := s.readers
+	s.readers = nil
+	s.mu.Unlock()
+	for _, r := range readers {
+		_ = r.Close()
+	}
+}
+
+// writeProtoLogs writes the ExportLogsServiceRequest of protoLogs encoded as
+// protobuf to w, one log record at a time. The length of each message,
+// written ahead of its fields, is computed with proto.Size.
+func writeProtoLogs(w io.Writer, protoLogs []*logspb.ResourceLogs) error {
+	enc := protoEncoder{w: w}
+	for _, rl := range protoLogs {
+		enc.header(resourceLogsField, proto.Size(rl))
+		enc.message(&logspb.ResourceLogs{Resource: rl.Resource, SchemaUrl: rl.SchemaUrl})
+		for _, sl := range rl.ScopeLogs {
+			enc.header(scopeLogsField, proto.Size(sl))
+			enc.message(&logspb.ScopeLogs{Scope: sl.Scope, SchemaUrl: sl.SchemaUrl})
+			for _, lr := range sl.LogRecords {
+				enc.header(logRecordsField, proto.Size(lr))
+				enc.message(lr)
+			}
+		}
+	}
+	return enc.err
+}
+
+// protoEncoder writes protobuf messages to w, keeping the first error.
+type protoEncoder struct {
+	w   io.Writer
+	buf []byte
+	err error
+}
+
+// header buffers the tag and length of an embedded message field.
+func (e *protoEncoder) header(num protowire.Number, size int) {
+	e.buf = protowire.AppendTag(e.buf, num, protowire.BytesType)
+	e.buf = protowire.AppendVarint(e.buf, uint64(size))
+}
+
+// message writes the buffered headers followed by the fields of m.
+func (e *protoEncoder) message(m proto.Message) {
+	if e.err != nil {
+		return
+	}
+	e.buf, e.err = proto.MarshalOptions{}.MarshalAppend(e.buf, m)
+	if e.err == nil {
+		_, e.err = e.w.Write(e.buf)
+	}
+	e.buf = e.buf[:0]
+}
+
+// writeJSONLogs writes the ExportLogsServiceRequest of protoLogs encoded as
+// JSON to w, one log record at a time.
+func writeJSONLogs(w io.Writer, protoLogs []*logspb.ResourceLogs) error {
+	enc := jsonEncoder{w: w}
+	enc.write([]byte(`{"resourceLogs":[`))
+	for i, rl := range protoLogs {
+		enc.separator(i)
+		enc.open(&logspb.ResourceLogs{Resource: rl.Resource, SchemaUrl: rl.SchemaUrl}, "scopeLogs")
+		for j, sl := range rl.ScopeLogs {
+			enc.separator(j)
+			enc.open(&logspb.ScopeLogs{Scope: sl.Scope, SchemaUrl: sl.SchemaUrl}, "logRecords")
+			for k, lr := range sl.LogRecords {
+				enc.separator(k)
+				enc.message(lr)
+			}
+			enc.write([]byte("]}"))
+		}
+		enc.write([]byte("]}"))
+	}
+	enc.write([]byte("]}"))
+	return enc.err
+}
+
+// jsonEncoder writes JSON messages to w, keeping the first error.
+type jsonEncoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *jsonEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// separator writes the comma preceding the i-th element of an array.
+func (e *jsonEncoder) separator(i int) {
+	if i > 0 {
+		e.write([]byte(","))
+	}
+}
+
+func (e *jsonEncoder) message(m proto.Message) {
+	if e.err != nil {
+		return
+	}
+	var b []byte
+	if b, e.err = protojson.Marshal(m); e.err == nil {
+		e.write(b)
+	}
+}
+
+// open writes the fields of m, leaving it open with the array field started,
+// e.g. {"resource":{},"scopeLogs":[
+func (e *jsonEncoder) open(m proto.Message, field string) {
+	if e.err != nil {
+		return
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		e.err = err
+		return
+	}
+	// Drop the closing brace of the object.
+	b = bytes.TrimRightFunc(b, unicode.IsSpace)
+	b = b[:len(b)-1]
+	if len(bytes.TrimSpace(b)) > 1 {
+		b = append(b, ',')
+	}
+	b = append(b, `"`+field+`":[`...)
+	e.write(b)
+}
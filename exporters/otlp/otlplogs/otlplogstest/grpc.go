@@ -0,0 +1,151 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package otlplogstest provides OTLP collectors running in the test process to
+// test the export of logs end to end, with the clients of this module or with
+// any other OTLP exporter.
+package otlplogstest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogsgrpc"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// GRPCEndpoint is the endpoint of a GRPCCollector. It is only reachable with
+// the dial option of the collector.
+const GRPCEndpoint = "bufnet"
+
+const bufSize = 1 << 20
+
+// GRPCCollector is an OTLP/gRPC logs collector listening in memory. It
+// records the requests it receives and their metadata.
+type GRPCCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []metadata.MD
+	err      error
+	stopOnce sync.Once
+}
+
+// NewGRPCCollector starts a GRPCCollector, stopped at the end of the test.
+func NewGRPCCollector(t testing.TB) *GRPCCollector {
+	t.Helper()
+	c := &GRPCCollector{
+		listener: bufconn.Listen(bufSize),
+		server:   grpc.NewServer(),
+	}
+	collogspb.RegisterLogsServiceServer(c.server, c)
+	go func() { _ = c.server.Serve(c.listener) }()
+	t.Cleanup(c.Stop)
+	return c
+}
+
+// Export records the request and its metadata. It returns the error set with
+// SetError, if any.
+func (c *GRPCCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, md.Copy())
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// SetError sets the error returned by the next exports, e.g. a status.Error
+// with codes.Unavailable to test retries. A nil error restores successful
+// exports. The requests are recorded either way.
+func (c *GRPCCollector) SetError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// DialOption returns the dial option connecting a gRPC client to the
+// collector.
+func (c *GRPCCollector) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return c.listener.DialContext(ctx)
+	})
+}
+
+// ClientOptions returns the options of an otlplogsgrpc client exporting to the
+// collector. Options passed after them to otlplogsgrpc.NewClient take
+// precedence.
+func (c *GRPCCollector) ClientOptions() []otlplogsgrpc.Option {
+	return []otlplogsgrpc.Option{
+		otlplogsgrpc.WithEndpoint(GRPCEndpoint),
+		otlplogsgrpc.WithInsecure(),
+		otlplogsgrpc.WithDialOption(c.DialOption()),
+	}
+}
+
+// Requests returns the requests received by the collector, in order.
+func (c *GRPCCollector) Requests() []*collogspb.ExportLogsServiceRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*collogspb.ExportLogsServiceRequest(nil), c.requests...)
+}
+
+// Headers returns the metadata of the requests received by the collector, in
+// order.
+func (c *GRPCCollector) Headers() []metadata.MD {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]metadata.MD(nil), c.headers...)
+}
+
+// LogRecords returns the log records of all the requests received by the
+// collector, in order.
+func (c *GRPCCollector) LogRecords() []*logspb.LogRecord {
+	return logRecords(c.Requests())
+}
+
+// Stop stops the collector, closing the connections of its clients. It is
+// safe to call it more than once.
+func (c *GRPCCollector) Stop() {
+	c.stopOnce.Do(c.server.Stop)
+}
+
+func logRecords(requests []*collogspb.ExportLogsServiceRequest) []*logspb.LogRecord {
+	var records []*logspb.LogRecord
+	for _, req := range requests {
+		for _, rl := range req.GetResourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				records = append(records, sl.GetLogRecords()...)
+			}
+		}
+	}
+	return records
+}
@@ -0,0 +1,90 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogstest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogsgrpc"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogstest"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/logs/logstest"
+)
+
+var body = "Log Record 0"
+var roLogRecords = logstest.LogRecordStubs{{Body: &body}}.Snapshots()
+
+func newGRPCExporter(t *testing.T, c *otlplogstest.GRPCCollector, opts ...otlplogsgrpc.Option) *otlplogs.Exporter {
+	t.Helper()
+	client := otlplogsgrpc.NewClient(append(c.ClientOptions(), opts...)...)
+	exp, err := otlplogs.NewExporter(context.Background(), otlplogs.WithClient(client))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+	return exp
+}
+
+func TestGRPCCollector(t *testing.T) {
+	c := otlplogstest.NewGRPCCollector(t)
+	exp := newGRPCExporter(t, c, otlplogsgrpc.WithHeaders(map[string]string{"authorization": "Bearer token"}))
+
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+
+	require.Len(t, c.Requests(), 2)
+	records := c.LogRecords()
+	require.Len(t, records, 2)
+	assert.Equal(t, body, records[0].GetBody().GetStringValue())
+
+	headers := c.Headers()
+	require.Len(t, headers, 2)
+	assert.Equal(t, []string{"Bearer token"}, headers[0].Get("authorization"))
+	assert.NotEmpty(t, headers[0].Get("user-agent"))
+}
+
+func TestGRPCCollectorSetError(t *testing.T) {
+	c := otlplogstest.NewGRPCCollector(t)
+	exp := newGRPCExporter(t, c, otlplogsgrpc.WithRetry(otlplogsgrpc.RetryConfig{Enabled: false}))
+
+	c.SetError(status.Error(codes.PermissionDenied, "denied"))
+	err := exp.Export(context.Background(), roLogRecords)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	c.SetError(nil)
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+	assert.Len(t, c.Requests(), 2, "failed requests are recorded")
+}
+
+func TestGRPCCollectorStop(t *testing.T) {
+	c := otlplogstest.NewGRPCCollector(t)
+	exp := newGRPCExporter(t, c,
+		otlplogsgrpc.WithRetry(otlplogsgrpc.RetryConfig{Enabled: false}),
+		otlplogsgrpc.WithTimeout(time.Second),
+	)
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+
+	c.Stop()
+	c.Stop()
+	assert.Error(t, exp.Export(context.Background(), roLogRecords))
+	assert.Len(t, c.Requests(), 1)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogstest
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const contentTypeJSON = "application/json"
+
+// HTTPResponse is a response returned by an HTTPCollector.
+type HTTPResponse struct {
+	// StatusCode is the status of the response, 200 if zero.
+	StatusCode int
+	// Header is added to the headers of the response, e.g. Retry-After.
+	Header http.Header
+	// PartialSuccess is returned in the body of a successful response.
+	PartialSuccess *collogspb.ExportLogsPartialSuccess
+}
+
+// HTTPCollector is an OTLP/HTTP logs collector built on httptest. It accepts
+// the protobuf and JSON encodings, gzip compressed or not, and records the
+// requests it receives with their headers and decompressed bodies.
+type HTTPCollector struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	requests  []*collogspb.ExportLogsServiceRequest
+	headers   []http.Header
+	bodies    [][]byte
+	responses []HTTPResponse
+}
+
+// NewHTTPCollector starts an HTTPCollector, closed at the end of the test.
+func NewHTTPCollector(t testing.TB) *HTTPCollector {
+	t.Helper()
+	c := &HTTPCollector{}
+	c.server = httptest.NewServer(http.HandlerFunc(c.serveHTTP))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+// Respond queues responses returned, in order, to the next requests. Once the
+// queued responses are used up, the collector responds with 200 OK.
+func (c *HTTPCollector) Respond(responses ...HTTPResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses = append(c.responses, responses...)
+}
+
+func (c *HTTPCollector) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), contentTypeJSON)
+	req := &collogspb.ExportLogsServiceRequest{}
+	if isJSON {
+		err = protojson.Unmarshal(body, req)
+	} else {
+		err = proto.Unmarshal(body, req)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header.Clone())
+	c.bodies = append(c.bodies, body)
+	var resp HTTPResponse
+	if len(c.responses) > 0 {
+		resp = c.responses[0]
+		c.responses = c.responses[1:]
+	}
+	c.mu.Unlock()
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	if resp.StatusCode != 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		w.WriteHeader(resp.StatusCode)
+		return
+	}
+
+	var raw []byte
+	msg := &collogspb.ExportLogsServiceResponse{PartialSuccess: resp.PartialSuccess}
+	if isJSON {
+		w.Header().Set("Content-Type", contentTypeJSON)
+		raw, err = protojson.Marshal(msg)
+	} else {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		raw, err = proto.Marshal(msg)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(max(resp.StatusCode, http.StatusOK))
+	_, _ = w.Write(raw)
+}
+
+// Endpoint returns the host and port of the collector.
+func (c *HTTPCollector) Endpoint() string {
+	return strings.TrimPrefix(c.server.URL, "http://")
+}
+
+// ClientOptions returns the options of an otlplogshttp client exporting to the
+// collector. Options passed after them to otlplogshttp.NewClient take
+// precedence.
+func (c *HTTPCollector) ClientOptions() []otlplogshttp.Option {
+	return []otlplogshttp.Option{
+		otlplogshttp.WithEndpoint(c.Endpoint()),
+		otlplogshttp.WithInsecure(),
+	}
+}
+
+// Requests returns the requests received by the collector, in order.
+func (c *HTTPCollector) Requests() []*collogspb.ExportLogsServiceRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*collogspb.ExportLogsServiceRequest(nil), c.requests...)
+}
+
+// Headers returns the headers of the requests received by the collector, in
+// order.
+func (c *HTTPCollector) Headers() []http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]http.Header(nil), c.headers...)
+}
+
+// Bodies returns the decompressed bodies of the requests received by the
+// collector, in order.
+func (c *HTTPCollector) Bodies() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.bodies...)
+}
+
+// LogRecords returns the log records of all the requests received by the
+// collector, in order.
+func (c *HTTPCollector) LogRecords() []*logspb.LogRecord {
+	return logRecords(c.Requests())
+}
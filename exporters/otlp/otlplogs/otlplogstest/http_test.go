@@ -0,0 +1,127 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlplogstest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
+	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogstest"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+)
+
+func newHTTPExporter(t *testing.T, c *otlplogstest.HTTPCollector, callback func(otlplogs.ExportResult), opts ...otlplogshttp.Option) *otlplogs.Exporter {
+	t.Helper()
+	client := otlplogshttp.NewClient(append(c.ClientOptions(), opts...)...)
+	exporterOpts := []otlplogs.ExporterOption{otlplogs.WithClient(client)}
+	if callback != nil {
+		exporterOpts = append(exporterOpts, otlplogs.WithExportCallback(callback))
+	}
+	exp, err := otlplogs.NewExporter(context.Background(), exporterOpts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+	return exp
+}
+
+func TestHTTPCollector(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []otlplogshttp.Option
+		contentType string
+		encoding    string
+	}{
+		{name: "protobuf", contentType: "application/x-protobuf"},
+		{name: "json", opts: []otlplogshttp.Option{otlplogshttp.WithJsonProtocol()}, contentType: "application/json"},
+		{
+			name:        "gzip",
+			opts:        []otlplogshttp.Option{otlplogshttp.WithCompression(otlplogshttp.GzipCompression)},
+			contentType: "application/x-protobuf",
+			encoding:    "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := otlplogstest.NewHTTPCollector(t)
+			opts := append([]otlplogshttp.Option{otlplogshttp.WithHeaders(map[string]string{"Authorization": "Bearer token"})}, tt.opts...)
+			exp := newHTTPExporter(t, c, nil, opts...)
+			require.NoError(t, exp.Export(context.Background(), roLogRecords))
+
+			records := c.LogRecords()
+			require.Len(t, records, 1)
+			assert.Equal(t, body, records[0].GetBody().GetStringValue())
+
+			headers := c.Headers()
+			require.Len(t, headers, 1)
+			assert.Equal(t, tt.contentType, headers[0].Get("Content-Type"))
+			assert.Equal(t, tt.encoding, headers[0].Get("Content-Encoding"))
+			assert.Equal(t, "Bearer token", headers[0].Get("Authorization"))
+
+			bodies := c.Bodies()
+			require.Len(t, bodies, 1)
+			assert.Contains(t, string(bodies[0]), body, "bodies are decompressed")
+		})
+	}
+}
+
+func TestHTTPCollectorRetry(t *testing.T) {
+	c := otlplogstest.NewHTTPCollector(t)
+	c.Respond(otlplogstest.HTTPResponse{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"0"}},
+	})
+	exp := newHTTPExporter(t, c, nil, otlplogshttp.WithRetry(otlplogshttp.RetryConfig{
+		Enabled:         true,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxElapsedTime:  time.Minute,
+	}))
+
+	require.NoError(t, exp.Export(context.Background(), roLogRecords))
+	assert.Len(t, c.Requests(), 2, "the throttled request is retried")
+	assert.Len(t, c.LogRecords(), 2)
+}
+
+func TestHTTPCollectorPartialSuccess(t *testing.T) {
+	for _, json := range []bool{false, true} {
+		c := otlplogstest.NewHTTPCollector(t)
+		c.Respond(otlplogstest.HTTPResponse{PartialSuccess: &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: 1,
+			ErrorMessage:       "too old",
+		}})
+
+		var result otlplogs.ExportResult
+		var opts []otlplogshttp.Option
+		if json {
+			opts = append(opts, otlplogshttp.WithJsonProtocol())
+		}
+		exp := newHTTPExporter(t, c, func(r otlplogs.ExportResult) { result = r }, opts...)
+
+		require.NoError(t, exp.Export(context.Background(), roLogRecords))
+		assert.True(t, result.PartialSuccess)
+		assert.Equal(t, int64(1), result.RejectedRecords)
+
+		require.NoError(t, exp.Export(context.Background(), roLogRecords))
+		assert.False(t, result.PartialSuccess, "later requests succeed")
+	}
+}
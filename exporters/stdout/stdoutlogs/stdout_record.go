@@ -37,6 +37,7 @@ type stdOutLogRecord struct {
 	TraceFlags           *trace.TraceFlags
 	SeverityText         *string
 	SeverityNumber       *logs.SeverityNumber
+	EventName            *string
 	Body                 *string
 	Resource             *resource.Resource
 	InstrumentationScope *instrumentation.Scope
@@ -83,6 +84,7 @@ func logRecordsFromReadableLogRecords(logRecords []sdk.ReadableLogRecord) []stdO
 			TraceFlags:           lr.TraceFlags(),
 			SeverityText:         lr.SeverityText(),
 			SeverityNumber:       lr.SeverityNumber(),
+			EventName:            lr.EventName(),
 			Body:                 convertBodyToString(lr.Body()),
 			Resource:             lr.Resource(),
 			InstrumentationScope: lr.InstrumentationScope(),
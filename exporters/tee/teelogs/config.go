@@ -0,0 +1,73 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package teelogs
+
+import (
+	"fmt"
+)
+
+// Policy selects when an export to several exporters succeeds.
+type Policy int
+
+const (
+	// RequireAll makes an export succeed only if it succeeds for all the
+	// exporters.
+	RequireAll Policy = iota
+	// RequireAny makes an export succeed if it succeeds for at least one of
+	// the exporters. The errors of the other exporters are reported to the
+	// global error handler.
+	RequireAny
+)
+
+// config contains options for the tee exporter.
+type config struct {
+	// Policy selects when an export succeeds. If not set, RequireAll is used.
+	Policy Policy
+}
+
+// newConfig creates a validated Config configured with options.
+func newConfig(options ...Option) (config, error) {
+	cfg := config{
+		Policy: RequireAll,
+	}
+	for _, opt := range options {
+		cfg = opt.apply(cfg)
+	}
+	if cfg.Policy != RequireAll && cfg.Policy != RequireAny {
+		return cfg, fmt.Errorf("unknown policy: %d", cfg.Policy)
+	}
+	return cfg, nil
+}
+
+// Option sets the value of an option for a Config.
+type Option interface {
+	apply(config) config
+}
+
+// WithPolicy sets when an export succeeds, RequireAll by default.
+func WithPolicy(policy Policy) Option {
+	return policyOption{policy}
+}
+
+type policyOption struct {
+	P Policy
+}
+
+func (o policyOption) apply(cfg config) config {
+	cfg.Policy = o.P
+	return cfg
+}
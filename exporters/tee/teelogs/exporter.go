@@ -0,0 +1,118 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package teelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	sdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"go.opentelemetry.io/otel"
+	"sync"
+)
+
+var _ sdk.LogRecordExporter = &Exporter{}
+
+var errNoExporters = errors.New("no exporter to send the logs to")
+
+// NewExporter creates an Exporter sending the logs to all the exporters, with
+// the passed options.
+func NewExporter(exporters []sdk.LogRecordExporter, options ...Option) (*Exporter, error) {
+	if len(exporters) == 0 {
+		return nil, errNoExporters
+	}
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		exporters: append([]sdk.LogRecordExporter(nil), exporters...),
+		policy:    cfg.Policy,
+	}, nil
+}
+
+// Exporter is an implementation of logs.LogRecordExporter that sends each
+// batch of logs to several exporters.
+type Exporter struct {
+	exporters []sdk.LogRecordExporter
+	policy    Policy
+}
+
+// Export sends logs to all the exporters concurrently and waits for them. It
+// returns the joined errors of the exporters, unless the policy is RequireAny
+// and at least one of them succeeded.
+func (e *Exporter) Export(ctx context.Context, logs []sdk.ReadableLogRecord) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	errs := e.each(func(exporter sdk.LogRecordExporter) error {
+		return exporter.Export(ctx, logs)
+	})
+
+	err := errors.Join(errs...)
+	if err != nil && e.policy == RequireAny && len(errs) < len(e.exporters) {
+		otel.Handle(err)
+		return nil
+	}
+	return err
+}
+
+// Shutdown shuts down all the exporters and returns their joined errors.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.each(func(exporter sdk.LogRecordExporter) error {
+		return exporter.Shutdown(ctx)
+	})...)
+}
+
+// each calls fn for all the exporters concurrently and returns the errors it
+// returned, identified by the index of the exporter.
+func (e *Exporter) each(fn func(sdk.LogRecordExporter) error) []error {
+	results := make([]error, len(e.exporters))
+	var wg sync.WaitGroup
+	for i, exporter := range e.exporters {
+		wg.Add(1)
+		go func(i int, exporter sdk.LogRecordExporter) {
+			defer wg.Done()
+			if err := fn(exporter); err != nil {
+				results[i] = fmt.Errorf("exporter %d: %w", i, err)
+			}
+		}(i, exporter)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this exporter.
+func (e *Exporter) MarshalLog() interface{} {
+	return struct {
+		Type      string
+		Exporters int
+		Policy    Policy
+	}{
+		Type:      "tee",
+		Exporters: len(e.exporters),
+		Policy:    e.policy,
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package teelogs
+
+import (
+	"context"
+	"errors"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	sdk "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"sync"
+	"testing"
+)
+
+// memoryExporter keeps the bodies of the logs it exports.
+type memoryExporter struct {
+	err error
+
+	mu       sync.Mutex
+	bodies   []string
+	shutdown bool
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdk.ReadableLogRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.bodies = append(e.bodies, r.Body().(string))
+	}
+	return e.err
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return e.err
+}
+
+func (e *memoryExporter) exported() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.bodies
+}
+
+func emit(t *testing.T, exporter sdk.LogRecordExporter, bodies ...string) {
+	t.Helper()
+	lp := sdk.NewLoggerProvider(sdk.WithBatcher(exporter))
+	for _, body := range bodies {
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	}
+	_ = lp.ForceFlush(context.Background())
+}
+
+func TestExporter(t *testing.T) {
+	old, migrated := &memoryExporter{}, &memoryExporter{}
+	exporter, err := NewExporter([]sdk.LogRecordExporter{old, migrated})
+	require.NoError(t, err)
+
+	emit(t, exporter, "first", "second")
+	assert.Equal(t, []string{"first", "second"}, old.exported())
+	assert.Equal(t, []string{"first", "second"}, migrated.exported())
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	assert.True(t, old.shutdown)
+	assert.True(t, migrated.shutdown)
+}
+
+func TestExporterPolicy(t *testing.T) {
+	errUnavailable := errors.New("collector unavailable")
+	tests := []struct {
+		name    string
+		policy  Policy
+		errs    []error
+		wantErr bool
+		handled int
+	}{
+		{name: "all succeed", policy: RequireAll, errs: []error{nil, nil}},
+		{name: "all with one failure", policy: RequireAll, errs: []error{nil, errUnavailable}, wantErr: true},
+		{name: "any with one failure", policy: RequireAny, errs: []error{nil, errUnavailable}, handled: 1},
+		{name: "any with all failures", policy: RequireAny, errs: []error{errUnavailable, errUnavailable}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled []error
+			otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+			t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+			var exporters []sdk.LogRecordExporter
+			for _, err := range tt.errs {
+				exporters = append(exporters, &memoryExporter{err: err})
+			}
+			exporter, err := NewExporter(exporters, WithPolicy(tt.policy))
+			require.NoError(t, err)
+
+			body := "payment accepted"
+			records := recordsOf(t, body)
+			err = exporter.Export(context.Background(), records)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errUnavailable)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, handled, tt.handled)
+			for _, e := range exporters {
+				assert.Equal(t, []string{body}, e.(*memoryExporter).exported())
+			}
+		})
+	}
+}
+
+func TestExporterErrorsIdentifyExporters(t *testing.T) {
+	exporter, err := NewExporter([]sdk.LogRecordExporter{
+		&memoryExporter{err: errors.New("old collector unavailable")},
+		&memoryExporter{err: errors.New("new collector unavailable")},
+	})
+	require.NoError(t, err)
+
+	err = exporter.Shutdown(context.Background())
+	assert.ErrorContains(t, err, "exporter 0: old collector unavailable")
+	assert.ErrorContains(t, err, "exporter 1: new collector unavailable")
+}
+
+func TestNewExporterErrors(t *testing.T) {
+	_, err := NewExporter(nil)
+	assert.ErrorIs(t, err, errNoExporters)
+
+	_, err = NewExporter([]sdk.LogRecordExporter{&memoryExporter{}}, WithPolicy(Policy(7)))
+	assert.Error(t, err)
+}
+
+// recordsOf returns the records emitted with bodies.
+func recordsOf(t *testing.T, bodies ...string) []sdk.ReadableLogRecord {
+	t.Helper()
+	exporter := &recordsExporter{}
+	lp := sdk.NewLoggerProvider(sdk.WithSyncer(exporter))
+	for _, body := range bodies {
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	}
+	return exporter.records
+}
+
+type recordsExporter struct {
+	records []sdk.ReadableLogRecord
+}
+
+func (e *recordsExporter) Export(_ context.Context, records []sdk.ReadableLogRecord) error {
+	e.records = append(e.records, records...)
+	return nil
+}
+
+func (e *recordsExporter) Shutdown(context.Context) error { return nil }
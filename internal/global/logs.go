@@ -17,6 +17,7 @@ limitations under the License.
 package global
 
 import (
+	"context"
 	"github.com/metoro-io/opentelemetry-logs-go/logs"
 	"sync"
 	"sync/atomic"
@@ -107,8 +108,12 @@ type logger struct {
 	delegate atomic.Value
 }
 
-// Compile-time guarantee that logger implements the logs.Logger interface.
-var _ logs.Logger = &logger{}
+// Compile-time guarantee that logger implements the logs.BatchLogger and
+// logs.EnabledLogger interfaces.
+var (
+	_ logs.BatchLogger   = &logger{}
+	_ logs.EnabledLogger = &logger{}
+)
 
 func (t *logger) Emit(logRecord logs.LogRecord) {
 	delegate := t.delegate.Load()
@@ -117,6 +122,33 @@ func (t *logger) Emit(logRecord logs.LogRecord) {
 	}
 }
 
+// EmitBatch passes the records to the delegate at once if it is a
+// logs.BatchLogger, one by one to its Emit otherwise.
+func (t *logger) EmitBatch(ctx context.Context, records []logs.LogRecord) {
+	delegate := t.delegate.Load()
+	if delegate == nil {
+		return
+	}
+	if bl, ok := delegate.(logs.BatchLogger); ok {
+		bl.EmitBatch(ctx, records)
+		return
+	}
+	for _, r := range records {
+		delegate.(logs.Logger).Emit(r)
+	}
+}
+
+func (t *logger) Enabled(ctx context.Context, severity logs.SeverityNumber) bool {
+	delegate := t.delegate.Load()
+	if delegate != nil {
+		if el, ok := delegate.(logs.EnabledLogger); ok {
+			return el.Enabled(ctx, severity)
+		}
+		return true
+	}
+	return false
+}
+
 // setDelegate configures t to delegate all Logger functionality to Loggers
 // created by provider.
 //
@@ -0,0 +1,62 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stacktrace captures the stack traces recorded as the
+// exception.stacktrace attribute of the logs.
+package stacktrace
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxDepth is the maximum number of frames of the captured stack traces.
+const maxDepth = 64
+
+// Capture returns the stack trace of the goroutine in the format of the Go
+// panics: the function of each frame followed by its file and line on an
+// indented line. skip is the number of frames skipped above the caller of
+// Capture, 0 starting the trace at the caller. The frames following them whose
+// function starts with one of skipPrefixes are skipped too, e.g. the ones of a
+// package delegating to the caller.
+func Capture(skip int, skipPrefixes ...string) string {
+	pcs := make([]uintptr, maxDepth)
+	// Skip runtime.Callers and Capture.
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 || !hasPrefix(frame.Function, skipPrefixes) {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// hasPrefix returns true if function starts with one of prefixes.
+func hasPrefix(function string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -24,6 +24,8 @@ type LoggerConfig struct {
 	// Schema URL of the telemetry emitted by the Logger.
 	schemaURL string
 	attrs     attribute.Set
+	// Default attributes of the records emitted by the Logger.
+	recordAttrs attribute.Set
 }
 
 // InstrumentationVersion returns the version of the library providing instrumentation.
@@ -37,6 +39,12 @@ func (t *LoggerConfig) InstrumentationAttributes() attribute.Set {
 	return t.attrs
 }
 
+// Attributes returns the default attributes of the records emitted by the
+// Logger.
+func (t *LoggerConfig) Attributes() attribute.Set {
+	return t.recordAttrs
+}
+
 // SchemaURL returns the Schema URL of the telemetry emitted by the Logger.
 func (t *LoggerConfig) SchemaURL() string {
 	return t.schemaURL
@@ -80,6 +88,20 @@ func WithInstrumentationAttributes(attr ...attribute.KeyValue) LoggerOption {
 	})
 }
 
+// WithAttributes sets default attributes added to every record emitted by
+// the Logger. On conflict, the attributes of the record win.
+//
+// Unlike WithInstrumentationAttributes, which describe the instrumentation
+// scope, these attributes are set on each record.
+//
+// The passed attributes will be de-duplicated.
+func WithAttributes(attr ...attribute.KeyValue) LoggerOption {
+	return loggerOptionFunc(func(config LoggerConfig) LoggerConfig {
+		config.recordAttrs = attribute.NewSet(attr...)
+		return config
+	})
+}
+
 // WithSchemaURL sets the schema URL for the Logger.
 func WithSchemaURL(schemaURL string) LoggerOption {
 	return loggerOptionFunc(func(cfg LoggerConfig) LoggerConfig {
@@ -0,0 +1,106 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"github.com/metoro-io/opentelemetry-logs-go/internal/stacktrace"
+	"github.com/metoro-io/opentelemetry-logs-go/semconv"
+	"go.opentelemetry.io/otel/attribute"
+	"reflect"
+	"slices"
+)
+
+// ErrorOption configures how SetBodyFromError converts an error.
+type ErrorOption interface {
+	applyError(errorConfig) errorConfig
+}
+
+type errorConfig struct {
+	stackTrace bool
+}
+
+type errorOptionFunc func(errorConfig) errorConfig
+
+func (fn errorOptionFunc) applyError(cfg errorConfig) errorConfig {
+	return fn(cfg)
+}
+
+// WithStackTrace captures the stack trace of the caller of SetBodyFromError as
+// the exception.stacktrace attribute. It is disabled by default as capturing
+// the stack is costly.
+func WithStackTrace() ErrorOption {
+	return errorOptionFunc(func(cfg errorConfig) errorConfig {
+		cfg.stackTrace = true
+		return cfg
+	})
+}
+
+// SetBodyFromError sets the body of the record to the message of err and
+// adds the exception.message and exception.type attributes of the OpenTelemetry
+// exception conventions to its attributes, e.g. for a bridge logging an error.
+// The type is the one of the first error err wraps that is not a wrapper added
+// by fmt.Errorf, e.g. *fs.PathError rather than the *fmt.wrapError around it.
+// The attributes of c are copied rather than modified. A nil err leaves c
+// unchanged.
+func (c *LogRecordConfig) SetBodyFromError(err error, opts ...ErrorOption) {
+	if err == nil {
+		return
+	}
+	var cfg errorConfig
+	for _, opt := range opts {
+		cfg = opt.applyError(cfg)
+	}
+
+	msg := err.Error()
+	c.Body = nil
+	c.BodyAny = msg
+
+	var attrs []attribute.KeyValue
+	if c.Attributes != nil {
+		attrs = slices.Clip(*c.Attributes)
+	}
+	attrs = append(attrs, semconv.ExceptionMessage(msg), semconv.ExceptionType(errorType(err)))
+	if cfg.stackTrace {
+		attrs = append(attrs, semconv.ExceptionStacktrace(stacktrace.Capture(1)))
+	}
+	c.Attributes = &attrs
+}
+
+// errorType returns the type of the first error wrapped by err that is not
+// one of the wrappers added by fmt.Errorf.
+func errorType(err error) string {
+	for {
+		typ := reflect.TypeOf(err).String()
+		if typ != "*fmt.wrapError" && typ != "*fmt.wrapErrors" {
+			return typ
+		}
+		var inner error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			inner = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			// Errorf wrapping several errors has no single cause.
+			if errs := u.Unwrap(); len(errs) == 1 {
+				inner = errs[0]
+			}
+		}
+		if inner == nil {
+			return typ
+		}
+		err = inner
+	}
+}
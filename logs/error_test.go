@@ -0,0 +1,128 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/semconv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+type quotaError struct{ tenant string }
+
+func (e quotaError) Error() string { return "quota exceeded for " + e.tenant }
+
+func TestSetBodyFromError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantType string
+	}{
+		{
+			name:     "plain",
+			err:      errors.New("connection refused"),
+			wantType: "*errors.errorString",
+		},
+		{
+			name:     "custom type",
+			err:      quotaError{tenant: "acme"},
+			wantType: "logs.quotaError",
+		},
+		{
+			name:     "wrapped",
+			err:      fmt.Errorf("export: %w", fmt.Errorf("upload: %w", quotaError{tenant: "acme"})),
+			wantType: "logs.quotaError",
+		},
+		{
+			name:     "wrapped standard error",
+			err:      fmt.Errorf("open config: %w", &fs.PathError{Op: "open", Path: "/etc/app", Err: fs.ErrNotExist}),
+			wantType: "*fs.PathError",
+		},
+		{
+			name:     "wrapping several errors",
+			err:      fmt.Errorf("close: %w, %w", quotaError{tenant: "acme"}, fs.ErrClosed),
+			wantType: "*fmt.wrapErrors",
+		},
+		{
+			name:     "joined",
+			err:      errors.Join(errors.New("first"), errors.New("second")),
+			wantType: "*errors.joinError",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c LogRecordConfig
+			c.SetBodyFromError(tt.err)
+
+			assert.Equal(t, tt.err.Error(), c.BodyAny)
+			require.NotNil(t, c.Attributes)
+			assert.Equal(t, []attribute.KeyValue{
+				semconv.ExceptionMessage(tt.err.Error()),
+				semconv.ExceptionType(tt.wantType),
+			}, *c.Attributes)
+		})
+	}
+}
+
+func TestSetBodyFromErrorAttributes(t *testing.T) {
+	body := "previous body"
+	attrs := make([]attribute.KeyValue, 1, 4)
+	attrs[0] = attribute.String("tenant", "acme")
+	c := LogRecordConfig{Body: &body, Attributes: &attrs}
+	c.SetBodyFromError(errors.New("failed"))
+
+	assert.Nil(t, c.Body)
+	assert.Equal(t, "failed", NewLogRecord(c).Body())
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("tenant", "acme"),
+		semconv.ExceptionMessage("failed"),
+		semconv.ExceptionType("*errors.errorString"),
+	}, *c.Attributes)
+	assert.Len(t, attrs, 1, "the attributes passed are not modified")
+	assert.Equal(t, attrs[:cap(attrs)][1], attribute.KeyValue{}, "the backing array is not modified")
+}
+
+func TestSetBodyFromErrorNil(t *testing.T) {
+	var c LogRecordConfig
+	c.SetBodyFromError(nil, WithStackTrace())
+	assert.Equal(t, LogRecordConfig{}, c)
+}
+
+func TestSetBodyFromErrorStackTrace(t *testing.T) {
+	var c LogRecordConfig
+	c.SetBodyFromError(errors.New("failed"), WithStackTrace())
+
+	require.NotNil(t, c.Attributes)
+	require.Len(t, *c.Attributes, 3)
+	kv := (*c.Attributes)[2]
+	assert.Equal(t, semconv.ExceptionStacktraceKey, kv.Key)
+	stack := kv.Value.AsString()
+	first, _, _ := strings.Cut(stack, "\n")
+	assert.Equal(t, "github.com/metoro-io/opentelemetry-logs-go/logs.TestSetBodyFromErrorStackTrace", first, "the stack starts at the caller")
+	assert.Contains(t, stack, "error_test.go:")
+	assert.NotContains(t, stack, "SetBodyFromError\n")
+
+	c = LogRecordConfig{}
+	c.SetBodyFromError(errors.New("failed"))
+	assert.Len(t, *c.Attributes, 2, "no stack trace by default")
+}
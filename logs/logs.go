@@ -17,6 +17,7 @@ limitations under the License.
 package logs
 
 import (
+	"context"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -34,12 +35,17 @@ type LogRecordConfig struct {
 	TraceFlags        *trace.TraceFlags
 	SeverityText      *string
 	SeverityNumber    *SeverityNumber
+	// EventName identifies the record as a structured event of that name.
+	EventName *string
 	// Deprecated: use BodyAny instead.
 	Body                 *string
 	BodyAny              any
 	Resource             *resource.Resource
 	InstrumentationScope *instrumentation.Scope
 	Attributes           *[]attribute.KeyValue
+	// Context carries request-scoped values, such as a tenant routing key, to
+	// the SDK. It is not part of the exported record.
+	Context context.Context
 }
 
 // NewLogRecord constructs a LogRecord using values from the provided
@@ -56,10 +62,12 @@ func NewLogRecord(config LogRecordConfig) LogRecord {
 		traceFlags:           config.TraceFlags,
 		severityText:         config.SeverityText,
 		severityNumber:       config.SeverityNumber,
+		eventName:            config.EventName,
 		body:                 config.BodyAny,
 		resource:             config.Resource,
 		instrumentationScope: config.InstrumentationScope,
 		attributes:           config.Attributes,
+		ctx:                  config.Context,
 	}
 }
 
@@ -74,10 +82,12 @@ type LogRecord struct {
 	traceFlags           *trace.TraceFlags
 	severityText         *string
 	severityNumber       *SeverityNumber
+	eventName            *string
 	body                 any
 	resource             *resource.Resource
 	instrumentationScope *instrumentation.Scope
 	attributes           *[]attribute.KeyValue
+	ctx                  context.Context
 }
 
 func (l LogRecord) Timestamp() *time.Time                        { return l.timestamp }
@@ -87,10 +97,12 @@ func (l LogRecord) SpanId() *trace.SpanID                        { return l.span
 func (l LogRecord) TraceFlags() *trace.TraceFlags                { return l.traceFlags }
 func (l LogRecord) SeverityText() *string                        { return l.severityText }
 func (l LogRecord) SeverityNumber() *SeverityNumber              { return l.severityNumber }
+func (l LogRecord) EventName() *string                           { return l.eventName }
 func (l LogRecord) Body() any                                    { return l.body }
 func (l LogRecord) Resource() *resource.Resource                 { return l.resource }
 func (l LogRecord) InstrumentationScope() *instrumentation.Scope { return l.instrumentationScope }
 func (l LogRecord) Attributes() *[]attribute.KeyValue            { return l.attributes }
+func (l LogRecord) Context() context.Context                     { return l.ctx }
 func (l LogRecord) private()                                     {}
 
 // SeverityNumber Possible values for LogRecord.SeverityNumber.
@@ -131,6 +143,28 @@ type Logger interface {
 	Emit(logRecord LogRecord)
 }
 
+// EnabledLogger is a Logger that can report whether it would drop a record
+// before it is built. Callers find it by a type assertion on a Logger. A
+// Logger that does not implement EnabledLogger is assumed to emit every
+// record.
+type EnabledLogger interface {
+	Logger
+	// Enabled reports whether a record with the severity would be emitted
+	// in ctx. Callers can use it to skip building costly records that would
+	// be dropped.
+	Enabled(ctx context.Context, severity SeverityNumber) bool
+}
+
+// BatchLogger is a Logger that can emit several records in a single call,
+// e.g. when ingesting a file. Callers find it by a type assertion on a Logger
+// and otherwise pass the records one by one to Emit.
+type BatchLogger interface {
+	Logger
+	// EmitBatch emits the records in order, as if each was passed to Emit.
+	// A record without a context is emitted in ctx.
+	EmitBatch(ctx context.Context, records []LogRecord)
+}
+
 // LoggerProvider provides Loggers that are used by instrumentation code to
 // log computational workflows.
 //
@@ -16,6 +16,8 @@ limitations under the License.
 
 package logs
 
+import "context"
+
 // NewNoopLoggerProvider returns an implementation of LoggerProvider that
 // performs no operations. The Logger created from the returned
 // LoggerProvider also perform no operations.
@@ -33,6 +35,13 @@ func (p noopLoggerProvider) Logger(string, ...LoggerOption) Logger {
 
 type noopLogger struct{}
 
-var _ Logger = noopLogger{}
+var (
+	_ BatchLogger   = noopLogger{}
+	_ EnabledLogger = noopLogger{}
+)
 
 func (n noopLogger) Emit(logRecord LogRecord) {}
+
+func (n noopLogger) EmitBatch(context.Context, []LogRecord) {}
+
+func (n noopLogger) Enabled(context.Context, SeverityNumber) bool { return false }
@@ -19,6 +19,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Environment variable names.
@@ -35,6 +36,18 @@ const (
 	// 512). Note: it must be less than or equal to
 	// EnvBatchLogsProcessorMaxQueueSize.
 	BatchLogsProcessorMaxExportBatchSizeKey = "OTEL_BLRP_MAX_EXPORT_BATCH_SIZE"
+	// SDKDisabledKey disables the SDK when set to true (i.e. true).
+	SDKDisabledKey = "OTEL_SDK_DISABLED"
+	// LogsMinSeverityKey is the minimum severity of the emitted log records
+	// (i.e. info). It is not OTEL_LOG_LEVEL, the level of the internal logger
+	// of the SDKs.
+	LogsMinSeverityKey = "OTEL_LOGS_MIN_SEVERITY"
+	// LogRecordAttributesKey is the list of default attributes of the emitted
+	// log records (i.e. env=prod,region=us).
+	LogRecordAttributesKey = "OTEL_LOG_RECORD_ATTRIBUTES"
+	// LogsSelfMetricsKey enables the metrics of the SDK about itself, sent to
+	// the global meter provider (i.e. true).
+	LogsSelfMetricsKey = "OTEL_GO_X_LOGS_SELF_METRICS"
 )
 
 // firstInt returns the value of the first matching environment variable from
@@ -103,3 +116,29 @@ func BatchLogsProcessorMaxQueueSize(defaultValue int) int {
 func BatchLogsProcessorMaxExportBatchSize(defaultValue int) int {
 	return IntEnvOr(BatchLogsProcessorMaxExportBatchSizeKey, defaultValue)
 }
+
+// SDKDisabled returns true if the environment variable OTEL_SDK_DISABLED is
+// set to true, case-insensitively. Any other value keeps the SDK enabled.
+func SDKDisabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(SDKDisabledKey)), "true")
+}
+
+// LogsMinSeverity returns the environment variable value for the
+// OTEL_LOGS_MIN_SEVERITY key and whether it is set and not empty.
+func LogsMinSeverity() (string, bool) {
+	value := strings.TrimSpace(os.Getenv(LogsMinSeverityKey))
+	return value, value != ""
+}
+
+// LogRecordAttributes returns the environment variable value for the
+// OTEL_LOG_RECORD_ATTRIBUTES key and whether it is set and not empty.
+func LogRecordAttributes() (string, bool) {
+	value := strings.TrimSpace(os.Getenv(LogRecordAttributesKey))
+	return value, value != ""
+}
+
+// LogsSelfMetrics returns true if the environment variable
+// OTEL_GO_X_LOGS_SELF_METRICS is set to true, case-insensitively.
+func LogsSelfMetrics() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(LogsSelfMetricsKey)), "true")
+}
@@ -0,0 +1,172 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AttributeFilterProcessorOption configures an AttributeFilterProcessor.
+type AttributeFilterProcessorOption func(o *AttributeFilterProcessorOptions)
+
+// AttributeFilterProcessorOptions is configuration settings for an
+// AttributeFilterProcessor.
+type AttributeFilterProcessorOptions struct {
+	// Allowlist is the keys of the attributes kept, the other attributes are
+	// removed. A nil Allowlist keeps all the attributes.
+	// The default value of Allowlist is nil.
+	Allowlist []string
+
+	// Denylist is the keys of the attributes removed. It applies after the
+	// Allowlist.
+	// The default value of Denylist is nil.
+	Denylist []string
+}
+
+// WithAttributeKeyAllowlist returns an AttributeFilterProcessorOption that
+// configures an AttributeFilterProcessor to remove the attributes whose key is
+// not one of keys. An empty, non-nil keys removes all the attributes.
+func WithAttributeKeyAllowlist(keys []string) AttributeFilterProcessorOption {
+	return func(o *AttributeFilterProcessorOptions) {
+		o.Allowlist = keys
+	}
+}
+
+// WithAttributeKeyDenylist returns an AttributeFilterProcessorOption that
+// configures an AttributeFilterProcessor to remove the attributes whose key is
+// one of keys.
+func WithAttributeKeyDenylist(keys []string) AttributeFilterProcessorOption {
+	return func(o *AttributeFilterProcessorOptions) {
+		o.Denylist = keys
+	}
+}
+
+type attributeFilterProcessor struct {
+	next LogRecordProcessor
+	// allowed is nil if all the keys are allowed.
+	allowed map[attribute.Key]struct{}
+	denied  map[attribute.Key]struct{}
+}
+
+var _ LogRecordProcessor = (*attributeFilterProcessor)(nil)
+var _ FilterProcessor = (*attributeFilterProcessor)(nil)
+
+// NewAttributeFilterProcessor returns a new LogRecordProcessor that removes
+// the attributes of the log records not allowed by the supplied options, e.g.
+// to let only an approved set of attributes leave the process, then passes the
+// records to next. The number of removed attributes is added to the
+// DroppedAttributes of the records.
+//
+// The attributes of the resource and of the instrumentation scope are not
+// filtered.
+func NewAttributeFilterProcessor(next LogRecordProcessor, options ...AttributeFilterProcessorOption) LogRecordProcessor {
+	var o AttributeFilterProcessorOptions
+	for _, opt := range options {
+		opt(&o)
+	}
+	lrp := &attributeFilterProcessor{next: next, denied: keySet(o.Denylist)}
+	if o.Allowlist != nil {
+		lrp.allowed = keySet(o.Allowlist)
+	}
+	return lrp
+}
+
+// keySet returns the set of keys.
+func keySet(keys []string) map[attribute.Key]struct{} {
+	set := make(map[attribute.Key]struct{}, len(keys))
+	for _, key := range keys {
+		set[attribute.Key(key)] = struct{}{}
+	}
+	return set
+}
+
+// OnEmit passes the log record to the next processor, or a copy of it without
+// the removed attributes if it has any.
+func (lrp *attributeFilterProcessor) OnEmit(rol ReadableLogRecord) {
+	attrs := rol.Attributes()
+	if attrs == nil {
+		lrp.next.OnEmit(rol)
+		return
+	}
+	var kept []attribute.KeyValue
+	for i, kv := range *attrs {
+		if lrp.allows(kv.Key) {
+			if kept != nil {
+				kept = append(kept, kv)
+			}
+			continue
+		}
+		if kept == nil {
+			kept = append(make([]attribute.KeyValue, 0, len(*attrs)-1), (*attrs)[:i]...)
+		}
+	}
+	if kept == nil {
+		lrp.next.OnEmit(rol)
+		return
+	}
+	lrp.next.OnEmit(newFilteredLogRecord(rol, kept, len(*attrs)-len(kept)))
+}
+
+// allows returns true if the attribute with the key is kept.
+func (lrp *attributeFilterProcessor) allows(key attribute.Key) bool {
+	if lrp.allowed != nil {
+		if _, ok := lrp.allowed[key]; !ok {
+			return false
+		}
+	}
+	_, denied := lrp.denied[key]
+	return !denied
+}
+
+// newFilteredLogRecord returns a copy of rol with the attributes attrs and
+// dropped more dropped attributes.
+func newFilteredLogRecord(rol ReadableLogRecord, attrs []attribute.KeyValue, dropped int) ReadableLogRecord {
+	r := cloneLogRecord(rol, &attrs)
+	r.droppedAttributes += dropped
+	return r
+}
+
+// Enabled reports whether the next processor is enabled.
+func (lrp *attributeFilterProcessor) Enabled(ctx context.Context, severity logs.SeverityNumber) bool {
+	if fp, ok := lrp.next.(FilterProcessor); ok {
+		return fp.Enabled(ctx, severity)
+	}
+	return true
+}
+
+// Shutdown shuts down the next processor.
+func (lrp *attributeFilterProcessor) Shutdown(ctx context.Context) error {
+	return lrp.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the next processor.
+func (lrp *attributeFilterProcessor) ForceFlush(ctx context.Context) error {
+	return lrp.next.ForceFlush(ctx)
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
+func (lrp *attributeFilterProcessor) MarshalLog() interface{} {
+	return struct {
+		Type               string
+		LogRecordProcessor LogRecordProcessor
+	}{
+		Type:               "AttributeFilterProcessor",
+		LogRecordProcessor: lrp.next,
+	}
+}
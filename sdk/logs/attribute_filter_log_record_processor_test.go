@@ -0,0 +1,99 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"testing"
+)
+
+func TestAttributeFilterProcessor(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.String("component", "billing"),
+		attribute.String("user.email", "jane@example.com"),
+		attribute.String("http.route", "/pay"),
+		attribute.String("card.number", "4111"),
+	}
+	tests := []struct {
+		name        string
+		options     []AttributeFilterProcessorOption
+		want        []attribute.KeyValue
+		wantDropped int
+	}{
+		{
+			name: "no option",
+			want: attrs,
+		},
+		{
+			name:        "allowlist",
+			options:     []AttributeFilterProcessorOption{WithAttributeKeyAllowlist([]string{"component", "http.route"})},
+			want:        []attribute.KeyValue{attrs[0], attrs[2]},
+			wantDropped: 2,
+		},
+		{
+			name:        "empty allowlist",
+			options:     []AttributeFilterProcessorOption{WithAttributeKeyAllowlist([]string{})},
+			want:        []attribute.KeyValue{},
+			wantDropped: 4,
+		},
+		{
+			name:        "denylist",
+			options:     []AttributeFilterProcessorOption{WithAttributeKeyDenylist([]string{"user.email", "card.number"})},
+			want:        []attribute.KeyValue{attrs[0], attrs[2]},
+			wantDropped: 2,
+		},
+		{
+			name: "allowlist and denylist",
+			options: []AttributeFilterProcessorOption{
+				WithAttributeKeyAllowlist([]string{"component", "http.route", "card.number"}),
+				WithAttributeKeyDenylist([]string{"card.number"}),
+			},
+			want:        []attribute.KeyValue{attrs[0], attrs[2]},
+			wantDropped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingProcessor{}
+			lrp := NewAttributeFilterProcessor(next, tt.options...)
+
+			emitted := append([]attribute.KeyValue(nil), attrs...)
+			lrp.OnEmit(&exportableLogRecord{attributes: &emitted, droppedAttributes: 1})
+
+			got := next.got()
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.want, *got[0].Attributes())
+			assert.Equal(t, 1+tt.wantDropped, got[0].DroppedAttributes())
+			assert.Equal(t, attrs, emitted, "the emitted record is not modified")
+		})
+	}
+}
+
+func TestAttributeFilterProcessorWithoutAttributes(t *testing.T) {
+	next := &recordingProcessor{}
+	lrp := NewAttributeFilterProcessor(next, WithAttributeKeyAllowlist([]string{"component"}))
+
+	rol := testLogRecord("no attributes")
+	lrp.OnEmit(rol)
+
+	got := next.got()
+	require.Len(t, got, 1)
+	assert.Same(t, rol, got[0])
+	assert.Zero(t, got[0].DroppedAttributes())
+}
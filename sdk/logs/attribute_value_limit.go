@@ -0,0 +1,118 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"unicode/utf8"
+)
+
+// AttributeValueLimitPolicy selects what a BatchLogRecordProcessor does with
+// the attribute values exceeding MaxAttributeValueBytes.
+type AttributeValueLimitPolicy int
+
+const (
+	// TruncateAttributeValue truncates the value to MaxAttributeValueBytes,
+	// ending it with TruncatedAttributeValueSuffix.
+	TruncateAttributeValue AttributeValueLimitPolicy = iota
+	// DropAttribute removes the attribute from the log, it is counted by its
+	// DroppedAttributes.
+	DropAttribute
+)
+
+// TruncatedAttributeValueSuffix ends the attribute values truncated by a
+// BatchLogRecordProcessor, see WithMaxSingleAttributeValueBytes.
+const TruncatedAttributeValueSuffix = "...[truncated]"
+
+// limitAttributeValues returns rol, or a copy of it if one of its attribute
+// values exceeds limit bytes, with these values truncated or their attributes
+// dropped according to policy.
+func limitAttributeValues(rol ReadableLogRecord, limit int, policy AttributeValueLimitPolicy) ReadableLogRecord {
+	attrs := rol.Attributes()
+	if attrs == nil {
+		return rol
+	}
+	var (
+		limited []attribute.KeyValue
+		dropped int
+	)
+	for i, kv := range *attrs {
+		v, exceeds := limitAttributeValue(kv.Value, limit)
+		if !exceeds {
+			if limited != nil {
+				limited = append(limited, kv)
+			}
+			continue
+		}
+		if limited == nil {
+			limited = append(make([]attribute.KeyValue, 0, len(*attrs)), (*attrs)[:i]...)
+		}
+		if policy == DropAttribute {
+			dropped++
+			continue
+		}
+		limited = append(limited, attribute.KeyValue{Key: kv.Key, Value: v})
+	}
+	if limited == nil {
+		return rol
+	}
+	return newFilteredLogRecord(rol, limited, dropped)
+}
+
+// limitAttributeValue returns v truncated to limit bytes and true if v is a
+// string longer than limit bytes, or a string slice holding one. The other
+// values are returned unchanged.
+func limitAttributeValue(v attribute.Value, limit int) (attribute.Value, bool) {
+	switch v.Type() {
+	case attribute.STRING:
+		s := v.AsString()
+		if len(s) <= limit {
+			return v, false
+		}
+		return attribute.StringValue(truncateString(s, limit)), true
+	case attribute.STRINGSLICE:
+		ss := v.AsStringSlice()
+		exceeds := false
+		for i, s := range ss {
+			if len(s) > limit {
+				ss[i] = truncateString(s, limit)
+				exceeds = true
+			}
+		}
+		if !exceeds {
+			return v, false
+		}
+		return attribute.StringSliceValue(ss), true
+	default:
+		return v, false
+	}
+}
+
+// truncateString returns s truncated to at most limit bytes, on a rune
+// boundary, and ending with TruncatedAttributeValueSuffix unless limit is
+// too small for it.
+func truncateString(s string, limit int) string {
+	suffix := TruncatedAttributeValueSuffix
+	if limit < len(suffix) {
+		suffix = ""
+	}
+	n := limit - len(suffix)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + suffix
+}
@@ -0,0 +1,103 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  string
+	}{
+		{name: "ascii", s: strings.Repeat("a", 32), limit: 20, want: "aaaaaa" + TruncatedAttributeValueSuffix},
+		{name: "rune boundary", s: strings.Repeat("é", 16), limit: 21, want: "ééé" + TruncatedAttributeValueSuffix},
+		{name: "limit below the suffix", s: strings.Repeat("a", 32), limit: 4, want: "aaaa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateString(tt.s, tt.limit)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), tt.limit)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+// limitedRecord emits a log with an oversized attribute value to a batch
+// processor configured with options and returns the exported log.
+func limitedRecord(t *testing.T, options ...BatchLogRecordProcessorOption) ReadableLogRecord {
+	t.Helper()
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp, options...)
+	attrs := []attribute.KeyValue{
+		attribute.String("blob", strings.Repeat("x", 1<<20)),
+		attribute.String("tenant", "acme"),
+		attribute.StringSlice("chunks", []string{"small", strings.Repeat("y", 1024)}),
+		attribute.Int("size", 1<<20),
+	}
+	lrp.OnEmit(&exportableLogRecord{attributes: &attrs, droppedAttributes: 1})
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	require.Len(t, exp.exported(), 1)
+	require.Len(t, exp.exported()[0], 1)
+	assert.Len(t, attrs[0].Value.AsString(), 1<<20, "the emitted log is not modified")
+	return exp.exported()[0][0]
+}
+
+func TestBatchLogRecordProcessorMaxSingleAttributeValueBytesTruncate(t *testing.T) {
+	got := limitedRecord(t, WithMaxSingleAttributeValueBytes(64))
+
+	require.NotNil(t, got.Attributes())
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("blob", strings.Repeat("x", 64-len(TruncatedAttributeValueSuffix))+TruncatedAttributeValueSuffix),
+		attribute.String("tenant", "acme"),
+		attribute.StringSlice("chunks", []string{"small", strings.Repeat("y", 64-len(TruncatedAttributeValueSuffix)) + TruncatedAttributeValueSuffix}),
+		attribute.Int("size", 1<<20),
+	}, *got.Attributes())
+	assert.Equal(t, 1, got.DroppedAttributes())
+}
+
+func TestBatchLogRecordProcessorMaxSingleAttributeValueBytesDrop(t *testing.T) {
+	got := limitedRecord(t, WithMaxSingleAttributeValueBytes(64), WithAttributeValueLimitPolicy(DropAttribute))
+
+	require.NotNil(t, got.Attributes())
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("tenant", "acme"),
+		attribute.Int("size", 1<<20),
+	}, *got.Attributes())
+	assert.Equal(t, 3, got.DroppedAttributes())
+}
+
+func TestBatchLogRecordProcessorMaxSingleAttributeValueBytesDisabled(t *testing.T) {
+	got := limitedRecord(t)
+	require.NotNil(t, got.Attributes())
+	assert.Len(t, (*got.Attributes())[0].Value.AsString(), 1<<20)
+
+	got = limitedRecord(t, WithMaxSingleAttributeValueBytes(2<<20))
+	assert.Len(t, (*got.Attributes())[0].Value.AsString(), 1<<20)
+	assert.Equal(t, 1, got.DroppedAttributes())
+}
@@ -18,8 +18,10 @@ package logs
 
 import (
 	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
 	"github.com/metoro-io/opentelemetry-logs-go/sdk/internal/env"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -61,13 +63,131 @@ type BatchLogRecordProcessorOptions struct {
 	// The default value of MaxExportBatchSize is 512.
 	MaxExportBatchSize int
 
-	// BlockOnQueueFull blocks onEnd() and onStart() method if the queue is full
+	// MaxExportBatchBytes is the maximum estimated encoded size in bytes of the
+	// logs exported in a single batch. A batch is exported as soon as either
+	// MaxExportBatchSize or MaxExportBatchBytes is reached. A log bigger than
+	// MaxExportBatchBytes is exported alone in its batch.
+	// The default value of MaxExportBatchBytes is 0, no byte limit.
+	MaxExportBatchBytes int
+
+	// MinBatchTimeout and MaxBatchTimeout bound the adaptive batch timeout.
+	// When MaxBatchTimeout is set, it replaces BatchTimeout: the maximum
+	// duration for constructing a batch is MaxBatchTimeout while no logs are
+	// pending and shrinks linearly to MinBatchTimeout as the pending logs get
+	// closer to MaxExportBatchSize.
+	// The default value of MaxBatchTimeout is 0, BatchTimeout is used.
+	MinBatchTimeout time.Duration
+	MaxBatchTimeout time.Duration
+
+	// BlockOnQueueFull blocks OnEmit method if the queue is full
 	// AND if BlockOnQueueFull is set to true.
 	// Blocking option should be used carefully as it can severely affect the performance of an
 	// application.
 	BlockOnQueueFull bool
+
+	// ErrorHandler receives the errors of the exports and of the shutdown of
+	// the exporter instead of the global error handler.
+	// The default value of ErrorHandler is nil, otel.Handle is used.
+	ErrorHandler func(error)
+
+	// MaxConcurrentExports is the maximum number of batches exported at the
+	// same time. With more than one, the batches are exported in parallel and
+	// may reach the exporter out of order, and their errors are reported to
+	// the error handler rather than returned by ForceFlush.
+	// The default value of MaxConcurrentExports is 1.
+	MaxConcurrentExports int
+
+	// MeterProvider receives the counts of the logs exported and dropped by
+	// the processor.
+	// The default value of MeterProvider is nil, no metrics are recorded
+	// unless the OTEL_GO_X_LOGS_SELF_METRICS environment variable is true, in
+	// which case the global meter provider is used.
+	MeterProvider metric.MeterProvider
+
+	// AttributeMetrics also measures the number of attributes of the emitted
+	// logs and the length of their attribute values, if the metrics are
+	// recorded.
+	// The default value of AttributeMetrics is false.
+	AttributeMetrics bool
+
+	// RecordValidation drops the emitted logs which do not conform to the
+	// OTLP data model rather than exporting them.
+	// The default value of RecordValidation is false.
+	RecordValidation bool
+
+	// Name tells the processor apart from the other ones of the application
+	// in its metrics and debug logs.
+	// The default value of Name is empty, the processor is anonymous.
+	Name string
+
+	// MaxAttributeValueBytes is the maximum size in bytes of a string
+	// attribute value, or of each string of a string slice, of the emitted
+	// logs. AttributeValueLimitPolicy selects what is done with the values
+	// exceeding it.
+	// The default value of MaxAttributeValueBytes is 0, no limit.
+	MaxAttributeValueBytes int
+
+	// AttributeValueLimitPolicy selects whether the attribute values
+	// exceeding MaxAttributeValueBytes are truncated or their attributes
+	// dropped.
+	// The default value of AttributeValueLimitPolicy is TruncateAttributeValue.
+	AttributeValueLimitPolicy AttributeValueLimitPolicy
+
+	// DroppedRecordsCallback is called with the number of logs dropped by the
+	// processor and the reason, DroppedReasonQueueFull,
+	// DroppedReasonExportFailed, DroppedReasonInvalid or
+	// DroppedReasonShutdown.
+	// The default value of DroppedRecordsCallback is nil.
+	DroppedRecordsCallback func(count int, reason string)
+
+	// QueueFullPolicy selects the logs dropped when the queue is full and
+	// BlockOnQueueFull is false.
+	// The default value of QueueFullPolicy is DropNewest.
+	QueueFullPolicy QueueFullPolicy
+
+	// DisableExportOnShutdown makes Shutdown discard the queued logs instead
+	// of exporting them.
+	// The default value of DisableExportOnShutdown is false.
+	DisableExportOnShutdown bool
+
+	// ObservedTimestampMonotonic clamps the observed timestamp of each
+	// emitted log to be no earlier than the one of the previous logs.
+	// The default value of ObservedTimestampMonotonic is false.
+	ObservedTimestampMonotonic bool
 }
 
+// QueueFullPolicy selects the logs a BatchLogRecordProcessor drops when its
+// queue is full.
+type QueueFullPolicy int
+
+const (
+	// DropNewest drops the log being emitted, keeping the queued ones.
+	DropNewest QueueFullPolicy = iota
+	// DropOldest evicts the oldest queued log to make room for the log being
+	// emitted, so that the most recent logs survive. The pending ForceFlush
+	// calls are kept queued, the log being emitted is dropped if the queue
+	// holds nothing else.
+	DropOldest
+)
+
+// Reasons the logs are dropped by a BatchLogRecordProcessor, passed to the
+// DroppedRecordsCallback.
+const (
+	// DroppedReasonQueueFull is the reason of a log dropped because the
+	// queue was full.
+	DroppedReasonQueueFull = "queue_full"
+	// DroppedReasonExportFailed is the reason of the logs dropped because
+	// their export failed.
+	DroppedReasonExportFailed = "export_failed"
+	// DroppedReasonInvalid is the reason of a log dropped because it does not
+	// conform to the OTLP data model, see WithRecordValidation.
+	DroppedReasonInvalid = "invalid"
+	// DroppedReasonShutdown is the reason of the logs dropped because the
+	// processor is shut down: the logs emitted after Shutdown and the ones
+	// discarded by it, see WithExportOnShutdown.
+	DroppedReasonShutdown = "shutdown"
+)
+
 // WithMaxQueueSize returns a BatchLogRecordProcessorOption that configures the
 // maximum queue size allowed for a BatchLogRecordProcessor.
 func WithMaxQueueSize(size int) BatchLogRecordProcessorOption {
@@ -84,6 +204,24 @@ func WithMaxExportBatchSize(size int) BatchLogRecordProcessorOption {
 	}
 }
 
+// WithMaxExportBatchBytes returns a BatchLogRecordProcessorOption that
+// configures the maximum estimated encoded size in bytes of a batch exported by
+// a BatchLogRecordProcessor.
+//
+// The size of logs is estimated before export and over-approximates their
+// OTLP encoding. The limit is not checked against the one of the exporter:
+// set it below the maximum request size of the exporter, e.g. the
+// WithGRPCMaxCallSendMsgSize option of otlplogsgrpc, and below the maximum
+// message size accepted by the collector, which is 4MiB by default for gRPC.
+// A log bigger than size is still exported alone in its batch, which the
+// exporter may reject. Use the otlplogs.WithMaxPayloadSize option of the
+// exporter to enforce an exact limit by splitting the requests.
+func WithMaxExportBatchBytes(size int) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.MaxExportBatchBytes = size
+	}
+}
+
 // WithBatchTimeout returns a BatchLogRecordProcessorOption that configures the
 // maximum delay allowed for a BatchLogRecordProcessor before it will export any
 // held log (whether the queue is full or not).
@@ -93,9 +231,33 @@ func WithBatchTimeout(delay time.Duration) BatchLogRecordProcessorOption {
 	}
 }
 
+// WithAdaptiveBatchTimeout returns a BatchLogRecordProcessorOption that
+// configures a BatchLogRecordProcessor to adapt its batch timeout to the load
+// instead of using a fixed one. The timeout is max while the processor is idle
+// and shrinks down to min as logs pile up, so that a burst of logs is exported
+// quickly while a trickle of logs is still batched. It takes precedence over
+// WithBatchTimeout. A max lower than min is raised to min.
+func WithAdaptiveBatchTimeout(min, max time.Duration) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		if min < 0 {
+			min = 0
+		}
+		if max < min {
+			max = min
+		}
+		o.MinBatchTimeout = min
+		o.MaxBatchTimeout = max
+	}
+}
+
 // WithExportTimeout returns a BatchLogRecordProcessorOption that configures the
 // amount of time a BatchLogRecordProcessor waits for an exporter to export before
 // abandoning the export.
+//
+// The timeout bounds the whole export of a batch, including any retries done
+// by the exporter, as the exporter is passed a context with this deadline. It
+// is distinct from a per-request timeout configured on the exporter itself.
+// Exporters must honor the context for the timeout to take effect.
 func WithExportTimeout(timeout time.Duration) BatchLogRecordProcessorOption {
 	return func(o *BatchLogRecordProcessorOptions) {
 		o.ExportTimeout = timeout
@@ -104,13 +266,180 @@ func WithExportTimeout(timeout time.Duration) BatchLogRecordProcessorOption {
 
 // WithBlocking returns a BatchLogRecordProcessorOption that configures a
 // BatchLogRecordProcessor to wait for enqueue operations to succeed instead of
-// dropping data when the queue is full.
+// dropping data when the queue is full: OnEmit blocks until space is available
+// in the queue or until the context the log was emitted in is done, the log
+// is then dropped.
+//
+// Blocking applies backpressure to the caller. If the exporter is fully
+// stalled the queue never drains and every call to Emit blocks until the
+// export timeout abandons the stalled export, or indefinitely if no export
+// timeout is set and the logs are emitted without a deadline.
 func WithBlocking() BatchLogRecordProcessorOption {
 	return func(o *BatchLogRecordProcessorOptions) {
 		o.BlockOnQueueFull = true
 	}
 }
 
+// WithQueueFullPolicy returns a BatchLogRecordProcessorOption that configures
+// which logs a BatchLogRecordProcessor drops when its queue is full: the log
+// being emitted with DropNewest, the default, or the oldest queued log with
+// DropOldest. It has no effect when the processor blocks on a full queue.
+func WithQueueFullPolicy(policy QueueFullPolicy) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.QueueFullPolicy = policy
+	}
+}
+
+// WithExportOnShutdown returns a BatchLogRecordProcessorOption that configures
+// whether a BatchLogRecordProcessor exports the logs it holds when it is shut
+// down (true, the default) or discards them (false). Skipping the final export
+// shortens the shutdown, e.g. for deployments restarting fast, at the cost of
+// losing the logs emitted since the last export. Exports already running are
+// still waited for.
+func WithExportOnShutdown(export bool) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.DisableExportOnShutdown = !export
+	}
+}
+
+// WithObservedTimestampMonotonic returns a BatchLogRecordProcessorOption that
+// configures a BatchLogRecordProcessor to clamp the observed timestamp of each
+// emitted log to the latest observed timestamp of the logs emitted before it,
+// if it is earlier, so that the observed timestamps never decrease even if the
+// wall clock steps backward, e.g. on an NTP adjustment. The logs emitted at
+// the same time by several goroutines may still be exported out of order. It is
+// disabled by default, the observed timestamps are then exported as recorded.
+func WithObservedTimestampMonotonic() BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.ObservedTimestampMonotonic = true
+	}
+}
+
+// WithErrorHandler returns a BatchLogRecordProcessorOption that configures a
+// BatchLogRecordProcessor to report the errors of its exporter to handler
+// instead of the global error handler. Use one per exporter to tell which
+// destination failed when several pipelines run in the same process.
+//
+// Errors returned by ForceFlush and Shutdown are still returned to the caller.
+func WithErrorHandler(handler func(error)) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.ErrorHandler = handler
+	}
+}
+
+// WithMaxConcurrentExports returns a BatchLogRecordProcessorOption that
+// configures a BatchLogRecordProcessor to run up to n exports in parallel, so
+// that a slow exporter does not hold back the following batches. The order of
+// the logs across batches is no longer guaranteed. The exporter must be safe
+// for concurrent use. An n lower than 1 is raised to 1.
+func WithMaxConcurrentExports(n int) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.MaxConcurrentExports = max(n, 1)
+	}
+}
+
+// WithMeterProvider returns a BatchLogRecordProcessorOption that configures a
+// BatchLogRecordProcessor to count the logs it exports and drops with the
+// otel.sdk.logs.processor.exported and otel.sdk.logs.processor.dropped
+// counters of a meter of mp. The dropped counter has a reason attribute,
+// queue_full, export_failed, invalid or shutdown. The duration of the exports,
+// in seconds, is recorded by the otel.sdk.logs.export.duration histogram with
+// an outcome attribute, success or failure.
+//
+// Without this option, setting the OTEL_GO_X_LOGS_SELF_METRICS environment
+// variable to true records these metrics with the global meter provider.
+func WithMeterProvider(mp metric.MeterProvider) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.MeterProvider = mp
+	}
+}
+
+// WithAttributeMetrics returns a BatchLogRecordProcessorOption that configures
+// a BatchLogRecordProcessor to also measure the attributes of the emitted logs,
+// to find out which services emit large attribute payloads: the number of
+// attributes of each log with the otel.sdk.logs.record.attributes histogram,
+// and the length of each string or slice attribute value with the
+// otel.sdk.logs.attribute.value.length histogram. The length of a string is
+// its number of bytes, the one of a string slice the sum of the lengths of its
+// strings and the one of the other slices their number of elements.
+//
+// It is ignored unless the metrics of the processor are recorded, see
+// WithMeterProvider. It is disabled by default as it costs a measurement per
+// attribute of every log.
+func WithAttributeMetrics() BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.AttributeMetrics = true
+	}
+}
+
+// WithRecordValidation returns a BatchLogRecordProcessorOption that configures
+// a BatchLogRecordProcessor to validate the emitted logs against the OTLP data
+// model, rather than exporting logs the collector would reject: their
+// timestamps must not be before the Unix epoch, their severity number must be
+// between UNSPECIFIED and FATAL4, their trace and span IDs must be valid if set,
+// a span ID requiring a trace ID, and their attribute keys must not be empty.
+//
+// An invalid log is dropped, its error reported to the error handler, and it is
+// counted with the reason invalid by the dropped counter and the
+// DroppedRecordsCallback.
+func WithRecordValidation() BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.RecordValidation = true
+	}
+}
+
+// WithProcessorName returns a BatchLogRecordProcessorOption that configures a
+// BatchLogRecordProcessor to be named name, e.g. after the tenant whose logs it
+// exports when an application runs several pipelines. The name is set as the
+// processor.name attribute of its metrics, see WithMeterProvider, and logged
+// with its debug messages.
+func WithProcessorName(name string) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.Name = name
+	}
+}
+
+// WithMaxSingleAttributeValueBytes returns a BatchLogRecordProcessorOption
+// that configures a BatchLogRecordProcessor to limit the size of the string
+// attribute values of the emitted logs, and of each string of their string
+// slice values, to limit bytes, e.g. to keep a serialized blob from inflating
+// the exports. The values exceeding it are truncated, on a rune boundary and
+// ending with TruncatedAttributeValueSuffix, unless another policy is set with
+// WithAttributeValueLimitPolicy. It is independent of the limits on the size
+// of the batches. A zero or negative limit, the default, does not limit the
+// values.
+func WithMaxSingleAttributeValueBytes(limit int) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.MaxAttributeValueBytes = limit
+	}
+}
+
+// WithAttributeValueLimitPolicy returns a BatchLogRecordProcessorOption that
+// configures what a BatchLogRecordProcessor does with the attribute values
+// exceeding the limit set with WithMaxSingleAttributeValueBytes: truncate them
+// with TruncateAttributeValue, the default, or drop their attributes with
+// DropAttribute.
+func WithAttributeValueLimitPolicy(policy AttributeValueLimitPolicy) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.AttributeValueLimitPolicy = policy
+	}
+}
+
+// WithDroppedRecordsCallback returns a BatchLogRecordProcessorOption that
+// configures a BatchLogRecordProcessor to call fn with the number of logs it
+// drops and the reason, DroppedReasonQueueFull, DroppedReasonExportFailed,
+// DroppedReasonInvalid or DroppedReasonShutdown, e.g. to alert on
+// backpressure. A log given up by a blocked Emit because its context is done
+// is dropped with DroppedReasonQueueFull.
+//
+// The callback is called synchronously by Emit and by the exports, it must
+// not block.
+func WithDroppedRecordsCallback(fn func(count int, reason string)) BatchLogRecordProcessorOption {
+	return func(o *BatchLogRecordProcessorOptions) {
+		o.DroppedRecordsCallback = fn
+	}
+}
+
 // batchLogRecordProcessor is a LogRecordProcessor that batches asynchronously-received
 // logs and sends them to a logs.Exporter when complete.
 type batchLogRecordProcessor struct {
@@ -120,13 +449,35 @@ type batchLogRecordProcessor struct {
 	queue   chan ReadableLogRecord
 	dropped uint32
 
-	batch      []ReadableLogRecord
+	// batch holds the log records to export in the order they were emitted.
+	batch []ReadableLogRecord
+	// batchBytes is the estimated size of batch, tracked when
+	// MaxExportBatchBytes is set.
+	batchBytes int
 	batchMutex sync.Mutex
 	timer      *time.Timer
-	stopWait   sync.WaitGroup
-	stopOnce   sync.Once
-	stopCh     chan struct{}
-	stopped    atomic.Bool
+	// timerStart and timerDeadline are when the timer was last reset and when
+	// it fires, tracked with the adaptive batch timeout.
+	timerStart    time.Time
+	timerDeadline time.Time
+	// now returns the current time, used by the adaptive batch timeout.
+	now      func() time.Time
+	stopWait sync.WaitGroup
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	stopped  atomic.Bool
+
+	// exportSlots limits the exports running in parallel, it is nil when
+	// batches are exported one at a time.
+	exportSlots chan struct{}
+	// waitingExports is held by the caller of waitExports acquiring all the
+	// export slots, so that concurrent callers do not each hold part of them.
+	waitingExports chan struct{}
+	// metrics is nil unless the metrics of the processor are enabled.
+	metrics *processorMetrics
+	// observedClamp keeps the observed timestamps monotonic, see
+	// WithObservedTimestampMonotonic.
+	observedClamp monotonicClamp
 }
 
 func (lrp *batchLogRecordProcessor) Shutdown(ctx context.Context) error {
@@ -139,7 +490,7 @@ func (lrp *batchLogRecordProcessor) Shutdown(ctx context.Context) error {
 			lrp.stopWait.Wait()
 			if lrp.e != nil {
 				if err := lrp.e.Shutdown(ctx); err != nil {
-					otel.Handle(err)
+					lrp.handleError(err)
 				}
 			}
 			close(wait)
@@ -155,6 +506,8 @@ func (lrp *batchLogRecordProcessor) Shutdown(ctx context.Context) error {
 }
 
 var _ LogRecordProcessor = (*batchLogRecordProcessor)(nil)
+var _ Flusher = (*batchLogRecordProcessor)(nil)
+var _ BulkProcessor = (*batchLogRecordProcessor)(nil)
 
 // NewBatchLogRecordProcessor creates a new LogRecordProcessor that will send completed
 // log batches to the exporter with the supplied options.
@@ -186,16 +539,33 @@ func NewBatchLogRecordProcessor(exporter LogRecordExporter, options ...BatchLogR
 		e:      exporter,
 		o:      o,
 		batch:  make([]ReadableLogRecord, 0, o.MaxExportBatchSize),
-		timer:  time.NewTimer(o.BatchTimeout),
 		queue:  make(chan ReadableLogRecord, o.MaxQueueSize),
 		stopCh: make(chan struct{}),
+		now:    time.Now,
+	}
+	if o.MaxConcurrentExports > 1 {
+		blp.exportSlots = make(chan struct{}, o.MaxConcurrentExports)
+		blp.waitingExports = make(chan struct{}, 1)
+	}
+	mp := o.MeterProvider
+	if mp == nil && env.LogsSelfMetrics() {
+		mp = otel.GetMeterProvider()
 	}
+	blp.metrics = newProcessorMetrics(mp, o.AttributeMetrics, o.Name)
+	blp.timer = time.NewTimer(blp.batchTimeout(0))
+	blp.timerStart = blp.now()
+	blp.timerDeadline = blp.timerStart.Add(blp.batchTimeout(0))
 
 	blp.stopWait.Add(1)
 	go func() {
 		defer blp.stopWait.Done()
 		blp.processQueue()
-		blp.drainQueue()
+		if blp.o.DisableExportOnShutdown {
+			blp.discardQueue()
+		} else {
+			blp.drainQueue()
+		}
+		_ = blp.waitExports(context.Background())
 	}()
 
 	return blp
@@ -205,13 +575,48 @@ func (lrp *batchLogRecordProcessor) OnEmit(rol ReadableLogRecord) {
 
 	// Do not enqueue spans after Shutdown.
 	if lrp.stopped.Load() {
+		lrp.recordShutdownDropped(1)
 		return
 	}
 	// Do not enqueue logs if we are just going to drop them.
 	if lrp.e == nil {
 		return
 	}
+	lrp.process(rol)
+}
+
+// OnEmitBatch queues the records one by one as OnEmit would, only checking
+// once whether the processor is shut down.
+func (lrp *batchLogRecordProcessor) OnEmitBatch(records []ReadableLogRecord) {
+	if lrp.stopped.Load() {
+		lrp.recordShutdownDropped(len(records))
+		return
+	}
+	if lrp.e == nil {
+		return
+	}
+	for _, rol := range records {
+		lrp.process(rol)
+	}
+}
 
+// process validates, limits and queues rol.
+func (lrp *batchLogRecordProcessor) process(rol ReadableLogRecord) {
+	if lrp.o.RecordValidation {
+		if err := validateLogRecord(rol); err != nil {
+			lrp.handleError(err)
+			lrp.metrics.recordInvalid(context.Background())
+			lrp.reportDropped(1, DroppedReasonInvalid)
+			return
+		}
+	}
+	lrp.metrics.recordAttributes(context.Background(), rol)
+	if lrp.o.MaxAttributeValueBytes > 0 {
+		rol = limitAttributeValues(rol, lrp.o.MaxAttributeValueBytes, lrp.o.AttributeValueLimitPolicy)
+	}
+	if lrp.o.ObservedTimestampMonotonic {
+		rol = lrp.observedClamp.clamp(rol)
+	}
 	lrp.enqueue(rol)
 }
 
@@ -220,6 +625,16 @@ type forceFlushLogs struct {
 	flushed chan struct{}
 }
 
+// handleError reports err to the configured error handler, or to the global
+// one if none is.
+func (lrp *batchLogRecordProcessor) handleError(err error) {
+	if lrp.o.ErrorHandler != nil {
+		lrp.o.ErrorHandler(err)
+		return
+	}
+	otel.Handle(err)
+}
+
 // processQueue removes logs from the `queue` channel until processor
 // is shut down. It calls the exporter in batches of up to MaxExportBatchSize
 // waiting up to BatchTimeout to form a batch.
@@ -228,30 +643,35 @@ func (lrp *batchLogRecordProcessor) processQueue() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	export := func() {
+		if !lrp.timer.Stop() {
+			<-lrp.timer.C
+		}
+		if err := lrp.exportLogs(ctx, nil); err != nil {
+			lrp.handleError(err)
+		}
+	}
 	for {
 		select {
 		case <-lrp.stopCh:
 			return
 		case <-lrp.timer.C:
-			if err := lrp.exportLogs(ctx); err != nil {
-				otel.Handle(err)
+			if err := lrp.exportLogs(ctx, nil); err != nil {
+				lrp.handleError(err)
 			}
 		case sd := <-lrp.queue:
 			if ffs, ok := sd.(forceFlushLogs); ok {
 				close(ffs.flushed)
 				continue
 			}
-			lrp.batchMutex.Lock()
-			lrp.batch = append(lrp.batch, sd)
-			shouldExport := len(lrp.batch) >= lrp.o.MaxExportBatchSize
-			lrp.batchMutex.Unlock()
-			if shouldExport {
-				if !lrp.timer.Stop() {
-					<-lrp.timer.C
-				}
-				if err := lrp.exportLogs(ctx); err != nil {
-					otel.Handle(err)
-				}
+			size := lrp.logRecordSize(sd)
+			if lrp.batchOverflows(size) {
+				export()
+			}
+			if lrp.appendToBatch(sd, size) {
+				export()
+			} else if lrp.o.MaxBatchTimeout > 0 {
+				lrp.shortenTimer()
 			}
 		}
 	}
@@ -262,39 +682,147 @@ func (lrp *batchLogRecordProcessor) processQueue() {
 func (lrp *batchLogRecordProcessor) drainQueue() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	export := func() {
+		if err := lrp.exportLogs(ctx, nil); err != nil {
+			lrp.handleError(err)
+		}
+	}
 	for {
 		select {
 		case sd := <-lrp.queue:
 			if sd == nil {
-				if err := lrp.exportLogs(ctx); err != nil {
-					otel.Handle(err)
+				if err := lrp.exportLogs(ctx, nil); err != nil {
+					lrp.handleError(err)
 				}
 				return
 			}
+			if ffs, ok := sd.(forceFlushLogs); ok {
+				close(ffs.flushed)
+				continue
+			}
 
-			lrp.batchMutex.Lock()
-			lrp.batch = append(lrp.batch, sd)
-			shouldExport := len(lrp.batch) == lrp.o.MaxExportBatchSize
-			lrp.batchMutex.Unlock()
+			size := lrp.logRecordSize(sd)
+			if lrp.batchOverflows(size) {
+				export()
+			}
+			if lrp.appendToBatch(sd, size) {
+				export()
+			}
+		default:
+			close(lrp.queue)
+		}
+	}
+}
 
-			if shouldExport {
-				if err := lrp.exportLogs(ctx); err != nil {
-					otel.Handle(err)
+// discardQueue releases the queued logs and the batch without exporting them,
+// the counterpart of drainQueue when the export on shutdown is disabled.
+func (lrp *batchLogRecordProcessor) discardQueue() {
+	discarded := 0
+	for {
+		select {
+		case sd := <-lrp.queue:
+			if sd == nil {
+				lrp.batchMutex.Lock()
+				discarded += len(lrp.batch)
+				releaseLogRecords(lrp.batch)
+				lrp.batch = lrp.batch[:0]
+				lrp.batchBytes = 0
+				lrp.batchMutex.Unlock()
+				if discarded > 0 {
+					lrp.recordShutdownDropped(discarded)
 				}
+				return
+			}
+			if ffs, ok := sd.(forceFlushLogs); ok {
+				close(ffs.flushed)
+				continue
 			}
+			discarded++
+			releaseLogRecord(sd)
 		default:
 			close(lrp.queue)
 		}
 	}
 }
 
-// exportLogs is a subroutine of processing and draining the queue.
-func (lrp *batchLogRecordProcessor) exportLogs(ctx context.Context) error {
-	lrp.timer.Reset(lrp.o.BatchTimeout)
+// logRecordSize returns the estimated size of sd when MaxExportBatchBytes is
+// set, 0 otherwise.
+func (lrp *batchLogRecordProcessor) logRecordSize(sd ReadableLogRecord) int {
+	if lrp.o.MaxExportBatchBytes <= 0 {
+		return 0
+	}
+	return estimateLogRecordSize(sd)
+}
+
+// batchOverflows returns true if adding a log of the size to the batch would
+// exceed MaxExportBatchBytes, in which case the batch must be exported first.
+func (lrp *batchLogRecordProcessor) batchOverflows(size int) bool {
+	if lrp.o.MaxExportBatchBytes <= 0 {
+		return false
+	}
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+	return len(lrp.batch) > 0 && lrp.batchBytes+size > lrp.o.MaxExportBatchBytes
+}
+
+// appendToBatch adds sd of the size to the batch and returns true if the batch
+// reached MaxExportBatchSize or MaxExportBatchBytes and must be exported.
+func (lrp *batchLogRecordProcessor) appendToBatch(sd ReadableLogRecord, size int) bool {
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+	lrp.batch = append(lrp.batch, sd)
+	lrp.batchBytes += size
+	return len(lrp.batch) >= lrp.o.MaxExportBatchSize ||
+		(lrp.o.MaxExportBatchBytes > 0 && lrp.batchBytes >= lrp.o.MaxExportBatchBytes)
+}
+
+// batchTimeout returns the maximum duration for constructing a batch while
+// pending logs are waiting to be exported.
+func (lrp *batchLogRecordProcessor) batchTimeout(pending int) time.Duration {
+	if lrp.o.MaxBatchTimeout <= 0 {
+		return lrp.o.BatchTimeout
+	}
+	if pending >= lrp.o.MaxExportBatchSize {
+		return lrp.o.MinBatchTimeout
+	}
+	span := lrp.o.MaxBatchTimeout - lrp.o.MinBatchTimeout
+	return lrp.o.MaxBatchTimeout - time.Duration(float64(span)*float64(pending)/float64(lrp.o.MaxExportBatchSize))
+}
+
+// shortenTimer moves the timer earlier if the adaptive batch timeout for the
+// logs now pending is reached before it. It must be called from processQueue,
+// which owns the timer channel.
+func (lrp *batchLogRecordProcessor) shortenTimer() {
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+
+	deadline := lrp.timerStart.Add(lrp.batchTimeout(len(lrp.batch) + len(lrp.queue)))
+	if !deadline.Before(lrp.timerDeadline) {
+		return
+	}
+	if !lrp.timer.Stop() {
+		<-lrp.timer.C
+	}
+	lrp.timer.Reset(max(deadline.Sub(lrp.now()), 0))
+	lrp.timerDeadline = deadline
+}
 
+// exportLogs is a subroutine of processing and draining the queue. The
+// records of the batch successfully exported are added to exported, if not
+// nil.
+func (lrp *batchLogRecordProcessor) exportLogs(ctx context.Context, exported *atomic.Int64) error {
 	lrp.batchMutex.Lock()
 	defer lrp.batchMutex.Unlock()
 
+	timeout := lrp.batchTimeout(len(lrp.queue))
+	lrp.timer.Reset(timeout)
+	lrp.timerStart = lrp.now()
+	lrp.timerDeadline = lrp.timerStart.Add(timeout)
+
+	if lrp.exportSlots != nil {
+		return lrp.exportLogsAsync(ctx, exported)
+	}
+
 	if lrp.o.ExportTimeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, lrp.o.ExportTimeout)
@@ -302,14 +830,23 @@ func (lrp *batchLogRecordProcessor) exportLogs(ctx context.Context) error {
 	}
 
 	if l := len(lrp.batch); l > 0 {
-		//global.Debug("exporting logs", "count", len(lrp.batch), "total_dropped", atomic.LoadUint32(&lrp.dropped))
+		global.Debug("exporting logs", "processor", lrp.o.Name, "count", l)
+		start := time.Now()
 		err := lrp.e.Export(ctx, lrp.batch)
+		lrp.metrics.recordExport(ctx, l, time.Since(start), err)
+		if err != nil {
+			lrp.reportDropped(l, DroppedReasonExportFailed)
+		} else if exported != nil {
+			exported.Add(int64(l))
+		}
+		releaseLogRecords(lrp.batch)
 
 		// A new batch is always created after exporting, even if the batch failed to be exported.
 		//
 		// It is up to the exporter to implement any type of retry logic if a batch is failing
 		// to be exported, since it is specific to the protocol and backend being sent to.
 		lrp.batch = lrp.batch[:0]
+		lrp.batchBytes = 0
 
 		if err != nil {
 			return err
@@ -318,18 +855,113 @@ func (lrp *batchLogRecordProcessor) exportLogs(ctx context.Context) error {
 	return nil
 }
 
+// exportLogsAsync exports the batch in a new goroutine once one of the export
+// slots is free, see exportLogs. It must be called with batchMutex held.
+func (lrp *batchLogRecordProcessor) exportLogsAsync(ctx context.Context, exported *atomic.Int64) error {
+	if len(lrp.batch) == 0 {
+		return nil
+	}
+	select {
+	case lrp.exportSlots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	batch := lrp.batch
+	lrp.batch = make([]ReadableLogRecord, 0, lrp.o.MaxExportBatchSize)
+	lrp.batchBytes = 0
+
+	// The export outlives the goroutine processing the queue, which cancels
+	// its context on shutdown, and is waited for instead.
+	ctx = context.WithoutCancel(ctx)
+	global.Debug("exporting logs", "processor", lrp.o.Name, "count", len(batch))
+	go func() {
+		defer func() { <-lrp.exportSlots }()
+		if lrp.o.ExportTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, lrp.o.ExportTimeout)
+			defer cancel()
+		}
+		start := time.Now()
+		err := lrp.e.Export(ctx, batch)
+		lrp.metrics.recordExport(ctx, len(batch), time.Since(start), err)
+		releaseLogRecords(batch)
+		if err != nil {
+			lrp.reportDropped(len(batch), DroppedReasonExportFailed)
+			lrp.handleError(err)
+		} else if exported != nil {
+			exported.Add(int64(len(batch)))
+		}
+	}()
+	return nil
+}
+
+// waitExports waits for the exports running in parallel to finish.
+func (lrp *batchLogRecordProcessor) waitExports(ctx context.Context) error {
+	if lrp.exportSlots == nil {
+		return nil
+	}
+	select {
+	case lrp.waitingExports <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-lrp.waitingExports }()
+
+	var acquired int
+	defer func() {
+		for ; acquired > 0; acquired-- {
+			<-lrp.exportSlots
+		}
+	}()
+	for acquired < cap(lrp.exportSlots) {
+		select {
+		case lrp.exportSlots <- struct{}{}:
+			acquired++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
 func (lrp *batchLogRecordProcessor) enqueue(sd ReadableLogRecord) {
-	ctx := context.TODO()
+	ctx := recordContext(sd)
+	// The record is kept until its export, released if it is not queued.
+	retainLogRecord(sd)
+	var queued bool
 	if lrp.o.BlockOnQueueFull {
-		lrp.enqueueBlockOnQueueFull(ctx, sd)
+		queued = lrp.enqueueBlockOnQueueFull(ctx, sd)
+		if !queued && ctx.Err() != nil {
+			lrp.recordDropped(ctx)
+		} else if !queued {
+			lrp.recordShutdownDropped(1)
+		}
 	} else {
-		lrp.enqueueDrop(ctx, sd)
+		queued = lrp.enqueueDrop(ctx, sd)
+	}
+	if !queued {
+		releaseLogRecord(sd)
 	}
 }
 
 // ForceFlush exports all ended logs that have not yet been exported.
 func (lrp *batchLogRecordProcessor) ForceFlush(ctx context.Context) error {
+	return lrp.flush(ctx, nil)
+}
 
+// Flush exports all ended logs that have not yet been exported, like
+// ForceFlush, and returns the number of records of the batch it exported. The
+// full batches exported while the queue is processed up to the flush, and the
+// ones exported by the timer or by concurrent flushes, are not counted.
+func (lrp *batchLogRecordProcessor) Flush(ctx context.Context) (int, error) {
+	var exported atomic.Int64
+	err := lrp.flush(ctx, &exported)
+	return int(exported.Load()), err
+}
+
+// flush is ForceFlush adding the records of the batch it exports to exported.
+func (lrp *batchLogRecordProcessor) flush(ctx context.Context, exported *atomic.Int64) error {
 	// Interrupt if context is already canceled.
 	if err := ctx.Err(); err != nil {
 		return err
@@ -354,7 +986,11 @@ func (lrp *batchLogRecordProcessor) ForceFlush(ctx context.Context) error {
 
 		wait := make(chan error)
 		go func() {
-			wait <- lrp.exportLogs(ctx)
+			err := lrp.exportLogs(ctx, exported)
+			if err == nil {
+				err = lrp.waitExports(ctx)
+			}
+			wait <- err
 			close(wait)
 		}()
 		// Wait until the export is finished or the context is cancelled/timed out
@@ -392,6 +1028,14 @@ func (lrp *batchLogRecordProcessor) enqueueBlockOnQueueFull(ctx context.Context,
 	default:
 	}
 
+	// Queue the log if there is room before waiting, a log emitted in a
+	// context already done is only dropped if the queue is full.
+	select {
+	case lrp.queue <- sd:
+		return true
+	default:
+	}
+
 	select {
 	case lrp.queue <- sd:
 		return true
@@ -400,7 +1044,15 @@ func (lrp *batchLogRecordProcessor) enqueueBlockOnQueueFull(ctx context.Context,
 	}
 }
 
-func (lrp *batchLogRecordProcessor) enqueueDrop(ctx context.Context, ld ReadableLogRecord) bool {
+func (lrp *batchLogRecordProcessor) enqueueDrop(ctx context.Context, ld ReadableLogRecord) (queued bool) {
+	// The log is dropped because the processor is shut down, unless it is
+	// dropped because the queue is full.
+	full := false
+	defer func() {
+		if !queued && !full {
+			lrp.recordShutdownDropped(1)
+		}
+	}()
 
 	// This ensures the bsp.queue<- below does not panic as the
 	// processor shuts down.
@@ -412,13 +1064,93 @@ func (lrp *batchLogRecordProcessor) enqueueDrop(ctx context.Context, ld Readable
 	default:
 	}
 
+	for {
+		select {
+		case lrp.queue <- ld:
+			return true
+		default:
+		}
+		if lrp.o.QueueFullPolicy != DropOldest || !lrp.dropOldest(ctx) {
+			full = true
+			lrp.recordDropped(ctx)
+			return false
+		}
+	}
+}
+
+// dropOldest evicts the oldest log of the queue to make room for another one.
+// The flush markers are never evicted, as they must be completed by
+// processQueue once done with the logs dequeued before them: the ones taken
+// off the queue are queued again behind the evicted log. It returns false if
+// the queue only holds flush markers, the log being emitted is then dropped.
+func (lrp *batchLogRecordProcessor) dropOldest(ctx context.Context) bool {
+	var flushes []forceFlushLogs
+	defer func() {
+		for _, ffs := range flushes {
+			lrp.requeueFlush(ffs)
+		}
+	}()
+	for {
+		select {
+		case sd, ok := <-lrp.queue:
+			if !ok {
+				return false
+			}
+			if ffs, ok := sd.(forceFlushLogs); ok {
+				flushes = append(flushes, ffs)
+				continue
+			}
+			lrp.recordDropped(ctx)
+			releaseLogRecord(sd)
+			return true
+		default:
+			// The queue was drained concurrently, unless it only held
+			// flush markers.
+			return len(flushes) == 0
+		}
+	}
+}
+
+// requeueFlush queues again a flush marker taken off the queue by dropOldest.
+// It waits for room in the queue, which the concurrent emits may have refilled,
+// or completes the flush if the processor is shut down, the queue then being
+// drained.
+func (lrp *batchLogRecordProcessor) requeueFlush(ffs forceFlushLogs) {
+	queued := false
+	defer func() {
+		if !queued {
+			close(ffs.flushed)
+		}
+	}()
+	defer recoverSendOnClosedChan()
+
 	select {
-	case lrp.queue <- ld:
-		return true
-	default:
-		atomic.AddUint32(&lrp.dropped, 1)
+	case lrp.queue <- ffs:
+		queued = true
+	case <-lrp.stopCh:
+	}
+}
+
+// recordDropped counts a log dropped because the queue is full.
+func (lrp *batchLogRecordProcessor) recordDropped(ctx context.Context) {
+	atomic.AddUint32(&lrp.dropped, 1)
+	lrp.metrics.recordQueueFull(ctx)
+	lrp.reportDropped(1, DroppedReasonQueueFull)
+}
+
+// recordShutdownDropped counts count logs dropped because the processor is
+// shut down.
+func (lrp *batchLogRecordProcessor) recordShutdownDropped(count int) {
+	lrp.metrics.recordShutdown(context.Background(), count)
+	lrp.reportDropped(count, DroppedReasonShutdown)
+}
+
+// reportDropped passes the count of dropped logs to the
+// DroppedRecordsCallback, if any.
+func (lrp *batchLogRecordProcessor) reportDropped(count int, reason string) {
+	if lrp.o.DroppedRecordsCallback != nil {
+		lrp.o.DroppedRecordsCallback(count, reason)
 	}
-	return false
 }
 
 // MarshalLog is the marshaling function used by the logging system to represent this exporter.
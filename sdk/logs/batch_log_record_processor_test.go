@@ -0,0 +1,941 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testLogRecord(body string) ReadableLogRecord {
+	return &exportableLogRecord{body: &body, observedTimestamp: time.Now()}
+}
+
+// slowExporter blocks every export until its context is done.
+type slowExporter struct {
+	mu       sync.Mutex
+	errs     []error
+	elapsed  []time.Duration
+	exported chan struct{}
+}
+
+func newSlowExporter() *slowExporter {
+	return &slowExporter{exported: make(chan struct{}, 10)}
+}
+
+func (e *slowExporter) Export(ctx context.Context, _ []ReadableLogRecord) error {
+	start := time.Now()
+	<-ctx.Done()
+	e.mu.Lock()
+	e.errs = append(e.errs, ctx.Err())
+	e.elapsed = append(e.elapsed, time.Since(start))
+	e.mu.Unlock()
+	e.exported <- struct{}{}
+	return ctx.Err()
+}
+
+func (e *slowExporter) Shutdown(context.Context) error { return nil }
+
+func TestBatchLogRecordProcessorExportTimeout(t *testing.T) {
+	exp := newSlowExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(1),
+		WithExportTimeout(20*time.Millisecond),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	lrp.OnEmit(testLogRecord("first"))
+	lrp.OnEmit(testLogRecord("second"))
+
+	// Both batches are exported: the first one is abandoned at the timeout and
+	// does not block the next one.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-exp.exported:
+		case <-time.After(5 * time.Second):
+			t.Fatal("export was not abandoned at the export timeout")
+		}
+	}
+
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	for i, err := range exp.errs {
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, exp.elapsed[i], time.Second)
+	}
+}
+
+// gatedExporter records exported log bodies and blocks each export until
+// released.
+type gatedExporter struct {
+	mu      sync.Mutex
+	bodies  []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGatedExporter() *gatedExporter {
+	return &gatedExporter{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (e *gatedExporter) Export(ctx context.Context, records []ReadableLogRecord) error {
+	select {
+	case e.started <- struct{}{}:
+	default:
+	}
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.bodies = append(e.bodies, *r.Body().(*string))
+	}
+	return nil
+}
+
+func (e *gatedExporter) Shutdown(context.Context) error { return nil }
+
+func (e *gatedExporter) exported() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.bodies...)
+}
+
+func TestBatchLogRecordProcessorDropOnQueueFull(t *testing.T) {
+	exp := newGatedExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(1),
+		WithMaxExportBatchSize(1),
+	).(*batchLogRecordProcessor)
+
+	// The first record is taken off the queue and its export stalls, the
+	// second one fills the queue and the third one is dropped.
+	lrp.OnEmit(testLogRecord("first"))
+	<-exp.started
+	lrp.OnEmit(testLogRecord("second"))
+	lrp.OnEmit(testLogRecord("third"))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&lrp.dropped))
+
+	close(exp.release)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, exp.exported())
+}
+
+func TestBatchLogRecordProcessorBlockOnQueueFull(t *testing.T) {
+	exp := newGatedExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(1),
+		WithMaxExportBatchSize(1),
+		WithBlocking(),
+	).(*batchLogRecordProcessor)
+
+	lrp.OnEmit(testLogRecord("first"))
+	<-exp.started
+	lrp.OnEmit(testLogRecord("second"))
+
+	emitted := make(chan struct{})
+	go func() {
+		lrp.OnEmit(testLogRecord("third"))
+		close(emitted)
+	}()
+
+	select {
+	case <-emitted:
+		t.Fatal("OnEmit did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Draining the queue releases the blocked caller.
+	close(exp.release)
+	select {
+	case <-emitted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnEmit was not released once the queue drained")
+	}
+
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, uint32(0), atomic.LoadUint32(&lrp.dropped))
+	assert.Equal(t, []string{"first", "second", "third"}, exp.exported())
+}
+
+func TestBatchLogRecordProcessorBlockOnQueueFullContextDone(t *testing.T) {
+	exp := newGatedExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(1),
+		WithMaxExportBatchSize(1),
+		WithBlocking(),
+	).(*batchLogRecordProcessor)
+
+	lrp.OnEmit(testLogRecord("first"))
+	<-exp.started
+	lrp.OnEmit(testLogRecord("second"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	body := "third"
+	emitted := make(chan struct{})
+	go func() {
+		lrp.OnEmit(&exportableLogRecord{body: &body, observedTimestamp: time.Now(), ctx: ctx})
+		close(emitted)
+	}()
+
+	select {
+	case <-emitted:
+		t.Fatal("OnEmit did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The blocked caller gives up once the context of the log is done.
+	cancel()
+	select {
+	case <-emitted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnEmit was not released once the context of the log was done")
+	}
+
+	close(exp.release)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, exp.exported())
+}
+
+func TestBatchLogRecordProcessorBlockingContextDoneQueued(t *testing.T) {
+	const n = 1000
+	exp := newGatedExporter()
+	close(exp.release)
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(n),
+		WithBatchTimeout(time.Hour),
+		WithBlocking(),
+	)
+
+	// The logs emitted in a context already done are queued while there is
+	// room in the queue.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < n; i++ {
+		body := fmt.Sprint(i)
+		lrp.OnEmit(&exportableLogRecord{body: &body, observedTimestamp: time.Now(), ctx: ctx})
+	}
+
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Len(t, exp.exported(), n)
+}
+
+func TestBatchLogRecordProcessorPreservesEmitOrder(t *testing.T) {
+	const n = 100
+	exp := newGatedExporter()
+	close(exp.release)
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(n/4),
+		WithBlocking(),
+	)
+
+	ts := time.Now()
+	want := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		body := fmt.Sprintf("record %d", i)
+		want = append(want, body)
+		lrp.OnEmit(&exportableLogRecord{body: &body, timestamp: &ts, observedTimestamp: ts})
+	}
+
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, want, exp.exported())
+}
+
+// batchRecordingExporter records the exported batches.
+type batchRecordingExporter struct {
+	mu      sync.Mutex
+	batches [][]ReadableLogRecord
+}
+
+func (e *batchRecordingExporter) Export(_ context.Context, records []ReadableLogRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, append([]ReadableLogRecord(nil), records...))
+	return nil
+}
+
+func (e *batchRecordingExporter) Shutdown(context.Context) error { return nil }
+
+func (e *batchRecordingExporter) exported() [][]ReadableLogRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.batches
+}
+
+func TestBatchLogRecordProcessorMaxExportBatchBytes(t *testing.T) {
+	const maxBytes = 10 * 1024
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchBytes(maxBytes),
+		WithBlocking(),
+		WithBatchTimeout(time.Hour),
+	)
+
+	body := strings.Repeat("x", 3000)
+	const n = 20
+	for i := 0; i < n; i++ {
+		lrp.OnEmit(testLogRecord(body))
+	}
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	var total int
+	batches := exp.exported()
+	assert.Greater(t, len(batches), 1)
+	for _, batch := range batches {
+		var size int
+		for _, r := range batch {
+			size += estimateLogRecordSize(r)
+		}
+		assert.LessOrEqual(t, size, maxBytes)
+		total += len(batch)
+	}
+	assert.Equal(t, n, total)
+}
+
+func TestBatchLogRecordProcessorMaxExportBatchBytesOversizedRecord(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchBytes(1024),
+		WithBlocking(),
+		WithBatchTimeout(time.Hour),
+	)
+
+	lrp.OnEmit(testLogRecord("small"))
+	lrp.OnEmit(testLogRecord(strings.Repeat("x", 4096)))
+	lrp.OnEmit(testLogRecord("small"))
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	batches := exp.exported()
+	require.Len(t, batches, 3, "the oversized record is exported alone")
+	for _, batch := range batches {
+		assert.Len(t, batch, 1)
+	}
+}
+
+func TestBatchLogRecordProcessorMaxExportBatchSizeWithBytes(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(2),
+		WithMaxExportBatchBytes(1024*1024),
+		WithBlocking(),
+		WithBatchTimeout(time.Hour),
+	)
+	for i := 0; i < 4; i++ {
+		lrp.OnEmit(testLogRecord("small"))
+	}
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	batches := exp.exported()
+	require.Len(t, batches, 2, "the count limit is hit first")
+	for _, batch := range batches {
+		assert.Len(t, batch, 2)
+	}
+}
+
+func TestEstimateLogRecordSize(t *testing.T) {
+	small := estimateLogRecordSize(testLogRecord("small"))
+	large := estimateLogRecordSize(testLogRecord(strings.Repeat("x", 1000)))
+	assert.GreaterOrEqual(t, large-small, 1000-len("small"))
+
+	attrs := []attribute.KeyValue{attribute.String("key", strings.Repeat("v", 500))}
+	withAttrs := estimateLogRecordSize(&exportableLogRecord{attributes: &attrs})
+	assert.Greater(t, withAttrs, 500)
+}
+
+func TestBatchLogRecordProcessorBatchTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       BatchLogRecordProcessorOptions
+		pending int
+		want    time.Duration
+	}{
+		{"fixed", BatchLogRecordProcessorOptions{BatchTimeout: time.Second, MaxExportBatchSize: 10}, 5, time.Second},
+		{"idle", BatchLogRecordProcessorOptions{MinBatchTimeout: time.Second, MaxBatchTimeout: 11 * time.Second, MaxExportBatchSize: 10}, 0, 11 * time.Second},
+		{"half full", BatchLogRecordProcessorOptions{MinBatchTimeout: time.Second, MaxBatchTimeout: 11 * time.Second, MaxExportBatchSize: 10}, 5, 6 * time.Second},
+		{"full", BatchLogRecordProcessorOptions{MinBatchTimeout: time.Second, MaxBatchTimeout: 11 * time.Second, MaxExportBatchSize: 10}, 10, time.Second},
+		{"overfull", BatchLogRecordProcessorOptions{MinBatchTimeout: time.Second, MaxBatchTimeout: 11 * time.Second, MaxExportBatchSize: 10}, 50, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lrp := &batchLogRecordProcessor{o: tt.o}
+			assert.Equal(t, tt.want, lrp.batchTimeout(tt.pending))
+		})
+	}
+}
+
+func TestWithAdaptiveBatchTimeout(t *testing.T) {
+	var o BatchLogRecordProcessorOptions
+	WithAdaptiveBatchTimeout(time.Second, time.Millisecond)(&o)
+	assert.Equal(t, time.Second, o.MinBatchTimeout)
+	assert.Equal(t, time.Second, o.MaxBatchTimeout, "max is raised to min")
+}
+
+// newAdaptiveBatchLogRecordProcessor returns a batchLogRecordProcessor with an
+// adaptive batch timeout whose timer deadlines are computed with clock. The
+// clock must only be advanced with advanceBatchClock.
+func newAdaptiveBatchLogRecordProcessor(exp LogRecordExporter, clock *fakeClock, min, max time.Duration) *batchLogRecordProcessor {
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(10),
+		WithAdaptiveBatchTimeout(min, max),
+	).(*batchLogRecordProcessor)
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+	lrp.now = clock.Now
+	lrp.timerStart = clock.Now()
+	lrp.timerDeadline = lrp.timerStart.Add(max)
+	return lrp
+}
+
+func advanceBatchClock(lrp *batchLogRecordProcessor, clock *fakeClock, d time.Duration) {
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+	clock.Advance(d)
+}
+
+func (lrp *batchLogRecordProcessor) pendingDeadline() (time.Time, int) {
+	lrp.batchMutex.Lock()
+	defer lrp.batchMutex.Unlock()
+	return lrp.timerDeadline, len(lrp.batch)
+}
+
+func TestBatchLogRecordProcessorAdaptiveBatchTimeoutTrickle(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	lrp := newAdaptiveBatchLogRecordProcessor(exp, clock, 0, time.Hour)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	// A single log barely shortens the timeout: it waits for more logs.
+	lrp.OnEmit(testLogRecord("rare"))
+	require.Eventually(t, func() bool {
+		_, n := lrp.pendingDeadline()
+		return n == 1
+	}, 5*time.Second, time.Millisecond)
+	deadline, _ := lrp.pendingDeadline()
+	assert.Equal(t, time.Unix(0, 0).Add(54*time.Minute), deadline)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, exp.exported())
+}
+
+func TestBatchLogRecordProcessorAdaptiveBatchTimeoutBurst(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	lrp := newAdaptiveBatchLogRecordProcessor(exp, clock, 0, time.Hour)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	// A burst of logs shortens the timeout of the batch to six minutes
+	// instead of the hour of an idle processor.
+	for i := 0; i < 9; i++ {
+		lrp.OnEmit(testLogRecord(fmt.Sprint(i)))
+	}
+	require.Eventually(t, func() bool {
+		_, n := lrp.pendingDeadline()
+		return n == 9
+	}, 5*time.Second, time.Millisecond)
+	deadline, _ := lrp.pendingDeadline()
+	assert.Equal(t, time.Unix(0, 0).Add(6*time.Minute), deadline)
+}
+
+func TestBatchLogRecordProcessorAdaptiveBatchTimeoutFlushLatency(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(10),
+		WithAdaptiveBatchTimeout(0, 2*time.Second),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	// The burst is exported after at most 200ms, well before the maximum
+	// batch timeout.
+	start := time.Now()
+	for i := 0; i < 9; i++ {
+		lrp.OnEmit(testLogRecord(fmt.Sprint(i)))
+	}
+	require.Eventually(t, func() bool { return len(exp.exported()) > 0 }, 5*time.Second, time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// failingExporter fails every export with err.
+type failingExporter struct{ err error }
+
+func (e failingExporter) Export(context.Context, []ReadableLogRecord) error { return e.err }
+func (e failingExporter) Shutdown(context.Context) error                    { return nil }
+
+func TestBatchLogRecordProcessorWithErrorHandler(t *testing.T) {
+	var global atomic.Int32
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) { global.Add(1) }))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	exportErr := errors.New("collector unavailable")
+	errs := make(chan error, 10)
+	lrp := NewBatchLogRecordProcessor(failingExporter{err: exportErr},
+		WithMaxExportBatchSize(1),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	lrp.OnEmit(testLogRecord("first"))
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, exportErr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("export error not reported to the error handler")
+	}
+	assert.Zero(t, global.Load(), "the global error handler is not called")
+}
+
+// concurrentExporter blocks every export until released and tracks the
+// maximum number of exports running at the same time.
+type concurrentExporter struct {
+	*gatedExporter
+	active    atomic.Int32
+	maxActive atomic.Int32
+	shutdown  atomic.Bool
+}
+
+func newConcurrentExporter() *concurrentExporter {
+	return &concurrentExporter{gatedExporter: newGatedExporter()}
+}
+
+func (e *concurrentExporter) Export(ctx context.Context, records []ReadableLogRecord) error {
+	n := e.active.Add(1)
+	defer e.active.Add(-1)
+	for m := e.maxActive.Load(); n > m && !e.maxActive.CompareAndSwap(m, n); m = e.maxActive.Load() {
+	}
+	return e.gatedExporter.Export(ctx, records)
+}
+
+func (e *concurrentExporter) Shutdown(context.Context) error {
+	e.shutdown.Store(true)
+	return nil
+}
+
+func TestBatchLogRecordProcessorMaxConcurrentExports(t *testing.T) {
+	exp := newConcurrentExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(1),
+		WithMaxConcurrentExports(3),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	for i := 0; i < 5; i++ {
+		lrp.OnEmit(testLogRecord(fmt.Sprint(i)))
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-exp.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("exports did not run in parallel")
+		}
+	}
+	select {
+	case <-exp.started:
+		t.Fatal("more exports than allowed run in parallel")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(exp.release)
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4"}, exp.exported())
+	assert.Equal(t, int32(3), exp.maxActive.Load())
+}
+
+func TestBatchLogRecordProcessorMaxConcurrentExportsForceFlush(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(1),
+		WithMaxConcurrentExports(4),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	// Concurrent flushes waiting for the running exports do not deadlock
+	// each holding part of the export slots.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				lrp.OnEmit(testLogRecord(fmt.Sprint(j)))
+				assert.NoError(t, lrp.ForceFlush(context.Background()))
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("concurrent ForceFlush calls did not return")
+	}
+}
+
+func TestBatchLogRecordProcessorMaxConcurrentExportsShutdown(t *testing.T) {
+	exp := newConcurrentExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxExportBatchSize(1),
+		WithMaxConcurrentExports(2),
+	)
+
+	lrp.OnEmit(testLogRecord("0"))
+	lrp.OnEmit(testLogRecord("1"))
+	for i := 0; i < 2; i++ {
+		<-exp.started
+	}
+
+	done := make(chan error)
+	go func() { done <- lrp.Shutdown(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("shutdown did not wait for the running exports")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, exp.shutdown.Load(), "exporter shut down before the end of the exports")
+
+	close(exp.release)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not return")
+	}
+	assert.ElementsMatch(t, []string{"0", "1"}, exp.exported())
+	assert.True(t, exp.shutdown.Load())
+}
+
+// droppedRecords records the calls of a DroppedRecordsCallback.
+type droppedRecords struct {
+	mu    sync.Mutex
+	count map[string]int
+}
+
+func (d *droppedRecords) callback(count int, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.count == nil {
+		d.count = make(map[string]int)
+	}
+	d.count[reason] += count
+}
+
+func (d *droppedRecords) got() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.count
+}
+
+func TestBatchLogRecordProcessorDroppedRecordsCallbackQueueFull(t *testing.T) {
+	var dropped droppedRecords
+	exp := newGatedExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(1),
+		WithMaxExportBatchSize(1),
+		WithDroppedRecordsCallback(dropped.callback),
+	)
+
+	lrp.OnEmit(testLogRecord("first"))
+	<-exp.started
+	lrp.OnEmit(testLogRecord("second"))
+	lrp.OnEmit(testLogRecord("third"))
+	lrp.OnEmit(testLogRecord("fourth"))
+
+	close(exp.release)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, map[string]int{DroppedReasonQueueFull: 2}, dropped.got())
+	assert.Equal(t, []string{"first", "second"}, exp.exported())
+}
+
+func TestBatchLogRecordProcessorDroppedRecordsCallbackExportFailed(t *testing.T) {
+	for _, concurrent := range []int{1, 2} {
+		t.Run(fmt.Sprintf("%d concurrent exports", concurrent), func(t *testing.T) {
+			var dropped droppedRecords
+			lrp := NewBatchLogRecordProcessor(failingExporter{err: errors.New("collector unavailable")},
+				WithMaxConcurrentExports(concurrent),
+				WithDroppedRecordsCallback(dropped.callback),
+				WithErrorHandler(func(error) {}),
+			)
+
+			lrp.OnEmit(testLogRecord("first"))
+			lrp.OnEmit(testLogRecord("second"))
+			_ = lrp.ForceFlush(context.Background())
+			require.NoError(t, lrp.Shutdown(context.Background()))
+			assert.Equal(t, map[string]int{DroppedReasonExportFailed: 2}, dropped.got())
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorDroppedRecordsCallbackShutdown(t *testing.T) {
+	t.Run("discarded on shutdown", func(t *testing.T) {
+		var dropped droppedRecords
+		lrp := NewBatchLogRecordProcessor(&batchRecordingExporter{},
+			WithBatchTimeout(time.Hour),
+			WithExportOnShutdown(false),
+			WithDroppedRecordsCallback(dropped.callback),
+		)
+		lrp.OnEmit(testLogRecord("first"))
+		lrp.OnEmit(testLogRecord("second"))
+		require.NoError(t, lrp.Shutdown(context.Background()))
+		assert.Equal(t, map[string]int{DroppedReasonShutdown: 2}, dropped.got())
+	})
+
+	t.Run("emitted after shutdown", func(t *testing.T) {
+		var dropped droppedRecords
+		lrp := NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithDroppedRecordsCallback(dropped.callback))
+		require.NoError(t, lrp.Shutdown(context.Background()))
+		lrp.OnEmit(testLogRecord("late"))
+		lrp.(BulkProcessor).OnEmitBatch([]ReadableLogRecord{testLogRecord("later"), testLogRecord("latest")})
+		assert.Equal(t, map[string]int{DroppedReasonShutdown: 3}, dropped.got())
+	})
+
+	t.Run("blocked emit given up", func(t *testing.T) {
+		var dropped droppedRecords
+		exp := newGatedExporter()
+		lrp := NewBatchLogRecordProcessor(exp,
+			WithMaxQueueSize(1),
+			WithMaxExportBatchSize(1),
+			WithBlocking(),
+			WithDroppedRecordsCallback(dropped.callback),
+		)
+		lrp.OnEmit(testLogRecord("first"))
+		<-exp.started
+		lrp.OnEmit(testLogRecord("second"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		body := "third"
+		lrp.OnEmit(&exportableLogRecord{body: &body, observedTimestamp: time.Now(), ctx: ctx})
+		assert.Equal(t, map[string]int{DroppedReasonQueueFull: 1}, dropped.got())
+
+		close(exp.release)
+		require.NoError(t, lrp.Shutdown(context.Background()))
+		assert.Equal(t, []string{"first", "second"}, exp.exported())
+	})
+}
+
+func TestBatchLogRecordProcessorQueueFullPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy QueueFullPolicy
+		want   []string
+	}{
+		{name: "drop newest", policy: DropNewest, want: []string{"first", "second", "third"}},
+		{name: "drop oldest", policy: DropOldest, want: []string{"first", "fourth", "fifth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped droppedRecords
+			exp := newGatedExporter()
+			lrp := NewBatchLogRecordProcessor(exp,
+				WithMaxQueueSize(2),
+				WithMaxExportBatchSize(1),
+				WithQueueFullPolicy(tt.policy),
+				WithDroppedRecordsCallback(dropped.callback),
+			)
+
+			lrp.OnEmit(testLogRecord("first"))
+			<-exp.started
+			for _, body := range []string{"second", "third", "fourth", "fifth"} {
+				lrp.OnEmit(testLogRecord(body))
+			}
+
+			close(exp.release)
+			require.NoError(t, lrp.Shutdown(context.Background()))
+			assert.Equal(t, tt.want, exp.exported())
+			assert.Equal(t, map[string]int{DroppedReasonQueueFull: 2}, dropped.got())
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorDropOldestForceFlush(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		queueSize int
+		want      []string
+	}{
+		// The flush marker is kept, the log queued after it is evicted.
+		{name: "log queued after the flush", queueSize: 2, want: []string{"first", "third"}},
+		// The queue only holds the flush marker, the log being emitted is
+		// dropped.
+		{name: "only the flush queued", queueSize: 1, want: []string{"first"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped droppedRecords
+			exp := newGatedExporter()
+			lrp := NewBatchLogRecordProcessor(exp,
+				WithMaxQueueSize(tt.queueSize),
+				WithMaxExportBatchSize(1),
+				WithQueueFullPolicy(DropOldest),
+				WithDroppedRecordsCallback(dropped.callback),
+			).(*batchLogRecordProcessor)
+
+			lrp.OnEmit(testLogRecord("first"))
+			<-exp.started
+			flushed := make(chan error)
+			go func() { flushed <- lrp.ForceFlush(context.Background()) }()
+			require.Eventually(t, func() bool { return len(lrp.queue) == 1 }, time.Second, time.Millisecond)
+			if tt.queueSize > 1 {
+				lrp.OnEmit(testLogRecord("second"))
+			}
+			lrp.OnEmit(testLogRecord("third"))
+
+			select {
+			case err := <-flushed:
+				t.Fatalf("ForceFlush returned before the export of the logs queued before it: %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(exp.release)
+			require.NoError(t, <-flushed)
+			require.NoError(t, lrp.Shutdown(context.Background()))
+			assert.Equal(t, tt.want, exp.exported())
+			assert.Equal(t, map[string]int{DroppedReasonQueueFull: 1}, dropped.got())
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorExportOnShutdown(t *testing.T) {
+	for _, export := range []bool{true, false} {
+		t.Run(fmt.Sprint(export), func(t *testing.T) {
+			exp := &batchRecordingExporter{}
+			lrp := NewBatchLogRecordProcessor(exp,
+				WithBatchTimeout(time.Hour),
+				WithExportOnShutdown(export),
+			)
+
+			lrp.OnEmit(testLogRecord("first"))
+			lrp.OnEmit(testLogRecord("second"))
+			require.NoError(t, lrp.Shutdown(context.Background()))
+
+			if !export {
+				assert.Empty(t, exp.exported(), "the buffered logs are discarded")
+				return
+			}
+			require.Len(t, exp.exported(), 1)
+			assert.Len(t, exp.exported()[0], 2)
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorFlush(t *testing.T) {
+	for _, concurrent := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrent=%d", concurrent), func(t *testing.T) {
+			exp := &batchRecordingExporter{}
+			lrp := NewBatchLogRecordProcessor(exp,
+				WithBatchTimeout(time.Hour),
+				WithMaxConcurrentExports(concurrent),
+			)
+			t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+			flusher, ok := lrp.(Flusher)
+			require.True(t, ok)
+
+			ctx := context.Background()
+			flushed, err := flusher.Flush(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, flushed)
+
+			lrp.OnEmit(testLogRecord("first"))
+			flushed, err = flusher.Flush(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, flushed)
+
+			for i := 0; i < 5; i++ {
+				lrp.OnEmit(testLogRecord(fmt.Sprint(i)))
+			}
+			flushed, err = flusher.Flush(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 5, flushed)
+
+			total := 0
+			for _, batch := range exp.exported() {
+				total += len(batch)
+			}
+			assert.Equal(t, 6, total)
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorFlushConcurrent(t *testing.T) {
+	const flushes, records = 8, 50
+	for _, concurrent := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrent=%d", concurrent), func(t *testing.T) {
+			exp := &batchRecordingExporter{}
+			lrp := NewBatchLogRecordProcessor(exp,
+				WithBatchTimeout(time.Millisecond),
+				WithMaxConcurrentExports(concurrent),
+			)
+			flusher := lrp.(Flusher)
+
+			// Each record is counted by at most one flush, the ones exported
+			// by the timer by none.
+			var flushed atomic.Int64
+			var wg sync.WaitGroup
+			for i := 0; i < flushes; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < records; j++ {
+						lrp.OnEmit(testLogRecord(fmt.Sprint(j)))
+						n, err := flusher.Flush(context.Background())
+						assert.NoError(t, err)
+						flushed.Add(int64(n))
+					}
+				}()
+			}
+			wg.Wait()
+			require.NoError(t, lrp.Shutdown(context.Background()))
+
+			total := 0
+			for _, batch := range exp.exported() {
+				total += len(batch)
+			}
+			assert.Equal(t, flushes*records, total)
+			assert.LessOrEqual(t, flushed.Load(), int64(total))
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorFlushFailedExport(t *testing.T) {
+	exportErr := errors.New("collector unavailable")
+	lrp := NewBatchLogRecordProcessor(failingExporter{err: exportErr},
+		WithBatchTimeout(time.Hour),
+		WithErrorHandler(func(error) {}),
+	)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	lrp.OnEmit(testLogRecord("first"))
+	lrp.OnEmit(testLogRecord("second"))
+	flushed, err := lrp.(Flusher).Flush(context.Background())
+	assert.ErrorIs(t, err, exportErr)
+	assert.Zero(t, flushed, "records failing to export are not counted")
+}
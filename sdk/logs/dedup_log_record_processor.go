@@ -0,0 +1,212 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"time"
+)
+
+// RepeatCountKey is the attribute Key added by the processor returned by
+// NewDedupProcessor to the summary record of a duplicated log record. Its value
+// is the number of duplicates suppressed within the window.
+const RepeatCountKey = attribute.Key("log.repeat.count")
+
+// dedupKey identifies duplicated log records.
+type dedupKey struct {
+	body       string
+	severity   logs.SeverityNumber
+	attributes attribute.Distinct
+}
+
+func newDedupKey(rol ReadableLogRecord) dedupKey {
+	var k dedupKey
+	switch body := rol.Body().(type) {
+	case nil:
+	case *string:
+		if body != nil {
+			k.body = *body
+		}
+	default:
+		k.body = fmt.Sprint(body)
+	}
+	if sn := rol.SeverityNumber(); sn != nil {
+		k.severity = *sn
+	}
+	var attrs []attribute.KeyValue
+	if a := rol.Attributes(); a != nil {
+		// NewSet sorts its argument in place, the attributes are shared with
+		// the other processors.
+		attrs = append(attrs, *a...)
+	}
+	set := attribute.NewSet(attrs...)
+	k.attributes = set.Equivalent()
+	return k
+}
+
+type dedupEntry struct {
+	last    ReadableLogRecord
+	repeats int64
+	timer   *time.Timer
+}
+
+type dedupLogRecordProcessor struct {
+	next   LogRecordProcessor
+	window time.Duration
+
+	mu       sync.Mutex
+	entries  map[dedupKey]*dedupEntry
+	stopped  bool
+	stopOnce sync.Once
+}
+
+var _ LogRecordProcessor = (*dedupLogRecordProcessor)(nil)
+
+// NewDedupProcessor returns a new LogRecordProcessor collapsing the log
+// records repeated within window before passing them to next.
+//
+// Log records are duplicates when they have the same body, severity number and
+// attributes. The first record is passed to next immediately, the duplicates
+// received within window after it are suppressed. When the window closes, or
+// on ForceFlush and Shutdown, a summary record is passed to next if any
+// duplicate was suppressed: it is the last duplicate with the RepeatCountKey
+// attribute set to the number of suppressed duplicates.
+//
+// If window is not positive, log records are passed to next unchanged.
+func NewDedupProcessor(next LogRecordProcessor, window time.Duration) LogRecordProcessor {
+	return &dedupLogRecordProcessor{
+		next:    next,
+		window:  window,
+		entries: make(map[dedupKey]*dedupEntry),
+	}
+}
+
+// OnEmit passes the log record to the next processor unless it duplicates a
+// record received within the window.
+func (lrp *dedupLogRecordProcessor) OnEmit(rol ReadableLogRecord) {
+	if lrp.window <= 0 {
+		lrp.next.OnEmit(rol)
+		return
+	}
+
+	k := newDedupKey(rol)
+
+	lrp.mu.Lock()
+	if lrp.stopped {
+		lrp.mu.Unlock()
+		return
+	}
+	if e, ok := lrp.entries[k]; ok {
+		// The last duplicate is kept until the summary is emitted.
+		retainLogRecord(rol)
+		last := e.last
+		e.last = rol
+		e.repeats++
+		lrp.mu.Unlock()
+		releaseLogRecord(last)
+		return
+	}
+	e := &dedupEntry{}
+	e.timer = time.AfterFunc(lrp.window, func() { lrp.flushEntry(k, e) })
+	lrp.entries[k] = e
+	lrp.mu.Unlock()
+
+	lrp.next.OnEmit(rol)
+}
+
+// flushEntry closes the window of the entry, passing its summary to next.
+func (lrp *dedupLogRecordProcessor) flushEntry(k dedupKey, e *dedupEntry) {
+	lrp.mu.Lock()
+	if lrp.entries[k] != e {
+		// Already flushed.
+		lrp.mu.Unlock()
+		return
+	}
+	delete(lrp.entries, k)
+	lrp.mu.Unlock()
+
+	lrp.emitSummary(e)
+}
+
+// flush closes all the windows, passing their summaries to next.
+func (lrp *dedupLogRecordProcessor) flush() {
+	lrp.mu.Lock()
+	entries := lrp.entries
+	lrp.entries = make(map[dedupKey]*dedupEntry)
+	lrp.mu.Unlock()
+
+	for _, e := range entries {
+		e.timer.Stop()
+		lrp.emitSummary(e)
+	}
+}
+
+func (lrp *dedupLogRecordProcessor) emitSummary(e *dedupEntry) {
+	if e.repeats == 0 {
+		return
+	}
+	lrp.next.OnEmit(newRepeatedLogRecord(e.last, e.repeats))
+	releaseLogRecord(e.last)
+}
+
+// newRepeatedLogRecord returns a copy of rol with the RepeatCountKey attribute.
+func newRepeatedLogRecord(rol ReadableLogRecord, repeats int64) ReadableLogRecord {
+	var attrs []attribute.KeyValue
+	if a := rol.Attributes(); a != nil {
+		attrs = append(attrs, *a...)
+	}
+	attrs = append(attrs, RepeatCountKey.Int64(repeats))
+	return cloneLogRecord(rol, &attrs)
+}
+
+// Shutdown passes the pending summaries to the next processor and shuts it
+// down.
+func (lrp *dedupLogRecordProcessor) Shutdown(ctx context.Context) error {
+	var err error
+	lrp.stopOnce.Do(func() {
+		lrp.mu.Lock()
+		lrp.stopped = true
+		lrp.mu.Unlock()
+		lrp.flush()
+		err = lrp.next.Shutdown(ctx)
+	})
+	return err
+}
+
+// ForceFlush passes the pending summaries to the next processor and flushes
+// it.
+func (lrp *dedupLogRecordProcessor) ForceFlush(ctx context.Context) error {
+	lrp.flush()
+	return lrp.next.ForceFlush(ctx)
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
+func (lrp *dedupLogRecordProcessor) MarshalLog() interface{} {
+	return struct {
+		Type               string
+		LogRecordProcessor LogRecordProcessor
+		Window             time.Duration
+	}{
+		Type:               "DedupLogRecordProcessor",
+		LogRecordProcessor: lrp.next,
+		Window:             lrp.window,
+	}
+}
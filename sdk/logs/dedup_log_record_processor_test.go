@@ -0,0 +1,140 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingProcessor records the log records it receives.
+type recordingProcessor struct {
+	mu      sync.Mutex
+	records []ReadableLogRecord
+}
+
+func (p *recordingProcessor) OnEmit(rol ReadableLogRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, rol)
+}
+func (p *recordingProcessor) Shutdown(context.Context) error   { return nil }
+func (p *recordingProcessor) ForceFlush(context.Context) error { return nil }
+
+func (p *recordingProcessor) got() []ReadableLogRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ReadableLogRecord(nil), p.records...)
+}
+
+func dedupRecord(body string, severity logs.SeverityNumber, attrs ...attribute.KeyValue) ReadableLogRecord {
+	return &exportableLogRecord{body: &body, severityNumber: &severity, attributes: &attrs}
+}
+
+func repeatCount(t *testing.T, rol ReadableLogRecord) (int64, bool) {
+	t.Helper()
+	for _, kv := range *rol.Attributes() {
+		if kv.Key == RepeatCountKey {
+			return kv.Value.AsInt64(), true
+		}
+	}
+	return 0, false
+}
+
+func TestDedupProcessorSuppressesDuplicates(t *testing.T) {
+	next := &recordingProcessor{}
+	lrp := NewDedupProcessor(next, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		lrp.OnEmit(dedupRecord("retrying", logs.WARN, attribute.String("host", "a")))
+	}
+	// Different severity, body or attributes are not duplicates.
+	lrp.OnEmit(dedupRecord("retrying", logs.ERROR, attribute.String("host", "a")))
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN, attribute.String("host", "b")))
+	lrp.OnEmit(dedupRecord("done", logs.WARN, attribute.String("host", "a")))
+
+	got := next.got()
+	require.Len(t, got, 4)
+	for _, rol := range got {
+		_, ok := repeatCount(t, rol)
+		assert.False(t, ok, "first occurrences are passed unchanged")
+	}
+
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	got = next.got()
+	require.Len(t, got, 5, "only the repeated record has a summary")
+	summary := got[4]
+	assert.Equal(t, "retrying", *summary.Body().(*string))
+	assert.Equal(t, logs.WARN, *summary.SeverityNumber())
+	count, ok := repeatCount(t, summary)
+	require.True(t, ok)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestDedupProcessorWindowClose(t *testing.T) {
+	next := &recordingProcessor{}
+	lrp := NewDedupProcessor(next, 20*time.Millisecond)
+	t.Cleanup(func() { require.NoError(t, lrp.Shutdown(context.Background())) })
+
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+
+	require.Eventually(t, func() bool { return len(next.got()) == 2 }, 5*time.Second, 5*time.Millisecond)
+	count, ok := repeatCount(t, next.got()[1])
+	require.True(t, ok)
+	assert.Equal(t, int64(1), count)
+
+	// Outside of the window, the record is passed again.
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	got := next.got()
+	require.Len(t, got, 3)
+	_, ok = repeatCount(t, got[2])
+	assert.False(t, ok)
+}
+
+func TestDedupProcessorShutdownFlushes(t *testing.T) {
+	next := &recordingProcessor{}
+	lrp := NewDedupProcessor(next, time.Hour)
+
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	got := next.got()
+	require.Len(t, got, 2)
+	count, ok := repeatCount(t, got[1])
+	require.True(t, ok)
+	assert.Equal(t, int64(2), count)
+
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	assert.Len(t, next.got(), 2, "records are dropped after shutdown")
+}
+
+func TestDedupProcessorWithoutWindow(t *testing.T) {
+	next := &recordingProcessor{}
+	lrp := NewDedupProcessor(next, 0)
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	lrp.OnEmit(dedupRecord("retrying", logs.WARN))
+	assert.Len(t, next.got(), 2)
+}
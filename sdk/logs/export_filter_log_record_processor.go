@@ -0,0 +1,83 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+)
+
+type exportFilterProcessor struct {
+	next   LogRecordProcessor
+	filter func(ReadableLogRecord) bool
+}
+
+var _ LogRecordProcessor = (*exportFilterProcessor)(nil)
+var _ FilterProcessor = (*exportFilterProcessor)(nil)
+
+// NewExportFilterProcessor returns a new LogRecordProcessor that passes to
+// next only the log records for which filter returns true, for the cases not
+// covered by the other processors, e.g. to drop the logs of a noisy component
+// by one of their attributes. filter is called concurrently by the goroutines
+// emitting logs and must not retain the records.
+//
+// If filter is nil, all the log records are passed to next.
+func NewExportFilterProcessor(next LogRecordProcessor, filter func(ReadableLogRecord) bool) LogRecordProcessor {
+	if filter == nil {
+		filter = func(ReadableLogRecord) bool { return true }
+	}
+	return &exportFilterProcessor{
+		next:   next,
+		filter: filter,
+	}
+}
+
+// OnEmit passes the log record to the next processor if the filter keeps it.
+func (lrp *exportFilterProcessor) OnEmit(rol ReadableLogRecord) {
+	if lrp.filter(rol) {
+		lrp.next.OnEmit(rol)
+	}
+}
+
+// Enabled reports whether the next processor is enabled.
+func (lrp *exportFilterProcessor) Enabled(ctx context.Context, severity logs.SeverityNumber) bool {
+	if fp, ok := lrp.next.(FilterProcessor); ok {
+		return fp.Enabled(ctx, severity)
+	}
+	return true
+}
+
+// Shutdown shuts down the next processor.
+func (lrp *exportFilterProcessor) Shutdown(ctx context.Context) error {
+	return lrp.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the next processor.
+func (lrp *exportFilterProcessor) ForceFlush(ctx context.Context) error {
+	return lrp.next.ForceFlush(ctx)
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
+func (lrp *exportFilterProcessor) MarshalLog() interface{} {
+	return struct {
+		Type               string
+		LogRecordProcessor LogRecordProcessor
+	}{
+		Type:               "ExportFilterProcessor",
+		LogRecordProcessor: lrp.next,
+	}
+}
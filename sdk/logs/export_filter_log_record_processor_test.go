@@ -0,0 +1,102 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"testing"
+)
+
+func TestExportFilterProcessor(t *testing.T) {
+	component := func(name string) ReadableLogRecord {
+		attrs := []attribute.KeyValue{attribute.String("component", name)}
+		return &exportableLogRecord{attributes: &attrs}
+	}
+	notHealthcheck := func(r ReadableLogRecord) bool {
+		if r.Attributes() == nil {
+			return true
+		}
+		for _, kv := range *r.Attributes() {
+			if kv.Key == "component" {
+				return kv.Value.AsString() != "healthcheck"
+			}
+		}
+		return true
+	}
+	tests := []struct {
+		name   string
+		filter func(ReadableLogRecord) bool
+		want   int
+	}{
+		{name: "nil filter", want: 3},
+		{name: "attribute filter", filter: notHealthcheck, want: 2},
+		{name: "drop all", filter: func(ReadableLogRecord) bool { return false }, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingProcessor{}
+			lrp := NewExportFilterProcessor(next, tt.filter)
+
+			billing := component("billing")
+			lrp.OnEmit(billing)
+			lrp.OnEmit(component("healthcheck"))
+			lrp.OnEmit(&exportableLogRecord{})
+
+			got := next.got()
+			require.Len(t, got, tt.want)
+			if tt.want > 0 {
+				assert.Same(t, billing, got[0], "the records kept are passed unchanged")
+			}
+			require.NoError(t, lrp.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestExportFilterProcessorComposes(t *testing.T) {
+	// The filter only applies to the logs passed to the processor it wraps.
+	filtered := &recordingProcessor{}
+	other := &recordingProcessor{}
+	lp := NewLoggerProvider(
+		WithLogRecordProcessor(NewExportFilterProcessor(filtered, func(r ReadableLogRecord) bool { return r.Body() != "probed" })),
+		WithLogRecordProcessor(other),
+	)
+	l := lp.Logger("test")
+	for _, body := range []string{"served", "probed"} {
+		l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	}
+	require.NoError(t, lp.Shutdown(context.Background()))
+	got := filtered.got()
+	require.Len(t, got, 1)
+	assert.Equal(t, "served", got[0].Body())
+	assert.Len(t, other.got(), 2)
+}
+
+func TestExportFilterProcessorEnabled(t *testing.T) {
+	ctx := context.Background()
+	keep := func(ReadableLogRecord) bool { return true }
+
+	fp := NewExportFilterProcessor(&minSeverityProcessor{min: logs.WARN}, keep).(FilterProcessor)
+	assert.False(t, fp.Enabled(ctx, logs.INFO))
+	assert.True(t, fp.Enabled(ctx, logs.WARN))
+
+	fp = NewExportFilterProcessor(&recordingProcessor{}, keep).(FilterProcessor)
+	assert.True(t, fp.Enabled(ctx, logs.DEBUG), "a next processor without Enabled is enabled")
+}
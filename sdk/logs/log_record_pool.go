@@ -0,0 +1,113 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"time"
+)
+
+// logRecordPool holds the records reused by the Loggers of the providers
+// created with WithLogRecordPool.
+var logRecordPool = sync.Pool{
+	New: func() any { return &exportableLogRecord{pooled: true} },
+}
+
+// getLogRecord returns a record of the pool, owned by the caller until it
+// releases it.
+func getLogRecord() *exportableLogRecord {
+	r := logRecordPool.Get().(*exportableLogRecord)
+	r.refs.Store(1)
+	return r
+}
+
+// retainLogRecord keeps rol, if it is a pooled record, out of the pool until
+// releaseLogRecord is called for it. The processors of this package keeping
+// records past the return of OnEmit retain them.
+func retainLogRecord(rol ReadableLogRecord) {
+	if r, ok := rol.(*exportableLogRecord); ok && r.pooled {
+		r.refs.Add(1)
+	}
+}
+
+// releaseLogRecord returns rol, if it is a pooled record, to the pool once
+// released by all the holders which retained it.
+func releaseLogRecord(rol ReadableLogRecord) {
+	r, ok := rol.(*exportableLogRecord)
+	if !ok || !r.pooled || r.refs.Add(-1) != 0 {
+		return
+	}
+	r.reset()
+	logRecordPool.Put(r)
+}
+
+// releaseLogRecords releases each record of records.
+func releaseLogRecords(records []ReadableLogRecord) {
+	for _, r := range records {
+		releaseLogRecord(r)
+	}
+}
+
+// reset clears the record for its reuse, keeping the capacity of its
+// attributes buffer.
+func (r *exportableLogRecord) reset() {
+	clear(r.attrBuf)
+	r.attrBuf = r.attrBuf[:0]
+	r.timestamp = nil
+	r.observedTimestamp = time.Time{}
+	r.traceId = nil
+	r.spanId = nil
+	r.traceFlags = nil
+	r.severityText = nil
+	r.severityNumber = nil
+	r.eventName = nil
+	r.body = nil
+	r.resource = nil
+	r.instrumentationScope = nil
+	r.attributes = nil
+	r.droppedAttributes = 0
+	r.ctx = nil
+}
+
+// appendRecordAttributes appends the attributes of a record merged with the
+// default attributes of the logger to dst, the ones of the record winning on
+// conflict.
+func (l logger) appendRecordAttributes(dst []attribute.KeyValue, attrs *[]attribute.KeyValue) []attribute.KeyValue {
+	if attrs == nil || len(*attrs) == 0 {
+		return append(dst, l.attributes...)
+	}
+	if len(l.attributes) == 0 {
+		return append(dst, *attrs...)
+	}
+	for _, kv := range l.attributes {
+		if !hasAttribute(*attrs, kv.Key) {
+			dst = append(dst, kv)
+		}
+	}
+	return append(dst, *attrs...)
+}
+
+// hasAttribute returns true if attrs has an attribute with the key.
+func hasAttribute(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
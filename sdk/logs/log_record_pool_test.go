@@ -0,0 +1,151 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"testing"
+	"time"
+)
+
+// copyingExporter copies the body and id attribute of the exported records,
+// as exporters must with pooled records, and reports the records whose body
+// and id differ.
+type copyingExporter struct {
+	mu        sync.Mutex
+	ids       []string
+	corrupted []string
+}
+
+func (e *copyingExporter) Export(_ context.Context, records []ReadableLogRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		body, _ := r.Body().(string)
+		var id, component string
+		for _, kv := range *r.Attributes() {
+			switch kv.Key {
+			case "id":
+				id = kv.Value.AsString()
+			case "component":
+				component = kv.Value.AsString()
+			}
+		}
+		if id != body || component != "billing" {
+			e.corrupted = append(e.corrupted, fmt.Sprintf("body %q, id %q, component %q", body, id, component))
+		}
+		e.ids = append(e.ids, id)
+	}
+	return nil
+}
+
+func (e *copyingExporter) Shutdown(context.Context) error { return nil }
+
+func TestLogRecordPoolConcurrentEmit(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+	first, second := &copyingExporter{}, &copyingExporter{}
+	lp := NewLoggerProvider(
+		WithLogRecordPool(),
+		WithBatcher(first, WithMaxExportBatchSize(64), WithBlocking()),
+		WithLogRecordProcessor(NewDedupProcessor(NewBatchLogRecordProcessor(second, WithMaxExportBatchSize(16), WithBlocking()), time.Hour)),
+	)
+	l := lp.Logger("billing", logs.WithAttributes(attribute.String("component", "billing")))
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				id := fmt.Sprintf("%d-%d", g, i)
+				attrs := []attribute.KeyValue{attribute.String("id", id)}
+				l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &id, Attributes: &attrs}))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, lp.Shutdown(context.Background()))
+
+	for _, exp := range []*copyingExporter{first, second} {
+		assert.Empty(t, exp.corrupted)
+		assert.Len(t, exp.ids, goroutines*perGoroutine)
+		seen := make(map[string]bool, len(exp.ids))
+		for _, id := range exp.ids {
+			assert.False(t, seen[id], "record %s exported twice", id)
+			seen[id] = true
+		}
+	}
+}
+
+func TestLogRecordPoolRelease(t *testing.T) {
+	r := getLogRecord()
+	body := "body"
+	r.body = &body
+	r.attrBuf = append(r.attrBuf, attribute.String("id", "1"))
+	r.attributes = &r.attrBuf
+
+	retainLogRecord(r)
+	releaseLogRecord(r)
+	assert.Equal(t, &body, r.Body(), "the record is still retained")
+
+	releaseLogRecord(r)
+	assert.Nil(t, r.Body(), "the released record is reset")
+	assert.Nil(t, r.Attributes())
+	assert.Empty(t, r.attrBuf)
+
+	// Records not from the pool are left alone.
+	unpooled := &exportableLogRecord{body: &body}
+	releaseLogRecord(unpooled)
+	assert.Equal(t, &body, unpooled.Body())
+}
+
+// discardProcessor drops the records it receives.
+type discardProcessor struct{}
+
+func (discardProcessor) OnEmit(ReadableLogRecord)         {}
+func (discardProcessor) Shutdown(context.Context) error   { return nil }
+func (discardProcessor) ForceFlush(context.Context) error { return nil }
+
+func BenchmarkLoggerEmit(b *testing.B) {
+	body := "request served"
+	attrs := []attribute.KeyValue{attribute.String("http.method", "GET"), attribute.Int("http.status_code", 200)}
+	record := logs.NewLogRecord(logs.LogRecordConfig{Body: &body, Attributes: &attrs})
+
+	for _, bm := range []struct {
+		name string
+		opts []LoggerProviderOption
+	}{
+		{name: "default"},
+		{name: "pool", opts: []LoggerProviderOption{WithLogRecordPool()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			lp := NewLoggerProvider(append(bm.opts, WithLogRecordProcessor(discardProcessor{}))...)
+			l := lp.Logger("bench", logs.WithAttributes(attribute.String("component", "billing")))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Emit(record)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package logs
 
 import (
 	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
 	"sync"
 )
 
@@ -55,6 +56,37 @@ type LogRecordProcessor interface {
 	// must never be done outside of a new major release.
 }
 
+// FilterProcessor is a LogRecordProcessor that can report whether it would
+// process a record. It is consulted by Logger.Enabled.
+//
+// A LogRecordProcessor that does not implement FilterProcessor is assumed to
+// process every record.
+type FilterProcessor interface {
+	// Enabled returns false if a record with the severity emitted in ctx
+	// would be dropped by the processor.
+	Enabled(ctx context.Context, severity logs.SeverityNumber) bool
+}
+
+// Flusher is a LogRecordProcessor that can report the number of records it
+// exported when flushed, e.g. to assert the progress of an export in tests.
+type Flusher interface {
+	// Flush is ForceFlush returning the number of records the flush itself
+	// exported, not counting the ones exported meanwhile by the processor
+	// on its own or by concurrent flushes.
+	Flush(ctx context.Context) (int, error)
+}
+
+// BulkProcessor is a LogRecordProcessor that can process the records of
+// logs.BatchLogger.EmitBatch at once, e.g. to export them in a single call.
+//
+// The records of a LogRecordProcessor that does not implement BulkProcessor
+// are passed one by one to OnEmit.
+type BulkProcessor interface {
+	// OnEmitBatch processes records as OnEmit would process each of them, in
+	// order. The records slice must not be retained after it returns.
+	OnEmitBatch(records []ReadableLogRecord)
+}
+
 type logRecordProcessorState struct {
 	lp    LogRecordProcessor
 	state sync.Once
@@ -0,0 +1,118 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"reflect"
+)
+
+const (
+	// logRecordOverhead over-approximates the encoded size of the fixed
+	// fields of a log record (timestamps, trace context, severity) and of the
+	// resource and scope envelopes wrapping it.
+	logRecordOverhead = 128
+	// fieldOverhead over-approximates the encoded size of the tag and length
+	// of a nested field such as an attribute.
+	fieldOverhead = 16
+)
+
+// estimateLogRecordSize returns an approximation of the encoded OTLP size of
+// a log record in bytes. It is meant to over-estimate rather than
+// under-estimate the size.
+func estimateLogRecordSize(rol ReadableLogRecord) int {
+	size := logRecordOverhead + estimateValueSize(rol.Body())
+	if st := rol.SeverityText(); st != nil {
+		size += len(*st)
+	}
+	if en := rol.EventName(); en != nil {
+		size += len(*en)
+	}
+	if attrs := rol.Attributes(); attrs != nil {
+		size += estimateAttributesSize(*attrs)
+	}
+	if res := rol.Resource(); res != nil {
+		size += estimateAttributesSize(res.Attributes())
+	}
+	if is := rol.InstrumentationScope(); is != nil {
+		size += len(is.Name) + len(is.Version) + len(is.SchemaURL)
+	}
+	return size
+}
+
+func estimateAttributesSize(attrs []attribute.KeyValue) int {
+	var size int
+	for _, kv := range attrs {
+		size += fieldOverhead + len(kv.Key) + estimateAttributeValueSize(kv.Value)
+	}
+	return size
+}
+
+func estimateAttributeValueSize(v attribute.Value) int {
+	switch v.Type() {
+	case attribute.STRING:
+		return len(v.AsString())
+	case attribute.STRINGSLICE:
+		var size int
+		for _, s := range v.AsStringSlice() {
+			size += fieldOverhead + len(s)
+		}
+		return size
+	case attribute.BOOLSLICE:
+		return fieldOverhead * len(v.AsBoolSlice())
+	case attribute.INT64SLICE:
+		return fieldOverhead * len(v.AsInt64Slice())
+	case attribute.FLOAT64SLICE:
+		return fieldOverhead * len(v.AsFloat64Slice())
+	default:
+		return fieldOverhead
+	}
+}
+
+// estimateValueSize approximates the encoded size of a log record body.
+func estimateValueSize(value any) int {
+	if value == nil {
+		return 0
+	}
+	val := reflect.ValueOf(value)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.String:
+		return fieldOverhead + val.Len()
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fieldOverhead
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return fieldOverhead + val.Len()
+		}
+	}
+	// Composite values are encoded as key-value lists, their formatting with
+	// the field names and an overhead per field approximates them.
+	if !val.CanInterface() {
+		return fieldOverhead
+	}
+	formatted := fmt.Sprintf("%+v", val.Interface())
+	return fieldOverhead + 2*len(formatted)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"time"
+)
+
+// errInvalidLogRecord is wrapped by the errors of the log records which do not
+// conform to the OTLP data model.
+var errInvalidLogRecord = errors.New("invalid log record")
+
+// validateLogRecord returns an error if rol does not conform to the OTLP data
+// model: its timestamps are before the Unix epoch, its severity number is out
+// of range, its trace or span ID is set but invalid, or one of its attributes
+// has an empty key.
+func validateLogRecord(rol ReadableLogRecord) error {
+	if ts := rol.Timestamp(); ts != nil && !ts.IsZero() && ts.Before(time.Unix(0, 0)) {
+		return fmt.Errorf("%w: negative timestamp %s", errInvalidLogRecord, ts)
+	}
+	if ts := rol.ObservedTimestamp(); !ts.IsZero() && ts.Before(time.Unix(0, 0)) {
+		return fmt.Errorf("%w: negative observed timestamp %s", errInvalidLogRecord, ts)
+	}
+	if sn := rol.SeverityNumber(); sn != nil && (*sn < logs.UNSPECIFIED || *sn > logs.FATAL4) {
+		return fmt.Errorf("%w: severity number %d out of range", errInvalidLogRecord, *sn)
+	}
+	traceID, spanID := rol.TraceId(), rol.SpanId()
+	if traceID != nil && !traceID.IsValid() {
+		return fmt.Errorf("%w: invalid trace ID %s", errInvalidLogRecord, traceID)
+	}
+	if spanID != nil && !spanID.IsValid() {
+		return fmt.Errorf("%w: invalid span ID %s", errInvalidLogRecord, spanID)
+	}
+	if spanID != nil && traceID == nil {
+		return fmt.Errorf("%w: span ID %s without a trace ID", errInvalidLogRecord, spanID)
+	}
+	if attrs := rol.Attributes(); attrs != nil {
+		for i, kv := range *attrs {
+			if kv.Key == "" {
+				return fmt.Errorf("%w: attribute %d has an empty key", errInvalidLogRecord, i)
+			}
+		}
+	}
+	return nil
+}
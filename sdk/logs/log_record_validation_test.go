@@ -0,0 +1,115 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	"time"
+)
+
+func TestValidateLogRecord(t *testing.T) {
+	validTraceID := trace.TraceID{1}
+	validSpanID := trace.SpanID{1}
+	zeroTraceID := trace.TraceID{}
+	zeroSpanID := trace.SpanID{}
+	epoch := time.Unix(0, 0)
+	beforeEpoch := time.Unix(-1, 0)
+	zeroTime := time.Time{}
+	severity := func(sn logs.SeverityNumber) *logs.SeverityNumber { return &sn }
+	attributes := func(attrs ...attribute.KeyValue) *[]attribute.KeyValue { return &attrs }
+
+	tests := []struct {
+		name    string
+		record  *exportableLogRecord
+		invalid string
+	}{
+		{name: "empty", record: &exportableLogRecord{}},
+		{name: "timestamp at epoch", record: &exportableLogRecord{timestamp: &epoch, observedTimestamp: epoch}},
+		{name: "zero timestamp", record: &exportableLogRecord{timestamp: &zeroTime}},
+		{name: "negative timestamp", record: &exportableLogRecord{timestamp: &beforeEpoch}, invalid: "negative timestamp"},
+		{name: "negative observed timestamp", record: &exportableLogRecord{observedTimestamp: beforeEpoch}, invalid: "negative observed timestamp"},
+		{name: "unspecified severity", record: &exportableLogRecord{severityNumber: severity(logs.UNSPECIFIED)}},
+		{name: "fatal4 severity", record: &exportableLogRecord{severityNumber: severity(logs.FATAL4)}},
+		{name: "negative severity", record: &exportableLogRecord{severityNumber: severity(-1)}, invalid: "severity number -1 out of range"},
+		{name: "severity above fatal4", record: &exportableLogRecord{severityNumber: severity(logs.FATAL4 + 1)}, invalid: "severity number 25 out of range"},
+		{name: "trace and span IDs", record: &exportableLogRecord{traceId: &validTraceID, spanId: &validSpanID}},
+		{name: "trace ID alone", record: &exportableLogRecord{traceId: &validTraceID}},
+		{name: "zero trace ID", record: &exportableLogRecord{traceId: &zeroTraceID, spanId: &validSpanID}, invalid: "invalid trace ID"},
+		{name: "zero span ID", record: &exportableLogRecord{traceId: &validTraceID, spanId: &zeroSpanID}, invalid: "invalid span ID"},
+		{name: "span ID alone", record: &exportableLogRecord{spanId: &validSpanID}, invalid: "without a trace ID"},
+		{name: "attributes", record: &exportableLogRecord{attributes: attributes(attribute.String("key", ""))}},
+		{name: "empty attribute key", record: &exportableLogRecord{attributes: attributes(attribute.String("key", "value"), attribute.String("", "value"))}, invalid: "attribute 1 has an empty key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLogRecord(tt.record)
+			if tt.invalid == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errInvalidLogRecord)
+			assert.ErrorContains(t, err, tt.invalid)
+		})
+	}
+}
+
+func TestBatchLogRecordProcessorRecordValidation(t *testing.T) {
+	var (
+		errs    []error
+		dropped = map[string]int{}
+	)
+	severity := logs.SeverityNumber(42)
+	invalid := &exportableLogRecord{severityNumber: &severity, observedTimestamp: time.Now()}
+
+	mp := newRecordingMeterProvider()
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithRecordValidation(),
+		WithMeterProvider(mp),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }),
+		WithDroppedRecordsCallback(func(count int, reason string) { dropped[reason] += count }),
+	)
+	lrp.OnEmit(testLogRecord("valid"))
+	lrp.OnEmit(invalid)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	require.Len(t, exp.exported(), 1)
+	require.Len(t, exp.exported()[0], 1)
+	assert.Equal(t, "valid", *exp.exported()[0][0].Body().(*string))
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errInvalidLogRecord)
+	assert.Equal(t, map[string]int{DroppedReasonInvalid: 1}, dropped)
+	assert.Equal(t, int64(1), mp.got()["otel.sdk.logs.processor.dropped/invalid"])
+}
+
+func TestBatchLogRecordProcessorRecordValidationDisabled(t *testing.T) {
+	severity := logs.SeverityNumber(42)
+	invalid := &exportableLogRecord{severityNumber: &severity, observedTimestamp: time.Now()}
+
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp)
+	lrp.OnEmit(invalid)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	require.Len(t, exp.exported(), 1)
+	assert.Len(t, exp.exported()[0], 1, "invalid records are exported without validation")
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package logs
 
 import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/internal/stacktrace"
 	"github.com/metoro-io/opentelemetry-logs-go/logs"
 	"github.com/metoro-io/opentelemetry-logs-go/semconv"
 	"go.opentelemetry.io/otel/attribute"
@@ -24,43 +26,254 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/trace"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type logger struct {
 	provider             *LoggerProvider
 	instrumentationScope instrumentation.Scope
+	// attributes are the defaults added to every emitted record.
+	attributes []attribute.KeyValue
 }
 
-var _ logs.Logger = &logger{}
+var (
+	_ logs.BatchLogger   = &logger{}
+	_ logs.EnabledLogger = &logger{}
+)
 
 func (l logger) Emit(logRecord logs.LogRecord) {
-	lps := l.provider.getLogRecordProcessorStates()
-	if len(lps) == 0 {
+	// Records emitted after the provider is shut down are dropped instead of
+	// being forwarded to already closed processors.
+	if l.provider.isShutdown.Load() {
+		l.provider.reportEmitAfterShutdown()
 		return
 	}
+	elr, lps := l.exportableLogRecord(logRecord.Context(), logRecord)
+	if elr == nil {
+		return
+	}
+	// The processors retain the record if they keep it past OnEmit.
+	defer releaseLogRecord(elr)
 
-	pr, err := resource.Merge(l.provider.resource, logRecord.Resource())
-	if err != nil {
+	for _, lp := range lps {
+		lp.lp.OnEmit(elr)
+	}
+}
+
+// EmitBatch implements logs.BatchLogger. It emits the records as Emit would,
+// handing the consecutive records routed to the same processors at once to
+// the BulkProcessor ones.
+func (l logger) EmitBatch(ctx context.Context, records []logs.LogRecord) {
+	if len(records) == 0 {
+		return
+	}
+	if l.provider.isShutdown.Load() {
+		l.provider.reportEmitAfterShutdown()
 		return
 	}
 
-	elr := &exportableLogRecord{
-		timestamp:            logRecord.Timestamp(),
-		observedTimestamp:    logRecord.ObservedTimestamp(),
-		traceId:              logRecord.TraceId(),
-		spanId:               logRecord.SpanId(),
-		traceFlags:           logRecord.TraceFlags(),
-		severityText:         logRecord.SeverityText(),
-		severityNumber:       logRecord.SeverityNumber(),
-		body:                 logRecord.Body(),
-		resource:             pr,
-		instrumentationScope: logRecord.InstrumentationScope(),
-		attributes:           logRecord.Attributes(),
+	var (
+		batch    []ReadableLogRecord
+		batchLps logRecordProcessorStates
+	)
+	for _, logRecord := range records {
+		recordCtx := logRecord.Context()
+		if recordCtx == nil {
+			recordCtx = ctx
+		}
+		elr, lps := l.exportableLogRecord(recordCtx, logRecord)
+		if elr == nil {
+			continue
+		}
+		if len(batch) > 0 && !sameLogRecordProcessorStates(lps, batchLps) {
+			emitBatch(batchLps, batch)
+			batch = nil
+		}
+		batch = append(batch, elr)
+		batchLps = lps
 	}
+	if len(batch) > 0 {
+		emitBatch(batchLps, batch)
+	}
+}
 
+// emitBatch hands records to lps, at once to the BulkProcessor ones, and then
+// releases them.
+func emitBatch(lps logRecordProcessorStates, records []ReadableLogRecord) {
 	for _, lp := range lps {
-		lp.lp.OnEmit(elr)
+		if bp, ok := lp.lp.(BulkProcessor); ok {
+			bp.OnEmitBatch(records)
+			continue
+		}
+		for _, r := range records {
+			lp.lp.OnEmit(r)
+		}
+	}
+	releaseLogRecords(records)
+}
+
+// sameLogRecordProcessorStates returns true if a and b are the same
+// processors, both returned by routeLogRecordProcessorStates. Comparing their
+// first elements tells the routes apart, see routeLogRecordProcessorStates.
+func sameLogRecordProcessorStates(a, b logRecordProcessorStates) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// exportableLogRecord returns the record handed to the processors for
+// logRecord emitted in ctx, which may be nil, and the processors it is routed
+// to. It returns a nil record if logRecord is dropped.
+//
+// A pooled record is owned by the caller, which releases it once handed to the
+// processors.
+func (l logger) exportableLogRecord(ctx context.Context, logRecord logs.LogRecord) (*exportableLogRecord, logRecordProcessorStates) {
+	if sn := logRecord.SeverityNumber(); sn != nil && l.provider.belowMinSeverity(*sn) {
+		return nil, nil
+	}
+	lps := l.provider.routeLogRecordProcessorStates(ctx)
+	if len(lps) == 0 {
+		return nil, nil
+	}
+
+	pr, err := resource.Merge(l.provider.resource, logRecord.Resource())
+	if err != nil {
+		return nil, nil
+	}
+
+	traceId, spanId, traceFlags := traceContext(ctx, logRecord, l.provider.spanContextFromContext)
+
+	observedTimestamp := logRecord.ObservedTimestamp()
+	if observedTimestamp.IsZero() {
+		observedTimestamp = l.provider.now()
+	}
+
+	var elr *exportableLogRecord
+	if l.provider.logRecordPool {
+		elr = getLogRecord()
+		elr.attrBuf = l.appendRecordAttributes(elr.attrBuf, logRecord.Attributes())
+		elr.attributes = &elr.attrBuf
+	} else {
+		elr = &exportableLogRecord{attributes: l.recordAttributes(logRecord.Attributes())}
+	}
+	elr.timestamp = logRecord.Timestamp()
+	elr.observedTimestamp = observedTimestamp
+	elr.traceId = traceId
+	elr.spanId = spanId
+	elr.traceFlags = traceFlags
+	elr.severityText = logRecord.SeverityText()
+	elr.severityNumber = logRecord.SeverityNumber()
+	elr.eventName = logRecord.EventName()
+	elr.body = logRecord.Body()
+	elr.resource = pr
+	elr.instrumentationScope = logRecord.InstrumentationScope()
+	elr.ctx = ctx
+	if l.capturesStackTrace(elr) {
+		// Skip logger.exportableLogRecord and logger.Emit or logger.EmitBatch.
+		elr.AddAttributes(semconv.ExceptionStacktrace(stacktrace.Capture(2, globalLoggerPrefix)))
+	}
+
+	if len(l.provider.logRecordHooks) > 0 {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		for _, hook := range l.provider.logRecordHooks {
+			hook(ctx, elr)
+		}
+	}
+	return elr, lps
+}
+
+// Enabled returns false if a record with the severity emitted in ctx would be
+// dropped: the provider is shut down, the severity is below the minimum
+// severity of the provider, no processor is registered for ctx, or all of them
+// are FilterProcessor dropping it.
+func (l logger) Enabled(ctx context.Context, severity logs.SeverityNumber) bool {
+	if l.provider.isShutdown.Load() || l.provider.belowMinSeverity(severity) {
+		return false
+	}
+	for _, lps := range l.provider.routeLogRecordProcessorStates(ctx) {
+		fp, ok := lps.lp.(FilterProcessor)
+		if !ok || fp.Enabled(ctx, severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturesStackTrace returns true if the stack trace of the caller of Emit is
+// captured for r, see WithStackTraceCapture.
+func (l logger) capturesStackTrace(r *exportableLogRecord) bool {
+	if l.provider.stackTraceSeverity == logs.UNSPECIFIED || r.severityNumber == nil || *r.severityNumber < l.provider.stackTraceSeverity {
+		return false
+	}
+	if r.attributes != nil {
+		for _, kv := range *r.attributes {
+			if kv.Key == semconv.ExceptionStacktraceKey {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// globalLoggerPrefix is the prefix of the functions of the global logger
+// delegating to the SDK, skipped with the SDK frames in the stack traces.
+const globalLoggerPrefix = "github.com/metoro-io/opentelemetry-logs-go/internal/global."
+
+// recordAttributes returns the attributes of a record merged with the default
+// attributes of the logger, the ones of the record winning on conflict.
+func (l logger) recordAttributes(attrs *[]attribute.KeyValue) *[]attribute.KeyValue {
+	if len(l.attributes) == 0 {
+		return attrs
+	}
+	if attrs == nil || len(*attrs) == 0 {
+		merged := append([]attribute.KeyValue(nil), l.attributes...)
+		return &merged
+	}
+
+	keys := make(map[attribute.Key]struct{}, len(*attrs))
+	for _, kv := range *attrs {
+		keys[kv.Key] = struct{}{}
+	}
+	merged := make([]attribute.KeyValue, 0, len(l.attributes)+len(*attrs))
+	for _, kv := range l.attributes {
+		if _, ok := keys[kv.Key]; !ok {
+			merged = append(merged, kv)
+		}
+	}
+	merged = append(merged, *attrs...)
+	return &merged
+}
+
+// traceContext returns the trace context of logRecord emitted in ctx.
+//
+// A record with both a valid trace ID and span ID keeps them. Its trace flags
+// are the ones supplied, or, if none were, the ones of the span context of ctx
+// when it is the same span. A record with neither is correlated with the span
+// context of ctx, if any, as returned by spanContextFromContext. A record with
+// only one of them has no trace context rather than a malformed one.
+func traceContext(ctx context.Context, logRecord logs.LogRecord, spanContextFromContext func(context.Context) trace.SpanContext) (*trace.TraceID, *trace.SpanID, *trace.TraceFlags) {
+	traceId, spanId, traceFlags := logRecord.TraceId(), logRecord.SpanId(), logRecord.TraceFlags()
+	hasTraceId := traceId != nil && traceId.IsValid()
+	hasSpanId := spanId != nil && spanId.IsValid()
+
+	var sc trace.SpanContext
+	if ctx != nil {
+		sc = spanContextFromContext(ctx)
+	}
+
+	switch {
+	case hasTraceId && hasSpanId:
+		if traceFlags == nil && sc.TraceID() == *traceId && sc.SpanID() == *spanId {
+			tf := sc.TraceFlags()
+			traceFlags = &tf
+		}
+		return traceId, spanId, traceFlags
+	case !hasTraceId && !hasSpanId && sc.IsValid():
+		tid, sid, tf := sc.TraceID(), sc.SpanID(), sc.TraceFlags()
+		return &tid, &sid, &tf
+	default:
+		return nil, nil, nil
 	}
 }
 
@@ -82,6 +295,9 @@ type ReadableLogRecord interface {
 	SeverityText() *string
 	// SeverityNumber	Numerical value of the severityNumber.
 	SeverityNumber() *logs.SeverityNumber
+	// EventName Name identifying the record as a structured event, nil for
+	// free-text logs.
+	EventName() *string
 	// Body The body of the log record.
 	Body() any
 	// Resource 	Describes the source of the log.
@@ -91,6 +307,9 @@ type ReadableLogRecord interface {
 	InstrumentationScope() *instrumentation.Scope
 	// Attributes describe the aspects of the event.
 	Attributes() *[]attribute.KeyValue
+	// DroppedAttributes is the number of attributes removed from the record,
+	// e.g. by an AttributeFilterProcessor.
+	DroppedAttributes() int
 
 	// A private method to prevent users implementing the
 	// interface and so future additions to it will not
@@ -100,11 +319,22 @@ type ReadableLogRecord interface {
 
 type ReadWriteLogRecord interface {
 	SetResource(resource *resource.Resource)
+	SetEventName(eventName *string)
 	// RecordException message, stacktrace, type
 	RecordException(*string, *string, *string)
 	ReadableLogRecord
 }
 
+// MutableLogRecord is a ReadWriteLogRecord whose body and attributes can be
+// changed, passed to the hooks of WithLogRecordHook.
+type MutableLogRecord interface {
+	ReadWriteLogRecord
+	// SetBody replaces the body of the record.
+	SetBody(body any)
+	// AddAttributes appends attrs to the attributes of the record.
+	AddAttributes(attrs ...attribute.KeyValue)
+}
+
 // exportableLogRecord is an implementation of the OpenTelemetry Log API
 // representing the individual component of a log.
 type exportableLogRecord struct {
@@ -117,10 +347,21 @@ type exportableLogRecord struct {
 	traceFlags           *trace.TraceFlags
 	severityText         *string
 	severityNumber       *logs.SeverityNumber
+	eventName            *string
 	body                 any
 	resource             *resource.Resource
 	instrumentationScope *instrumentation.Scope
 	attributes           *[]attribute.KeyValue
+	droppedAttributes    int
+
+	// ctx is the context the record was emitted in, nil if none.
+	ctx context.Context
+
+	// pooled is true for the records of logRecordPool, refs is then the
+	// number of holders of the record and attrBuf backs its attributes.
+	pooled  bool
+	refs    atomic.Int32
+	attrBuf []attribute.KeyValue
 }
 
 // newReadWriteLogRecord create
@@ -156,6 +397,26 @@ func newReadWriteLogRecord(
 
 func (r *exportableLogRecord) SetResource(resource *resource.Resource) { r.resource = resource }
 
+func (r *exportableLogRecord) SetEventName(eventName *string) { r.eventName = eventName }
+
+func (r *exportableLogRecord) SetBody(body any) { r.body = body }
+
+// AddAttributes appends attrs to a copy of the attributes, which may be shared
+// with the emitted record, except for a pooled record owning its attributes.
+func (r *exportableLogRecord) AddAttributes(attrs ...attribute.KeyValue) {
+	if r.pooled {
+		r.attrBuf = append(r.attrBuf, attrs...)
+		r.attributes = &r.attrBuf
+		return
+	}
+	var merged []attribute.KeyValue
+	if r.attributes != nil {
+		merged = append(merged, *r.attributes...)
+	}
+	merged = append(merged, attrs...)
+	r.attributes = &merged
+}
+
 // RecordException helper to add Exception related information as attributes of Log Record
 // see https://opentelemetry.io/docs/specs/otel/logs/semantic_conventions/exceptions/#recording-an-exception
 func (r *exportableLogRecord) RecordException(message *string, stacktrace *string, exceptionType *string) {
@@ -174,6 +435,36 @@ func (r *exportableLogRecord) RecordException(message *string, stacktrace *strin
 	}
 }
 
+// recordContext returns the context rol was emitted in, or the background
+// context if it has none.
+func recordContext(rol ReadableLogRecord) context.Context {
+	if r, ok := rol.(*exportableLogRecord); ok && r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
+// cloneLogRecord returns a copy of rol with the attributes attrs, for the
+// processors passing a modified record to the next one.
+func cloneLogRecord(rol ReadableLogRecord, attrs *[]attribute.KeyValue) *exportableLogRecord {
+	return &exportableLogRecord{
+		timestamp:            rol.Timestamp(),
+		observedTimestamp:    rol.ObservedTimestamp(),
+		traceId:              rol.TraceId(),
+		spanId:               rol.SpanId(),
+		traceFlags:           rol.TraceFlags(),
+		severityText:         rol.SeverityText(),
+		severityNumber:       rol.SeverityNumber(),
+		eventName:            rol.EventName(),
+		body:                 rol.Body(),
+		resource:             rol.Resource(),
+		instrumentationScope: rol.InstrumentationScope(),
+		attributes:           attrs,
+		droppedAttributes:    rol.DroppedAttributes(),
+		ctx:                  recordContext(rol),
+	}
+}
+
 func (r *exportableLogRecord) Timestamp() *time.Time         { return r.timestamp }
 func (r *exportableLogRecord) ObservedTimestamp() time.Time  { return r.observedTimestamp }
 func (r *exportableLogRecord) TraceId() *trace.TraceID       { return r.traceId }
@@ -184,7 +475,9 @@ func (r *exportableLogRecord) InstrumentationScope() *instrumentation.Scope {
 }
 func (r *exportableLogRecord) SeverityText() *string                { return r.severityText }
 func (r *exportableLogRecord) SeverityNumber() *logs.SeverityNumber { return r.severityNumber }
+func (r *exportableLogRecord) EventName() *string                   { return r.eventName }
 func (r *exportableLogRecord) Body() any                            { return r.body }
 func (r *exportableLogRecord) Resource() *resource.Resource         { return r.resource }
 func (r *exportableLogRecord) Attributes() *[]attribute.KeyValue    { return r.attributes }
+func (r *exportableLogRecord) DroppedAttributes() int               { return r.droppedAttributes }
 func (r *exportableLogRecord) private()                             {}
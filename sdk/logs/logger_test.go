@@ -17,13 +17,17 @@ limitations under the License.
 package logs
 
 import (
+	"context"
+	"fmt"
 	"github.com/metoro-io/opentelemetry-logs-go/logs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	sdkresource "go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
 	"go.opentelemetry.io/otel/trace"
+	"strings"
 	"testing"
 	"time"
 )
@@ -69,3 +73,433 @@ func TestLogsReadWriteAPIFormat(t *testing.T) {
 	assert.Equal(t, "My Log Message", *(record.Body().(*string)))
 
 }
+
+func TestLoggerEmitEventName(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next))
+
+	eventName := "session.start"
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{EventName: &eventName}))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+	got := next.got()
+	assert.Len(t, got, 2)
+	assert.Equal(t, &eventName, got[0].EventName())
+	assert.Nil(t, got[1].EventName())
+
+	other := "session.end"
+	record := newReadWriteLogRecord(&trace.SpanContext{}, nil, nil, nil, nil, nil, nil, nil)
+	record.SetEventName(&other)
+	assert.Equal(t, &other, record.EventName())
+}
+
+func TestLoggerEmitTraceContext(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("80f198ee56343ba864fe8b2a57d3eff7")
+	spanID, _ := trace.SpanIDFromHex("2a00000000000000")
+	otherSpanID, _ := trace.SpanIDFromHex("2b00000000000000")
+	sampled := trace.FlagsSampled
+	unsampled := trace.TraceFlags(0)
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	tests := []struct {
+		name      string
+		config    logs.LogRecordConfig
+		wantTrace *trace.TraceID
+		wantSpan  *trace.SpanID
+		wantFlags *trace.TraceFlags
+	}{
+		{
+			name:      "valid with flags",
+			config:    logs.LogRecordConfig{TraceId: &traceID, SpanId: &spanID, TraceFlags: &unsampled, Context: spanCtx},
+			wantTrace: &traceID,
+			wantSpan:  &spanID,
+			wantFlags: &unsampled,
+		},
+		{
+			name:      "valid without flags",
+			config:    logs.LogRecordConfig{TraceId: &traceID, SpanId: &spanID},
+			wantTrace: &traceID,
+			wantSpan:  &spanID,
+		},
+		{
+			name:      "valid without flags defaulted from context",
+			config:    logs.LogRecordConfig{TraceId: &traceID, SpanId: &spanID, Context: spanCtx},
+			wantTrace: &traceID,
+			wantSpan:  &spanID,
+			wantFlags: &sampled,
+		},
+		{
+			name:      "valid without flags from another span",
+			config:    logs.LogRecordConfig{TraceId: &traceID, SpanId: &otherSpanID, Context: spanCtx},
+			wantTrace: &traceID,
+			wantSpan:  &otherSpanID,
+		},
+		{
+			name:      "absent correlated from context",
+			config:    logs.LogRecordConfig{Context: spanCtx},
+			wantTrace: &traceID,
+			wantSpan:  &spanID,
+			wantFlags: &sampled,
+		},
+		{
+			name:   "absent without span context",
+			config: logs.LogRecordConfig{TraceFlags: &sampled, Context: context.Background()},
+		},
+		{
+			name:   "span ID without trace ID",
+			config: logs.LogRecordConfig{SpanId: &spanID, TraceFlags: &sampled, Context: spanCtx},
+		},
+		{
+			name:   "trace ID without span ID",
+			config: logs.LogRecordConfig{TraceId: &traceID, TraceFlags: &sampled},
+		},
+		{
+			name:   "invalid trace ID",
+			config: logs.LogRecordConfig{TraceId: &trace.TraceID{}, SpanId: &spanID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingProcessor{}
+			lp := NewLoggerProvider(WithLogRecordProcessor(next))
+			lp.Logger("test").Emit(logs.NewLogRecord(tt.config))
+
+			got := next.got()
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.wantTrace, got[0].TraceId())
+			assert.Equal(t, tt.wantSpan, got[0].SpanId())
+			assert.Equal(t, tt.wantFlags, got[0].TraceFlags())
+		})
+	}
+}
+
+// legacyTraceKey is the context key of the trace context of a legacy
+// framework, holding the hexadecimal trace and span IDs.
+type legacyTraceKey struct{}
+
+func legacySpanContext(ctx context.Context) trace.SpanContext {
+	ids, _ := ctx.Value(legacyTraceKey{}).([2]string)
+	traceID, _ := trace.TraceIDFromHex(ids[0])
+	spanID, _ := trace.SpanIDFromHex(ids[1])
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+}
+
+func TestLoggerEmitSpanContextExtractor(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("80f198ee56343ba864fe8b2a57d3eff7")
+	spanID, _ := trace.SpanIDFromHex("2a00000000000000")
+	otherSpanID, _ := trace.SpanIDFromHex("2b00000000000000")
+	legacyCtx := context.WithValue(context.Background(), legacyTraceKey{}, [2]string{traceID.String(), spanID.String()})
+	// The standard span context is ignored by the extractor.
+	otelCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  otherSpanID,
+	}))
+
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next), WithSpanContextExtractor(legacySpanContext))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: legacyCtx}))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: otelCtx}))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{TraceId: &traceID, SpanId: &otherSpanID, Context: legacyCtx}))
+
+	got := next.got()
+	require.Len(t, got, 3)
+	sampled := trace.FlagsSampled
+	assert.Equal(t, &traceID, got[0].TraceId())
+	assert.Equal(t, &spanID, got[0].SpanId())
+	assert.Equal(t, &sampled, got[0].TraceFlags())
+	assert.Nil(t, got[1].TraceId(), "the context has no legacy trace context")
+	assert.Nil(t, got[1].SpanId())
+	assert.Equal(t, &otherSpanID, got[2].SpanId(), "the trace context of the record is kept")
+	assert.Nil(t, got[2].TraceFlags(), "the flags of another span are not used")
+
+	// Without the option, the standard span context is used.
+	next = &recordingProcessor{}
+	lp = NewLoggerProvider(WithLogRecordProcessor(next))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: legacyCtx}))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: otelCtx}))
+	got = next.got()
+	require.Len(t, got, 2)
+	assert.Nil(t, got[0].TraceId())
+	assert.Equal(t, &otherSpanID, got[1].SpanId())
+}
+
+func TestLoggerEmitStackTraceCapture(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next), WithStackTraceCapture(logs.ERROR))
+	l := lp.Logger("test")
+
+	info, errorSeverity, fatal := logs.INFO, logs.ERROR, logs.FATAL
+	existing := []attribute.KeyValue{semconv.ExceptionStacktrace("captured by the bridge")}
+	for _, config := range []logs.LogRecordConfig{
+		{SeverityNumber: &info},
+		{SeverityNumber: &errorSeverity},
+		{SeverityNumber: &fatal},
+		{},
+		{SeverityNumber: &errorSeverity, Attributes: &existing},
+	} {
+		l.Emit(logs.NewLogRecord(config))
+	}
+
+	got := next.got()
+	require.Len(t, got, 5)
+	assert.Nil(t, got[0].Attributes(), "no stack trace below the severity")
+	for _, r := range got[1:3] {
+		require.NotNil(t, r.Attributes())
+		require.Len(t, *r.Attributes(), 1)
+		kv := (*r.Attributes())[0]
+		assert.Equal(t, semconv.ExceptionStacktraceKey, kv.Key)
+		first, _, _ := strings.Cut(kv.Value.AsString(), "\n")
+		assert.Equal(t, "github.com/metoro-io/opentelemetry-logs-go/sdk/logs.TestLoggerEmitStackTraceCapture", first, "the SDK frames are skipped")
+		assert.Contains(t, kv.Value.AsString(), "logger_test.go:")
+	}
+	assert.Nil(t, got[3].Attributes(), "no stack trace without a severity")
+	assert.Equal(t, existing, *got[4].Attributes(), "the stack trace of the record is kept")
+
+	next = &recordingProcessor{}
+	lp = NewLoggerProvider(WithLogRecordProcessor(next))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{SeverityNumber: &fatal}))
+	require.Len(t, next.got(), 1)
+	assert.Nil(t, next.got()[0].Attributes(), "disabled by default")
+}
+
+func TestLoggerDefaultAttributes(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next))
+	l := lp.Logger("billing", logs.WithAttributes(
+		attribute.String("component", "billing"),
+		attribute.String("region", "eu"),
+	))
+
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+	attrs := []attribute.KeyValue{attribute.String("region", "us"), attribute.Int("invoice", 42)}
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Attributes: &attrs}))
+	lp.Logger("other").Emit(logs.NewLogRecord(logs.LogRecordConfig{Attributes: &attrs}))
+
+	got := next.got()
+	require.Len(t, got, 3)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("component", "billing"),
+		attribute.String("region", "eu"),
+	}, *got[0].Attributes())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("component", "billing"),
+		attribute.String("region", "us"),
+		attribute.Int("invoice", 42),
+	}, *got[1].Attributes())
+	assert.Equal(t, attrs, *got[2].Attributes())
+	assert.Len(t, attrs, 2, "the attributes of the caller are not modified")
+}
+
+func TestLoggerEmitObservedTimestamp(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next), withClock(func() time.Time { return now }))
+	l := lp.Logger("test")
+
+	observed := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{ObservedTimestamp: observed}))
+
+	got := next.got()
+	require.Len(t, got, 2)
+	assert.Equal(t, now, got[0].ObservedTimestamp())
+	assert.Equal(t, observed, got[1].ObservedTimestamp(), "supplied observed timestamps are kept")
+}
+
+// minSeverityProcessor is a FilterProcessor dropping records below min.
+type minSeverityProcessor struct {
+	recordingProcessor
+	min logs.SeverityNumber
+}
+
+func (p *minSeverityProcessor) Enabled(_ context.Context, severity logs.SeverityNumber) bool {
+	return severity >= p.min
+}
+
+func TestLoggerEnabled(t *testing.T) {
+	ctx := context.Background()
+
+	lp := NewLoggerProvider(WithLogRecordProcessor(&minSeverityProcessor{min: logs.WARN}))
+	l := lp.Logger("test").(logs.EnabledLogger)
+	assert.False(t, l.Enabled(ctx, logs.DEBUG))
+	assert.False(t, l.Enabled(ctx, logs.INFO4))
+	assert.True(t, l.Enabled(ctx, logs.WARN))
+	assert.True(t, l.Enabled(ctx, logs.ERROR))
+
+	// Any processor processing the record enables it.
+	lp = NewLoggerProvider(
+		WithLogRecordProcessor(&minSeverityProcessor{min: logs.WARN}),
+		WithLogRecordProcessor(&minSeverityProcessor{min: logs.INFO}),
+	)
+	assert.True(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.INFO))
+	assert.False(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.DEBUG))
+
+	// Processors not filtering records process all of them.
+	lp = NewLoggerProvider(WithLogRecordProcessor(&recordingProcessor{}))
+	assert.True(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.TRACE))
+
+	// Filtering is threaded through the sampling processor.
+	lp = NewLoggerProvider(WithLogRecordProcessor(
+		NewSamplingProcessor(&minSeverityProcessor{min: logs.ERROR}, SeverityThreshold(logs.INFO)),
+	))
+	assert.False(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.DEBUG))
+	assert.False(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.WARN))
+	assert.True(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.ERROR))
+}
+
+func TestLoggerEnabledWithoutProcessors(t *testing.T) {
+	ctx := context.Background()
+
+	lp := NewLoggerProvider()
+	assert.False(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.ERROR))
+
+	lp = NewLoggerProvider(WithLogRecordProcessor(&recordingProcessor{}))
+	l := lp.Logger("test").(logs.EnabledLogger)
+	require.NoError(t, lp.Shutdown(ctx))
+	assert.False(t, l.Enabled(ctx, logs.ERROR))
+	assert.False(t, lp.Logger("test").(logs.EnabledLogger).Enabled(ctx, logs.ERROR))
+}
+
+func TestLoggerEmitBatch(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("80f198ee56343ba864fe8b2a57d3eff7")
+	spanID, _ := trace.SpanIDFromHex("2a00000000000000")
+	otherSpanID, _ := trace.SpanIDFromHex("2b00000000000000")
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	otherCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  otherSpanID,
+	}))
+
+	exp := &batchRecordingExporter{}
+	next, tenant := &recordingProcessor{}, &recordingProcessor{}
+	lp := NewLoggerProvider(
+		WithLogRecordProcessor(NewSimpleLogRecordProcessor(exp)),
+		WithLogRecordProcessor(next),
+		WithTenantRoute("acme", tenant),
+		WithMinSeverity(logs.INFO),
+	)
+	l := lp.Logger("ingest").(logs.BatchLogger)
+
+	info, debug := logs.INFO, logs.DEBUG
+	var records []logs.LogRecord
+	var want []string
+	for i := 0; i < 5; i++ {
+		body := fmt.Sprintf("line %d", i)
+		config := logs.LogRecordConfig{Body: &body, SeverityNumber: &info}
+		if i == 2 {
+			config.Context = otherCtx
+		}
+		records = append(records, logs.NewLogRecord(config))
+		want = append(want, body)
+	}
+	dropped := "dropped below the minimum severity"
+	records = append(records[:3], append([]logs.LogRecord{logs.NewLogRecord(logs.LogRecordConfig{Body: &dropped, SeverityNumber: &debug})}, records[3:]...)...)
+	tenantBody := "routed to the tenant"
+	records = append(records, logs.NewLogRecord(logs.LogRecordConfig{Body: &tenantBody, Context: ContextWithTenant(context.Background(), "acme")}))
+	l.EmitBatch(spanCtx, records)
+
+	bodies := func(records []ReadableLogRecord) []string {
+		var got []string
+		for _, r := range records {
+			got = append(got, r.Body().(string))
+		}
+		return got
+	}
+	require.Len(t, exp.exported(), 1, "the records are exported at once")
+	assert.Equal(t, want, bodies(exp.exported()[0]))
+	got := next.got()
+	assert.Equal(t, want, bodies(got), "the records are passed one by one to OnEmit")
+	for i, r := range got {
+		wantSpan := spanID
+		if i == 2 {
+			wantSpan = otherSpanID
+		}
+		require.NotNil(t, r.SpanId())
+		assert.Equal(t, wantSpan, *r.SpanId(), "the records without a context are emitted in ctx")
+	}
+	assert.Equal(t, []string{tenantBody}, bodies(tenant.got()))
+
+	require.NoError(t, lp.Shutdown(context.Background()))
+	l.EmitBatch(context.Background(), records)
+	assert.Len(t, next.got(), len(want), "records emitted after shutdown are dropped")
+}
+
+func TestLoggerEmitBatchStackTraceCapture(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next), WithStackTraceCapture(logs.ERROR))
+	errorSeverity := logs.ERROR
+	lp.Logger("test").(logs.BatchLogger).EmitBatch(context.Background(), []logs.LogRecord{logs.NewLogRecord(logs.LogRecordConfig{SeverityNumber: &errorSeverity})})
+
+	got := next.got()
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Attributes())
+	require.Len(t, *got[0].Attributes(), 1)
+	first, _, _ := strings.Cut((*got[0].Attributes())[0].Value.AsString(), "\n")
+	assert.Equal(t, "github.com/metoro-io/opentelemetry-logs-go/sdk/logs.TestLoggerEmitBatchStackTraceCapture", first)
+}
+
+// discardExporter drops the records it exports.
+type discardExporter struct{}
+
+func (discardExporter) Export(context.Context, []ReadableLogRecord) error { return nil }
+func (discardExporter) Shutdown(context.Context) error                    { return nil }
+
+// BenchmarkLoggerEmitBatch compares the concurrent emission of batches of
+// records with Emit, which locks the exporter of a simple processor for each
+// record, and with EmitBatch, which locks it once per batch.
+func BenchmarkLoggerEmitBatch(b *testing.B) {
+	const size = 64
+	body := "request served"
+	attrs := []attribute.KeyValue{attribute.String("http.method", "GET"), attribute.Int("http.status_code", 200)}
+	records := make([]logs.LogRecord, size)
+	for i := range records {
+		records[i] = logs.NewLogRecord(logs.LogRecordConfig{Body: &body, Attributes: &attrs})
+	}
+
+	for _, bm := range []struct {
+		name string
+		emit func(l logs.Logger)
+	}{
+		{name: "Emit", emit: func(l logs.Logger) {
+			for _, r := range records {
+				l.Emit(r)
+			}
+		}},
+		{name: "EmitBatch", emit: func(l logs.Logger) {
+			l.(logs.BatchLogger).EmitBatch(context.Background(), records)
+		}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			lp := NewLoggerProvider(WithLogRecordProcessor(NewSimpleLogRecordProcessor(discardExporter{})))
+			l := lp.Logger("bench")
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					bm.emit(l)
+				}
+			})
+		})
+	}
+}
+
+func TestLoggerEmitRecordContext(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next))
+	ctx := context.WithValue(context.Background(), legacyTraceKey{}, "acme")
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: ctx}))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+	got := next.got()
+	require.Len(t, got, 2)
+	assert.Equal(t, ctx, recordContext(got[0]))
+	assert.Equal(t, context.Background(), recordContext(got[1]))
+}
@@ -35,10 +35,12 @@ type LogRecordStub struct {
 	TraceFlags           *trace.TraceFlags
 	SeverityText         *string
 	SeverityNumber       *logs.SeverityNumber
+	EventName            *string
 	Body                 any
 	Resource             *resource.Resource
 	InstrumentationScope *instrumentation.Scope
 	Attributes           *[]attribute.KeyValue
+	DroppedAttributes    int
 }
 
 // LogRecordStubFromReadableLogRecord returns a LogRecordStub populated from rl.
@@ -54,10 +56,12 @@ func LogRecordStubFromReadableLogRecord(rl logssdk.ReadableLogRecord) LogRecordS
 		TraceFlags:           rl.TraceFlags(),
 		SeverityText:         rl.SeverityText(),
 		SeverityNumber:       rl.SeverityNumber(),
+		EventName:            rl.EventName(),
 		Body:                 rl.Body(),
 		Resource:             rl.Resource(),
 		InstrumentationScope: rl.InstrumentationScope(),
 		Attributes:           rl.Attributes(),
+		DroppedAttributes:    rl.DroppedAttributes(),
 	}
 }
 
@@ -71,10 +75,12 @@ func (s LogRecordStub) Snapshot() logssdk.ReadableLogRecord {
 		traceFlags:           s.TraceFlags,
 		severityText:         s.SeverityText,
 		severityNumber:       s.SeverityNumber,
+		eventName:            s.EventName,
 		body:                 s.Body,
 		resource:             s.Resource,
 		instrumentationScope: s.InstrumentationScope,
 		attributes:           s.Attributes,
+		droppedAttributes:    s.DroppedAttributes,
 	}
 }
 
@@ -88,10 +94,12 @@ type logRecordSnapshot struct {
 	traceFlags           *trace.TraceFlags
 	severityText         *string
 	severityNumber       *logs.SeverityNumber
+	eventName            *string
 	body                 any
 	resource             *resource.Resource
 	instrumentationScope *instrumentation.Scope
 	attributes           *[]attribute.KeyValue
+	droppedAttributes    int
 }
 
 func (r *logRecordSnapshot) Timestamp() *time.Time         { return r.timestamp }
@@ -104,6 +112,8 @@ func (r *logRecordSnapshot) InstrumentationScope() *instrumentation.Scope {
 }
 func (r *logRecordSnapshot) SeverityText() *string                { return r.severityText }
 func (r *logRecordSnapshot) SeverityNumber() *logs.SeverityNumber { return r.severityNumber }
+func (r *logRecordSnapshot) EventName() *string                   { return r.eventName }
 func (r *logRecordSnapshot) Body() any                            { return r.body }
 func (r *logRecordSnapshot) Resource() *resource.Resource         { return r.resource }
 func (r *logRecordSnapshot) Attributes() *[]attribute.KeyValue    { return r.attributes }
+func (r *logRecordSnapshot) DroppedAttributes() int               { return r.droppedAttributes }
@@ -0,0 +1,65 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"sync/atomic"
+	"time"
+)
+
+// monotonicClamp clamps the observed timestamps of the logs passed to it to be
+// no earlier than the latest one it has seen, see WithObservedTimestampMonotonic.
+type monotonicClamp struct {
+	// latest is the latest observed timestamp, in nanoseconds since the Unix
+	// epoch.
+	latest atomic.Int64
+}
+
+// clamp returns rol, or a copy of it with the latest observed timestamp seen
+// if its own is earlier. The logs without an observed timestamp are returned
+// unchanged.
+func (c *monotonicClamp) clamp(rol ReadableLogRecord) ReadableLogRecord {
+	observed := rol.ObservedTimestamp()
+	if observed.IsZero() {
+		return rol
+	}
+	ts := observed.UnixNano()
+	for {
+		latest := c.latest.Load()
+		if ts < latest {
+			return withObservedTimestamp(rol, time.Unix(0, latest))
+		}
+		if c.latest.CompareAndSwap(latest, ts) {
+			return rol
+		}
+	}
+}
+
+// withObservedTimestamp returns a copy of rol with the observed timestamp ts.
+// The attributes are copied too, as the ones of a pooled log are reused once
+// it is released.
+func withObservedTimestamp(rol ReadableLogRecord, ts time.Time) ReadableLogRecord {
+	var attrs *[]attribute.KeyValue
+	if a := rol.Attributes(); a != nil {
+		copied := append([]attribute.KeyValue(nil), *a...)
+		attrs = &copied
+	}
+	r := cloneLogRecord(rol, attrs)
+	r.observedTimestamp = ts
+	return r
+}
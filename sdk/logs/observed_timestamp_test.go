@@ -0,0 +1,92 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"testing"
+	"time"
+)
+
+func TestBatchLogRecordProcessorObservedTimestampMonotonic(t *testing.T) {
+	start := time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)
+	// The clock steps back by a second after the second log.
+	steps := []time.Duration{0, 2 * time.Second, time.Second, 1500 * time.Millisecond, 3 * time.Second}
+
+	for _, monotonic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("monotonic=%t", monotonic), func(t *testing.T) {
+			var options []BatchLogRecordProcessorOption
+			if monotonic {
+				options = append(options, WithObservedTimestampMonotonic())
+			}
+			exp := &batchRecordingExporter{}
+			lrp := NewBatchLogRecordProcessor(exp, options...)
+			var now time.Time
+			lp := NewLoggerProvider(
+				WithLogRecordProcessor(lrp),
+				withClock(func() time.Time { return now }),
+			)
+			l := lp.Logger("test")
+			for i, step := range steps {
+				now = start.Add(step)
+				attrs := []attribute.KeyValue{attribute.Int("index", i)}
+				l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Attributes: &attrs}))
+			}
+			require.NoError(t, lp.ForceFlush(context.Background()))
+			require.NoError(t, lp.Shutdown(context.Background()))
+
+			var got []time.Duration
+			for _, batch := range exp.exported() {
+				for _, r := range batch {
+					assert.Equal(t, []attribute.KeyValue{attribute.Int("index", len(got))}, *r.Attributes())
+					got = append(got, r.ObservedTimestamp().Sub(start))
+				}
+			}
+			if monotonic {
+				assert.Equal(t, []time.Duration{0, 2 * time.Second, 2 * time.Second, 2 * time.Second, 3 * time.Second}, got)
+			} else {
+				assert.Equal(t, steps, got)
+			}
+		})
+	}
+}
+
+func TestWithObservedTimestamp(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("key", "value")}
+	body := "body"
+	rol := &exportableLogRecord{body: body, attributes: &attrs, droppedAttributes: 2, observedTimestamp: time.Unix(5, 0)}
+
+	got := withObservedTimestamp(rol, time.Unix(10, 0))
+	attrs[0] = attribute.String("key", "reused")
+	assert.Equal(t, time.Unix(10, 0), got.ObservedTimestamp())
+	assert.Equal(t, body, got.Body())
+	assert.Equal(t, 2, got.DroppedAttributes())
+	assert.Equal(t, []attribute.KeyValue{attribute.String("key", "value")}, *got.Attributes(), "the attributes are copied")
+	assert.Nil(t, withObservedTimestamp(&exportableLogRecord{}, time.Unix(10, 0)).Attributes())
+}
+
+func TestMonotonicClampZeroObservedTimestamp(t *testing.T) {
+	var c monotonicClamp
+	c.clamp(&exportableLogRecord{observedTimestamp: time.Unix(10, 0)})
+	rol := &exportableLogRecord{}
+	assert.Same(t, rol, c.clamp(rol), "a log without an observed timestamp is unchanged")
+}
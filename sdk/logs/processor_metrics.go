@@ -0,0 +1,208 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"time"
+)
+
+// meterName is the name of the meter of the metrics of the SDK about itself.
+const meterName = "github.com/metoro-io/opentelemetry-logs-go/sdk/logs"
+
+// processorNameKey is the key of the attribute naming the batch processor on
+// its metrics, set if it has a name, see WithProcessorName.
+const processorNameKey = attribute.Key("processor.name")
+
+// Reasons a log record is dropped by the batch processor, the value of the
+// reason attribute of the dropped counter.
+var (
+	droppedQueueFull    = attribute.String("reason", DroppedReasonQueueFull)
+	droppedExportFailed = attribute.String("reason", DroppedReasonExportFailed)
+	droppedInvalid      = attribute.String("reason", DroppedReasonInvalid)
+	droppedShutdown     = attribute.String("reason", DroppedReasonShutdown)
+)
+
+// Outcomes of an export, the value of the outcome attribute of the export
+// duration histogram.
+var (
+	exportSucceeded = attribute.String("outcome", "success")
+	exportFailed    = attribute.String("outcome", "failure")
+)
+
+// measurementAttributes returns the attributes of a measurement of the
+// processor named name, kvs and the name of the processor if it has one.
+func measurementAttributes(name string, kvs ...attribute.KeyValue) metric.MeasurementOption {
+	if name != "" {
+		kvs = append(kvs, processorNameKey.String(name))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(kvs...))
+}
+
+// processorMetrics counts the log records exported and dropped by a batch
+// processor and measures the duration of its exports. A nil *processorMetrics
+// records nothing, so that the processor pays nothing for the metrics unless
+// they are enabled.
+type processorMetrics struct {
+	exported metric.Int64Counter
+	dropped  metric.Int64Counter
+	duration metric.Float64Histogram
+
+	// attributeCount and attributeValueLength are nil unless the attribute
+	// metrics are enabled.
+	attributeCount       metric.Int64Histogram
+	attributeValueLength metric.Int64Histogram
+
+	// The attributes of the measurements, computed once as they only depend
+	// on the name of the processor.
+	attrs               metric.MeasurementOption
+	droppedQueueFull    metric.MeasurementOption
+	droppedExportFailed metric.MeasurementOption
+	droppedInvalid      metric.MeasurementOption
+	droppedShutdown     metric.MeasurementOption
+	exportSucceeded     metric.MeasurementOption
+	exportFailed        metric.MeasurementOption
+}
+
+// newProcessorMetrics returns the metrics of a batch processor sent to mp, or
+// nil if mp is nil. The distributions of the attributes of the emitted log
+// records are only measured if attributes is true. The measurements have a
+// processor.name attribute if name is not empty.
+func newProcessorMetrics(mp metric.MeterProvider, attributes bool, name string) *processorMetrics {
+	if mp == nil {
+		return nil
+	}
+	meter := mp.Meter(meterName)
+	// The errors creating the instruments are reported to the global error
+	// handler by the meter, which then returns working no-op instruments.
+	exported, _ := meter.Int64Counter("otel.sdk.logs.processor.exported",
+		metric.WithDescription("The number of log records successfully exported by the batch processor."),
+		metric.WithUnit("{log_record}"))
+	dropped, _ := meter.Int64Counter("otel.sdk.logs.processor.dropped",
+		metric.WithDescription("The number of log records dropped by the batch processor, because its queue is full, their export failed or they are invalid."),
+		metric.WithUnit("{log_record}"))
+	duration, _ := meter.Float64Histogram("otel.sdk.logs.export.duration",
+		metric.WithDescription("The duration of the exports of the batch processor."),
+		metric.WithUnit("s"))
+	m := &processorMetrics{
+		exported:            exported,
+		dropped:             dropped,
+		duration:            duration,
+		attrs:               measurementAttributes(name),
+		droppedQueueFull:    measurementAttributes(name, droppedQueueFull),
+		droppedExportFailed: measurementAttributes(name, droppedExportFailed),
+		droppedInvalid:      measurementAttributes(name, droppedInvalid),
+		droppedShutdown:     measurementAttributes(name, droppedShutdown),
+		exportSucceeded:     measurementAttributes(name, exportSucceeded),
+		exportFailed:        measurementAttributes(name, exportFailed),
+	}
+	if attributes {
+		m.attributeCount, _ = meter.Int64Histogram("otel.sdk.logs.record.attributes",
+			metric.WithDescription("The number of attributes of the log records emitted to the batch processor."),
+			metric.WithUnit("{attribute}"))
+		m.attributeValueLength, _ = meter.Int64Histogram("otel.sdk.logs.attribute.value.length",
+			metric.WithDescription("The length of the string and slice attribute values of the log records emitted to the batch processor."),
+			metric.WithUnit("{element}"))
+	}
+	return m
+}
+
+// recordExport records the result and the duration of the export of n log
+// records.
+func (m *processorMetrics) recordExport(ctx context.Context, n int, duration time.Duration, err error) {
+	if m == nil || n == 0 {
+		return
+	}
+	if err != nil {
+		m.duration.Record(ctx, duration.Seconds(), m.exportFailed)
+		m.dropped.Add(ctx, int64(n), m.droppedExportFailed)
+		return
+	}
+	m.duration.Record(ctx, duration.Seconds(), m.exportSucceeded)
+	m.exported.Add(ctx, int64(n), m.attrs)
+}
+
+// recordQueueFull records a log record dropped because the queue is full.
+func (m *processorMetrics) recordQueueFull(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.dropped.Add(ctx, 1, m.droppedQueueFull)
+}
+
+// recordInvalid records a log record dropped because it is invalid.
+func (m *processorMetrics) recordInvalid(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.dropped.Add(ctx, 1, m.droppedInvalid)
+}
+
+// recordShutdown records n log records dropped because the processor is shut
+// down.
+func (m *processorMetrics) recordShutdown(ctx context.Context, n int) {
+	if m == nil {
+		return
+	}
+	m.dropped.Add(ctx, int64(n), m.droppedShutdown)
+}
+
+// recordAttributes records the number of attributes of rol and the length of
+// its string and slice attribute values, if the attribute metrics are enabled.
+func (m *processorMetrics) recordAttributes(ctx context.Context, rol ReadableLogRecord) {
+	if m == nil || m.attributeCount == nil {
+		return
+	}
+	attrs := rol.Attributes()
+	if attrs == nil {
+		m.attributeCount.Record(ctx, 0, m.attrs)
+		return
+	}
+	m.attributeCount.Record(ctx, int64(len(*attrs)), m.attrs)
+	for _, kv := range *attrs {
+		if n, ok := attributeValueLength(kv.Value); ok {
+			m.attributeValueLength.Record(ctx, int64(n), m.attrs)
+		}
+	}
+}
+
+// attributeValueLength returns the length in bytes of a string value, the sum
+// of the lengths of the strings of a string slice, and the number of elements
+// of the other slices. It returns false for the other values, whose size is
+// fixed.
+func attributeValueLength(v attribute.Value) (int, bool) {
+	switch v.Type() {
+	case attribute.STRING:
+		return len(v.AsString()), true
+	case attribute.STRINGSLICE:
+		var n int
+		for _, s := range v.AsStringSlice() {
+			n += len(s)
+		}
+		return n, true
+	case attribute.BOOLSLICE:
+		return len(v.AsBoolSlice()), true
+	case attribute.INT64SLICE:
+		return len(v.AsInt64Slice()), true
+	case attribute.FLOAT64SLICE:
+		return len(v.AsFloat64Slice()), true
+	default:
+		return 0, false
+	}
+}
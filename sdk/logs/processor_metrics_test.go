@@ -0,0 +1,329 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMeterProvider sums the increments of the counters of its meters by
+// counter name and reason attribute, and keeps the measurements of its
+// histograms by histogram name and outcome attribute. The keys end with
+// "@" and the processor.name attribute if it is set.
+type recordingMeterProvider struct {
+	noop.MeterProvider
+
+	mu           sync.Mutex
+	counts       map[string]int64
+	measurements map[string][]float64
+}
+
+func newRecordingMeterProvider() *recordingMeterProvider {
+	return &recordingMeterProvider{counts: map[string]int64{}, measurements: map[string][]float64{}}
+}
+
+func (mp *recordingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return recordingMeter{mp: mp}
+}
+
+func (mp *recordingMeterProvider) got() map[string]int64 {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	got := make(map[string]int64, len(mp.counts))
+	for k, v := range mp.counts {
+		got[k] = v
+	}
+	return got
+}
+
+func (mp *recordingMeterProvider) gotMeasurements() map[string][]float64 {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	got := make(map[string][]float64, len(mp.measurements))
+	for k, v := range mp.measurements {
+		got[k] = append([]float64(nil), v...)
+	}
+	return got
+}
+
+type recordingMeter struct {
+	noop.Meter
+	mp *recordingMeterProvider
+}
+
+func (m recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return recordingCounter{name: name, mp: m.mp}, nil
+}
+
+func (m recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return recordingHistogram{name: name, mp: m.mp}, nil
+}
+
+func (m recordingMeter) Int64Histogram(name string, _ ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return recordingInt64Histogram{name: name, mp: m.mp}, nil
+}
+
+type recordingCounter struct {
+	noop.Int64Counter
+	name string
+	mp   *recordingMeterProvider
+}
+
+func (c recordingCounter) Add(_ context.Context, incr int64, opts ...metric.AddOption) {
+	key := c.name
+	attrs := metric.NewAddConfig(opts).Attributes()
+	if reason, ok := attrs.Value("reason"); ok {
+		key += "/" + reason.AsString()
+	}
+	key += processorNameSuffix(attrs)
+	c.mp.mu.Lock()
+	defer c.mp.mu.Unlock()
+	c.mp.counts[key] += incr
+}
+
+type recordingHistogram struct {
+	noop.Float64Histogram
+	name string
+	mp   *recordingMeterProvider
+}
+
+func (h recordingHistogram) Record(_ context.Context, value float64, opts ...metric.RecordOption) {
+	key := h.name
+	attrs := metric.NewRecordConfig(opts).Attributes()
+	if outcome, ok := attrs.Value("outcome"); ok {
+		key += "/" + outcome.AsString()
+	}
+	key += processorNameSuffix(attrs)
+	h.mp.mu.Lock()
+	defer h.mp.mu.Unlock()
+	h.mp.measurements[key] = append(h.mp.measurements[key], value)
+}
+
+type recordingInt64Histogram struct {
+	noop.Int64Histogram
+	name string
+	mp   *recordingMeterProvider
+}
+
+func (h recordingInt64Histogram) Record(_ context.Context, value int64, opts ...metric.RecordOption) {
+	key := h.name + processorNameSuffix(metric.NewRecordConfig(opts).Attributes())
+	h.mp.mu.Lock()
+	defer h.mp.mu.Unlock()
+	h.mp.measurements[key] = append(h.mp.measurements[key], float64(value))
+}
+
+// processorNameSuffix returns the suffix of the recording keys of the
+// measurements with the attributes attrs.
+func processorNameSuffix(attrs attribute.Set) string {
+	if name, ok := attrs.Value(processorNameKey); ok {
+		return "@" + name.AsString()
+	}
+	return ""
+}
+
+func TestBatchLogRecordProcessorMeterProvider(t *testing.T) {
+	mp := newRecordingMeterProvider()
+	exp := newGatedExporter()
+	lrp := NewBatchLogRecordProcessor(exp,
+		WithMaxQueueSize(1),
+		WithMaxExportBatchSize(1),
+		WithMeterProvider(mp),
+	)
+
+	lrp.OnEmit(testLogRecord("first"))
+	<-exp.started
+	lrp.OnEmit(testLogRecord("second"))
+	lrp.OnEmit(testLogRecord("third"))
+
+	close(exp.release)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, map[string]int64{
+		"otel.sdk.logs.processor.exported":           2,
+		"otel.sdk.logs.processor.dropped/queue_full": 1,
+	}, mp.got())
+}
+
+func TestBatchLogRecordProcessorMeterProviderExportFailed(t *testing.T) {
+	mp := newRecordingMeterProvider()
+	lrp := NewBatchLogRecordProcessor(failingExporter{err: errors.New("collector unavailable")},
+		WithMeterProvider(mp),
+		WithErrorHandler(func(error) {}),
+	)
+
+	lrp.OnEmit(testLogRecord("first"))
+	lrp.OnEmit(testLogRecord("second"))
+	assert.Error(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, map[string]int64{"otel.sdk.logs.processor.dropped/export_failed": 2}, mp.got())
+}
+
+func TestBatchLogRecordProcessorMeterProviderShutdown(t *testing.T) {
+	mp := newRecordingMeterProvider()
+	lrp := NewBatchLogRecordProcessor(&batchRecordingExporter{},
+		WithBatchTimeout(time.Hour),
+		WithExportOnShutdown(false),
+		WithMeterProvider(mp),
+	)
+
+	lrp.OnEmit(testLogRecord("first"))
+	lrp.OnEmit(testLogRecord("second"))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	lrp.OnEmit(testLogRecord("third"))
+	lrp.(BulkProcessor).OnEmitBatch([]ReadableLogRecord{testLogRecord("fourth"), testLogRecord("fifth")})
+	assert.Equal(t, map[string]int64{"otel.sdk.logs.processor.dropped/shutdown": 5}, mp.got())
+}
+
+func TestBatchLogRecordProcessorName(t *testing.T) {
+	mp := newRecordingMeterProvider()
+	tenantA := NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithMeterProvider(mp), WithProcessorName("tenant-a"), WithAttributeMetrics())
+	tenantB := NewBatchLogRecordProcessor(failingExporter{err: errors.New("collector unavailable")},
+		WithMeterProvider(mp),
+		WithProcessorName("tenant-b"),
+		WithErrorHandler(func(error) {}),
+	)
+	anonymous := NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithMeterProvider(mp))
+
+	tenantA.OnEmit(testLogRecord("first"))
+	tenantA.OnEmit(testLogRecord("second"))
+	tenantB.OnEmit(testLogRecord("third"))
+	anonymous.OnEmit(testLogRecord("fourth"))
+	for _, lrp := range []LogRecordProcessor{tenantA, tenantB, anonymous} {
+		_ = lrp.ForceFlush(context.Background())
+		require.NoError(t, lrp.Shutdown(context.Background()))
+	}
+
+	assert.Equal(t, map[string]int64{
+		"otel.sdk.logs.processor.exported@tenant-a":              2,
+		"otel.sdk.logs.processor.dropped/export_failed@tenant-b": 1,
+		"otel.sdk.logs.processor.exported":                       1,
+	}, mp.got())
+	measurements := mp.gotMeasurements()
+	assert.Len(t, measurements["otel.sdk.logs.export.duration/success@tenant-a"], 1)
+	assert.Len(t, measurements["otel.sdk.logs.export.duration/failure@tenant-b"], 1)
+	assert.Len(t, measurements["otel.sdk.logs.export.duration/success"], 1)
+	assert.Equal(t, []float64{0, 0}, measurements["otel.sdk.logs.record.attributes@tenant-a"])
+}
+
+func TestBatchLogRecordProcessorSelfMetricsEnv(t *testing.T) {
+	for _, value := range []string{"", "false", "true", "TRUE"} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("OTEL_GO_X_LOGS_SELF_METRICS", value)
+			mp := newRecordingMeterProvider()
+			global := otel.GetMeterProvider()
+			otel.SetMeterProvider(mp)
+			t.Cleanup(func() { otel.SetMeterProvider(global) })
+
+			exp := &batchRecordingExporter{}
+			lrp := NewBatchLogRecordProcessor(exp).(*batchLogRecordProcessor)
+			lrp.OnEmit(testLogRecord("first"))
+			require.NoError(t, lrp.ForceFlush(context.Background()))
+			require.NoError(t, lrp.Shutdown(context.Background()))
+
+			if value == "" || value == "false" {
+				assert.Nil(t, lrp.metrics, "no metrics are recorded when disabled")
+				assert.Empty(t, mp.got())
+				return
+			}
+			assert.Equal(t, map[string]int64{"otel.sdk.logs.processor.exported": 1}, mp.got())
+		})
+	}
+}
+
+func TestProcessorMetricsNil(t *testing.T) {
+	var m *processorMetrics
+	assert.Nil(t, newProcessorMetrics(nil, true, ""))
+	assert.NotPanics(t, func() {
+		m.recordExport(context.Background(), 1, time.Second, nil)
+		m.recordQueueFull(context.Background())
+		m.recordAttributes(context.Background(), testLogRecord("first"))
+	})
+}
+
+func TestBatchLogRecordProcessorExportDuration(t *testing.T) {
+	mp := newRecordingMeterProvider()
+	exp := &batchRecordingExporter{}
+	lrp := NewBatchLogRecordProcessor(exp, WithMeterProvider(mp))
+
+	lrp.OnEmit(testLogRecord("first"))
+	require.NoError(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	got := mp.gotMeasurements()
+	require.Len(t, got["otel.sdk.logs.export.duration/success"], 1)
+	assert.GreaterOrEqual(t, got["otel.sdk.logs.export.duration/success"][0], 0.0)
+	assert.Empty(t, got["otel.sdk.logs.export.duration/failure"])
+
+	mp = newRecordingMeterProvider()
+	lrp = NewBatchLogRecordProcessor(failingExporter{err: errors.New("collector unavailable")},
+		WithMeterProvider(mp),
+		WithErrorHandler(func(error) {}),
+	)
+	lrp.OnEmit(testLogRecord("first"))
+	assert.Error(t, lrp.ForceFlush(context.Background()))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Len(t, mp.gotMeasurements()["otel.sdk.logs.export.duration/failure"], 1)
+}
+
+func TestBatchLogRecordProcessorAttributeMetrics(t *testing.T) {
+	body := "body"
+	attrs := []attribute.KeyValue{
+		attribute.String("payload", strings.Repeat("x", 1000)),
+		attribute.StringSlice("tags", []string{"a", "bc"}),
+		attribute.Int64Slice("ids", []int64{1, 2, 3}),
+		attribute.Int("count", 1),
+	}
+	record := &exportableLogRecord{body: &body, attributes: &attrs, observedTimestamp: time.Now()}
+
+	mp := newRecordingMeterProvider()
+	lrp := NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithMeterProvider(mp), WithAttributeMetrics())
+	lrp.OnEmit(record)
+	lrp.OnEmit(testLogRecord("no attributes"))
+	require.NoError(t, lrp.Shutdown(context.Background()))
+
+	got := mp.gotMeasurements()
+	assert.Equal(t, []float64{4, 0}, got["otel.sdk.logs.record.attributes"])
+	assert.Equal(t, []float64{1000, 3, 3}, got["otel.sdk.logs.attribute.value.length"])
+}
+
+func TestBatchLogRecordProcessorAttributeMetricsDisabled(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("payload", "value")}
+	record := &exportableLogRecord{attributes: &attrs, observedTimestamp: time.Now()}
+
+	mp := newRecordingMeterProvider()
+	lrp := NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithMeterProvider(mp))
+	lrp.OnEmit(record)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	got := mp.gotMeasurements()
+	assert.NotContains(t, got, "otel.sdk.logs.record.attributes")
+	assert.NotContains(t, got, "otel.sdk.logs.attribute.value.length")
+
+	// Without a meter provider, the option alone records nothing.
+	lrp = NewBatchLogRecordProcessor(&batchRecordingExporter{}, WithAttributeMetrics())
+	assert.Nil(t, lrp.(*batchLogRecordProcessor).metrics)
+	require.NoError(t, lrp.Shutdown(context.Background()))
+}
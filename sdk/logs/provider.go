@@ -18,25 +18,61 @@ package logs // Package logs import "github.com/metoro-io/opentelemetry-logs-go/
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/metoro-io/opentelemetry-logs-go/internal/global"
 	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/metoro-io/opentelemetry-logs-go/sdk/internal/env"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
 	defaultLoggerName = "github.com/metoro-io/opentelemetry-logs-go/sdk/logs/provider"
 )
 
+var errEmitAfterShutdown = errors.New("log record emitted after LoggerProvider shutdown, dropping it")
+
 // loggerProviderConfig Configuration for Logger Provider
 type loggerProviderConfig struct {
 	processors []LogRecordProcessor
+	// tenantRoutes are the processors used instead of processors for records
+	// emitted with a matching tenant routing key.
+	tenantRoutes map[string][]LogRecordProcessor
 	// resource contains attributes representing an entity that produces telemetry.
 	resource *resource.Resource
+	// serviceName is the service.name of the resource, unless the resource
+	// passed to WithResource has one other than unknown_service as
+	// hasServiceName reports.
+	serviceName    string
+	hasServiceName bool
+	// resourceAttributes are merged into the resource.
+	resourceAttributes []attribute.KeyValue
+	// now returns the observed timestamp of the records emitted without one.
+	now func() time.Time
+	// minSeverity is the minimum severity of the emitted records.
+	minSeverity logs.SeverityNumber
+	// attributes are the defaults added to the records of every Logger.
+	attributes []attribute.KeyValue
+	// logRecordPool reuses the emitted records.
+	logRecordPool bool
+	// logRecordHooks run on each emitted record before the processors.
+	logRecordHooks []func(context.Context, MutableLogRecord)
+	// spanContextFromContext returns the span context the records emitted
+	// without a trace context are correlated with.
+	spanContextFromContext func(context.Context) trace.SpanContext
+	// stackTraceSeverity is the minimum severity of the records whose stack
+	// trace is captured, UNSPECIFIED if none is.
+	stackTraceSeverity logs.SeverityNumber
 }
 
 // LoggerProviderOption configures a LoggerProvider.
@@ -49,7 +85,12 @@ func (fn loggerProviderOptionFunc) apply(cfg loggerProviderConfig) loggerProvide
 	return fn(cfg)
 }
 
-// WithLogRecordProcessor will configure processor to process logs
+// WithLogRecordProcessor will configure processor to process logs.
+//
+// Processors run in the order they are registered: records are passed to
+// each processor in turn, and processors are force flushed and shut down in
+// the same order. Register a filtering processor before a batching one for it
+// to see records first.
 func WithLogRecordProcessor(logsProcessor LogRecordProcessor) LoggerProviderOption {
 	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
 		cfg.processors = append(cfg.processors, logsProcessor)
@@ -57,6 +98,21 @@ func WithLogRecordProcessor(logsProcessor LogRecordProcessor) LoggerProviderOpti
 	})
 }
 
+// WithTenantRoute will configure processor to process the logs emitted with a
+// context carrying the tenant routing key (see ContextWithTenant). Records
+// routed to a tenant are only sent to the processors registered for that
+// tenant, records without a key or with an unmatched key are sent to the
+// processors registered with WithLogRecordProcessor.
+func WithTenantRoute(key string, processor LogRecordProcessor) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		if cfg.tenantRoutes == nil {
+			cfg.tenantRoutes = make(map[string][]LogRecordProcessor)
+		}
+		cfg.tenantRoutes[key] = append(cfg.tenantRoutes[key], processor)
+		return cfg
+	})
+}
+
 // WithSyncer registers the exporter with the LoggerProvider using a
 // SimpleLogRecordProcessor.
 //
@@ -75,6 +131,46 @@ func WithBatcher(e LogRecordExporter, opts ...BatchLogRecordProcessorOption) Log
 	return WithLogRecordProcessor(NewBatchLogRecordProcessor(e, opts...))
 }
 
+// WithMinSeverity will configure the minimum severity of the emitted logs.
+// Records with a lower severity number are dropped before reaching any
+// processor, and Logger.Enabled returns false for them. Records without a
+// severity number are always emitted.
+//
+// If the OTEL_LOGS_MIN_SEVERITY environment variable is set to one of trace,
+// debug, info, warn, error or fatal, it takes precedence over this option.
+func WithMinSeverity(severity logs.SeverityNumber) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.minSeverity = severity
+		return cfg
+	})
+}
+
+// WithDefaultAttributesFromEnv will configure the attributes added to every
+// emitted record from the OTEL_LOG_RECORD_ATTRIBUTES environment variable, a
+// comma-separated list of key=value pairs (i.e. env=prod,region=us) with
+// URL-encoded keys and values. Unlike OTEL_RESOURCE_ATTRIBUTES, the attributes
+// are set on each record rather than on the resource.
+//
+// On conflict, the attributes set with logs.WithAttributes and the ones of the
+// record win. Invalid pairs are ignored.
+func WithDefaultAttributesFromEnv() LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		if value, ok := env.LogRecordAttributes(); ok {
+			cfg.attributes = parseAttributes(value)
+		}
+		return cfg
+	})
+}
+
+// withClock configures the time source used to stamp the observed timestamp
+// of the records emitted without one. It is meant for tests.
+func withClock(now func() time.Time) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.now = now
+		return cfg
+	})
+}
+
 // WithResource will configure OTLP logger with common resource attributes.
 //
 // Parameters:
@@ -86,6 +182,91 @@ func WithResource(r *resource.Resource) LoggerProviderOption {
 		if err != nil {
 			otel.Handle(err)
 		}
+		name, ok := r.Set().Value(semconv.ServiceNameKey)
+		// The unknown_service placeholder of the default resource is unset.
+		cfg.hasServiceName = ok && !strings.HasPrefix(name.AsString(), "unknown_service")
+		return cfg
+	})
+}
+
+// WithServiceName will configure the service.name attribute of the resource.
+// It takes precedence over the OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+// environment variables, but not over a service.name set in the resource
+// passed to WithResource, unless it is the unknown_service placeholder of
+// resource.Default.
+func WithServiceName(name string) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.serviceName = name
+		return cfg
+	})
+}
+
+// WithResourceAttributes will configure attributes merged into the resource,
+// without replacing the resource detected from the environment or the one
+// passed to WithResource. On conflict, the attributes take precedence over the
+// ones of the resource, including service.name set with WithServiceName.
+// Multiple calls accumulate, the last value of a duplicate key wins.
+func WithResourceAttributes(attrs ...attribute.KeyValue) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.resourceAttributes = append(cfg.resourceAttributes, attrs...)
+		return cfg
+	})
+}
+
+// WithLogRecordPool reuses the records emitted by the Loggers of the
+// LoggerProvider instead of allocating one, with its attributes, per Emit,
+// which lowers the allocations at high emit rates.
+//
+// A pooled record is reused once the processors are done with it. The
+// processors and exporters of this module keep the records as long as they
+// need them, e.g. until a batch is exported. Other processors must not use the
+// records passed to OnEmit after it returns, nor other exporters the records
+// passed to Export after it returns: they must copy the fields they keep.
+func WithLogRecordPool() LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.logRecordPool = true
+		return cfg
+	})
+}
+
+// WithLogRecordHook will configure a function run on each emitted record
+// before it reaches the processors, e.g. to add attributes derived at runtime
+// or to rewrite the body. The context is the one the record was emitted with.
+// Multiple hooks run in the order they are registered.
+//
+// The hooks run synchronously in Emit, they must be fast and must not keep
+// the record.
+func WithLogRecordHook(hook func(ctx context.Context, record MutableLogRecord)) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.logRecordHooks = append(cfg.logRecordHooks, hook)
+		return cfg
+	})
+}
+
+// WithSpanContextExtractor will configure the function returning the span
+// context of the context a record is emitted with, used to correlate the
+// records with the current span, e.g. for a framework storing the trace
+// context under its own context keys. It replaces trace.SpanContextFromContext,
+// the default. An invalid span context, from a context without a span, leaves
+// the records emitted without a trace context uncorrelated.
+func WithSpanContextExtractor(extract func(context.Context) trace.SpanContext) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.spanContextFromContext = extract
+		return cfg
+	})
+}
+
+// WithStackTraceCapture will configure the stack trace of the caller of Emit
+// to be captured as the exception.stacktrace attribute of the records emitted
+// with a severity number of at least severity, e.g. logs.ERROR, unless they
+// have one. The frames of the SDK are skipped. Records without a severity
+// number are never captured.
+//
+// It is disabled by default, as capturing a stack trace is costly and takes
+// place synchronously in Emit. An UNSPECIFIED severity disables it.
+func WithStackTraceCapture(severity logs.SeverityNumber) LoggerProviderOption {
+	return loggerProviderOptionFunc(func(cfg loggerProviderConfig) loggerProviderConfig {
+		cfg.stackTraceSeverity = severity
 		return cfg
 	})
 }
@@ -99,17 +280,32 @@ type LoggerProvider struct {
 
 	logProcessors atomic.Pointer[logRecordProcessorStates]
 	isShutdown    atomic.Bool
+	// disabled is set when the SDK is disabled with OTEL_SDK_DISABLED.
+	disabled bool
+	// emitAfterShutdownOnce limits the report of dropped records emitted
+	// after shutdown to a single error.
+	emitAfterShutdownOnce sync.Once
 
 	// These fields are not protected by the lock mu. They are assumed to be
 	// immutable after creation of the LoggerProvider.
-	resource *resource.Resource
+	resource     *resource.Resource
+	tenantRoutes map[string]logRecordProcessorStates
+	now          func() time.Time
+	minSeverity  logs.SeverityNumber
+	attributes   []attribute.KeyValue
+
+	logRecordPool  bool
+	logRecordHooks []func(context.Context, MutableLogRecord)
+
+	spanContextFromContext func(context.Context) trace.SpanContext
+	stackTraceSeverity     logs.SeverityNumber
 }
 
 var _ logs.LoggerProvider = &LoggerProvider{}
 
 func (lp *LoggerProvider) Logger(name string, opts ...logs.LoggerOption) logs.Logger {
 
-	if lp.isShutdown.Load() {
+	if lp.disabled || lp.isShutdown.Load() {
 		return logs.NewNoopLoggerProvider().Logger(name, opts...)
 	}
 
@@ -136,9 +332,11 @@ func (lp *LoggerProvider) Logger(name string, opts ...logs.LoggerOption) logs.Lo
 
 		t, ok := lp.namedLogger[is]
 		if !ok {
+			attrs := c.Attributes()
 			t = &logger{
 				provider:             lp,
 				instrumentationScope: is,
+				attributes:           lp.loggerAttributes(attrs.ToSlice()),
 			}
 		}
 		return t, ok
@@ -157,6 +355,11 @@ func (lp *LoggerProvider) Logger(name string, opts ...logs.LoggerOption) logs.Lo
 
 var _ logs.LoggerProvider = &LoggerProvider{}
 
+// NewLoggerProvider returns a new LoggerProvider configured with opts.
+//
+// If the OTEL_SDK_DISABLED environment variable is set to true, the Loggers
+// of the returned provider emit nothing. The configured processors are still
+// shut down and flushed by the provider.
 func NewLoggerProvider(opts ...LoggerProviderOption) *LoggerProvider {
 	o := loggerProviderConfig{}
 
@@ -166,11 +369,22 @@ func NewLoggerProvider(opts ...LoggerProviderOption) *LoggerProvider {
 		o = opt.apply(o)
 	}
 
+	o = applyLoggerProviderEnvOverrides(o)
 	o = ensureValidLoggerProviderConfig(o)
 
 	lp := &LoggerProvider{
 		namedLogger: make(map[instrumentation.Scope]*logger),
 		resource:    o.resource,
+		now:         o.now,
+		minSeverity: o.minSeverity,
+		attributes:  o.attributes,
+		disabled:    env.SDKDisabled(),
+
+		logRecordPool:  o.logRecordPool,
+		logRecordHooks: o.logRecordHooks,
+
+		spanContextFromContext: o.spanContextFromContext,
+		stackTraceSeverity:     o.stackTraceSeverity,
 	}
 
 	global.Info("LoggerProvider created", "config", o)
@@ -181,15 +395,84 @@ func NewLoggerProvider(opts ...LoggerProviderOption) *LoggerProvider {
 	}
 	lp.logProcessors.Store(&lrpss)
 
+	if len(o.tenantRoutes) > 0 {
+		lp.tenantRoutes = make(map[string]logRecordProcessorStates, len(o.tenantRoutes))
+		for key, processors := range o.tenantRoutes {
+			for _, lrp := range processors {
+				lp.tenantRoutes[key] = append(lp.tenantRoutes[key], newLogsProcessorState(lrp))
+			}
+		}
+	}
+
 	return lp
 
 }
 
+// loggerAttributes returns the default attributes of the provider merged with
+// the ones of a Logger, the ones of the Logger winning on conflict.
+func (p *LoggerProvider) loggerAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(p.attributes) == 0 {
+		return attrs
+	}
+	merged := append(append([]attribute.KeyValue(nil), p.attributes...), attrs...)
+	// A set keeps the last value of duplicate keys.
+	set := attribute.NewSet(merged...)
+	return set.ToSlice()
+}
+
+// reportEmitAfterShutdown reports, only once, that records are emitted after
+// the provider was shut down.
+func (p *LoggerProvider) reportEmitAfterShutdown() {
+	p.emitAfterShutdownOnce.Do(func() {
+		otel.Handle(errEmitAfterShutdown)
+	})
+}
+
 func (p *LoggerProvider) getLogRecordProcessorStates() logRecordProcessorStates {
 	return *(p.logProcessors.Load())
 }
 
-func (p LoggerProvider) Shutdown(ctx context.Context) error {
+// routeLogRecordProcessorStates returns the processors of the tenant carried
+// by ctx, or the default processors if there is no matching tenant route.
+//
+// Each route returns its own slice, built once and never modified in place, so
+// two non-empty results share the same first element only if they are the
+// same route: sameLogRecordProcessorStates relies on it.
+func (p *LoggerProvider) routeLogRecordProcessorStates(ctx context.Context) logRecordProcessorStates {
+	if len(p.tenantRoutes) > 0 {
+		if key, ok := TenantFromContext(ctx); ok {
+			if lrpss, ok := p.tenantRoutes[key]; ok {
+				return lrpss
+			}
+		}
+	}
+	return p.getLogRecordProcessorStates()
+}
+
+// allLogRecordProcessorStates returns the default processors followed by the
+// tenant processors, sorted by tenant, each in registration order.
+func (p *LoggerProvider) allLogRecordProcessorStates() logRecordProcessorStates {
+	lrpss := p.getLogRecordProcessorStates()
+	if len(p.tenantRoutes) == 0 {
+		return lrpss
+	}
+	keys := make([]string, 0, len(p.tenantRoutes))
+	for key := range p.tenantRoutes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	all := append(logRecordProcessorStates{}, lrpss...)
+	for _, key := range keys {
+		all = append(all, p.tenantRoutes[key]...)
+	}
+	return all
+}
+
+// Shutdown shuts down all the registered log processors and returns the
+// joined errors of their shutdowns. It is idempotent and safe to call
+// concurrently: only the first call performs the shutdown, later calls return
+// nil. Once shut down, records emitted by the provided Loggers are dropped.
+func (p *LoggerProvider) Shutdown(ctx context.Context) error {
 	// This check prevents deadlocks in case of recursive shutdown.
 	if p.isShutdown.Load() {
 		return nil
@@ -202,35 +485,29 @@ func (p LoggerProvider) Shutdown(ctx context.Context) error {
 	}
 
 	var retErr error
-	for _, lrps := range p.getLogRecordProcessorStates() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	for _, lrps := range p.allLogRecordProcessorStates() {
+		if err := ctx.Err(); err != nil {
+			retErr = errors.Join(retErr, err)
+			break
 		}
 
 		var err error
 		lrps.state.Do(func() {
 			err = lrps.lp.Shutdown(ctx)
 		})
-		if err != nil {
-			if retErr == nil {
-				retErr = err
-			} else {
-				// Poor man's list of errors
-				retErr = fmt.Errorf("%v; %v", retErr, err)
-			}
-		}
+		retErr = errors.Join(retErr, err)
 	}
 	p.logProcessors.Store(&logRecordProcessorStates{})
 	return retErr
-
 }
 
 // ForceFlush immediately exports all logs that have not yet been exported for
 // all the registered log processors.
 func (p *LoggerProvider) ForceFlush(ctx context.Context) error {
-	lrpss := p.getLogRecordProcessorStates()
+	if p.isShutdown.Load() {
+		return nil
+	}
+	lrpss := p.allLogRecordProcessorStates()
 	if len(lrpss) == 0 {
 		return nil
 	}
@@ -249,6 +526,33 @@ func (p *LoggerProvider) ForceFlush(ctx context.Context) error {
 	return nil
 }
 
+// panicFlushTimeout bounds the force flush done by ForceFlushOnPanic.
+const panicFlushTimeout = 5 * time.Second
+
+// ForceFlushOnPanic force flushes all the registered log processors if a panic
+// is in progress, then panics again with the same value, so that the logs
+// emitted before a crash are exported. The flush is bounded to 5 seconds and
+// its error is reported to the otel error handler.
+//
+// It must be deferred directly, in the goroutine that may panic:
+//
+//	defer provider.ForceFlushOnPanic()
+//
+// A panic in another goroutine, or a call in a function deferred by that
+// goroutine, is not recovered and does not trigger the flush.
+func (p *LoggerProvider) ForceFlushOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), panicFlushTimeout)
+	defer cancel()
+	if err := p.ForceFlush(ctx); err != nil {
+		otel.Handle(err)
+	}
+	panic(r)
+}
+
 func applyLoggerProviderEnvConfigs(cfg loggerProviderConfig) loggerProviderConfig {
 	for _, opt := range loggerProviderOptionsFromEnv() {
 		cfg = opt.apply(cfg)
@@ -263,6 +567,76 @@ func loggerProviderOptionsFromEnv() []LoggerProviderOption {
 	return opts
 }
 
+// applyLoggerProviderEnvOverrides applies the environment variables taking
+// precedence over the options.
+func applyLoggerProviderEnvOverrides(cfg loggerProviderConfig) loggerProviderConfig {
+	if level, ok := env.LogsMinSeverity(); ok {
+		if severity, ok := severityFromLogLevel(level); ok {
+			cfg.minSeverity = severity
+		} else {
+			global.Warn("ignoring invalid log level", "key", env.LogsMinSeverityKey, "value", level)
+		}
+	}
+	return cfg
+}
+
+// parseAttributes parses a comma-separated list of URL-encoded key=value
+// pairs, ignoring the invalid ones.
+func parseAttributes(value string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, found := strings.Cut(pair, "=")
+		if !found {
+			global.Warn("ignoring invalid log record attribute, missing '='", "key", env.LogRecordAttributesKey, "input", pair)
+			continue
+		}
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			global.Warn("ignoring invalid log record attribute key", "key", env.LogRecordAttributesKey, "input", k, "error", err)
+			continue
+		}
+		val, err := url.QueryUnescape(v)
+		if err != nil {
+			global.Warn("ignoring invalid log record attribute value", "key", env.LogRecordAttributesKey, "input", v, "error", err)
+			continue
+		}
+		if key = strings.TrimSpace(key); key == "" {
+			global.Warn("ignoring log record attribute with an empty key", "key", env.LogRecordAttributesKey, "input", pair)
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, strings.TrimSpace(val)))
+	}
+	return attrs
+}
+
+// severityFromLogLevel returns the lowest severity number of a log level.
+func severityFromLogLevel(level string) (logs.SeverityNumber, bool) {
+	switch strings.ToLower(level) {
+	case "trace":
+		return logs.TRACE, true
+	case "debug":
+		return logs.DEBUG, true
+	case "info":
+		return logs.INFO, true
+	case "warn":
+		return logs.WARN, true
+	case "error":
+		return logs.ERROR, true
+	case "fatal":
+		return logs.FATAL, true
+	default:
+		return logs.UNSPECIFIED, false
+	}
+}
+
+// belowMinSeverity returns true if a record with the severity must be dropped.
+func (p *LoggerProvider) belowMinSeverity(severity logs.SeverityNumber) bool {
+	return severity != logs.UNSPECIFIED && severity < p.minSeverity
+}
+
 // ensureValidLoggerProviderConfig ensures that given LoggerProviderConfig is valid.
 func ensureValidLoggerProviderConfig(cfg loggerProviderConfig) loggerProviderConfig {
 
@@ -270,5 +644,30 @@ func ensureValidLoggerProviderConfig(cfg loggerProviderConfig) loggerProviderCon
 		cfg.resource = resource.Default()
 	}
 
+	if cfg.serviceName != "" && !cfg.hasServiceName {
+		r, err := resource.Merge(cfg.resource, resource.NewSchemaless(semconv.ServiceName(cfg.serviceName)))
+		if err != nil {
+			otel.Handle(err)
+		} else {
+			cfg.resource = r
+		}
+	}
+
+	if len(cfg.resourceAttributes) > 0 {
+		r, err := resource.Merge(cfg.resource, resource.NewSchemaless(cfg.resourceAttributes...))
+		if err != nil {
+			otel.Handle(err)
+		} else {
+			cfg.resource = r
+		}
+	}
+
+	if cfg.now == nil {
+		cfg.now = time.Now
+	}
+	if cfg.spanContextFromContext == nil {
+		cfg.spanContextFromContext = trace.SpanContextFromContext
+	}
+
 	return cfg
 }
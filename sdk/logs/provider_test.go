@@ -17,11 +17,21 @@ limitations under the License.
 package logs
 
 import (
+	"context"
+	"errors"
 	//	"github.com/metoro-io/opentelemetry-logs-go/exporters/otlp/otlplogs/otlplogshttp"
 	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -60,3 +70,589 @@ func TestLogsProvider(t *testing.T) {
 	batchOtlpLogger.Emit(logRecord)
 
 }
+
+type shutdownProcessor struct {
+	emitted   atomic.Int32
+	shutdowns atomic.Int32
+	err       error
+}
+
+func (p *shutdownProcessor) OnEmit(ReadableLogRecord) { p.emitted.Add(1) }
+func (p *shutdownProcessor) Shutdown(context.Context) error {
+	p.shutdowns.Add(1)
+	return p.err
+}
+func (p *shutdownProcessor) ForceFlush(context.Context) error { return nil }
+
+func TestLoggerProviderConcurrentShutdown(t *testing.T) {
+	errA := errors.New("processor a")
+	errB := errors.New("processor b")
+	pa := &shutdownProcessor{err: errA}
+	pb := &shutdownProcessor{err: errB}
+	lp := NewLoggerProvider(WithLogRecordProcessor(pa), WithLogRecordProcessor(pb))
+
+	const num = 20
+	errs := make([]error, num)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(num)
+	for i := 0; i < num; i++ {
+		go func(idx int) {
+			defer wg.Done()
+			<-start
+			errs[idx] = lp.Shutdown(context.Background())
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var joined []error
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	require.Len(t, joined, 1, "only one Shutdown call must perform the shutdown")
+	assert.ErrorIs(t, joined[0], errA)
+	assert.ErrorIs(t, joined[0], errB)
+	assert.Equal(t, int32(1), pa.shutdowns.Load())
+	assert.Equal(t, int32(1), pb.shutdowns.Load())
+
+	assert.NoError(t, lp.Shutdown(context.Background()))
+}
+
+func TestLoggerProviderEmitAfterShutdown(t *testing.T) {
+	p := &shutdownProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(p))
+	l := lp.Logger("test")
+
+	body := "body"
+	record := logs.NewLogRecord(logs.LogRecordConfig{Body: &body})
+	l.Emit(record)
+	require.NoError(t, lp.Shutdown(context.Background()))
+
+	assert.NotPanics(t, func() {
+		l.Emit(record)
+		lp.Logger("test").Emit(record)
+	})
+	assert.Equal(t, int32(1), p.emitted.Load())
+}
+
+func TestLoggerProviderEmitAfterShutdownReportedOnce(t *testing.T) {
+	var reported atomic.Int32
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		if errors.Is(err, errEmitAfterShutdown) {
+			reported.Add(1)
+		}
+	}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	exp := NewTestExporter()
+	lp := NewLoggerProvider(WithBatcher(exp))
+	l := lp.Logger("test")
+	require.NoError(t, lp.Shutdown(context.Background()))
+
+	body := "body"
+	record := logs.NewLogRecord(logs.LogRecordConfig{Body: &body})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NotPanics(t, func() { l.Emit(record) })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), reported.Load())
+	assert.Empty(t, exp.logs)
+}
+
+type blockingExporter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (e *blockingExporter) Export(ctx context.Context, _ []ReadableLogRecord) error {
+	e.once.Do(func() { close(e.started) })
+	<-e.release
+	return nil
+}
+
+func (e *blockingExporter) Shutdown(context.Context) error { return nil }
+
+func TestLoggerProviderShutdownDuringSlowExport(t *testing.T) {
+	exp := &blockingExporter{started: make(chan struct{}), release: make(chan struct{})}
+	lp := NewLoggerProvider(WithBatcher(exp, WithMaxExportBatchSize(1)))
+	t.Cleanup(func() { close(exp.release) })
+
+	body := "body"
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	<-exp.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := lp.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestLoggerProviderTenantRoute(t *testing.T) {
+	defaultExp := NewTestExporter()
+	tenantAExp := NewTestExporter()
+	tenantBExp := NewTestExporter()
+	lp := NewLoggerProvider(
+		WithSyncer(defaultExp),
+		WithTenantRoute("a", NewSimpleLogRecordProcessor(tenantAExp)),
+		WithTenantRoute("b", NewSimpleLogRecordProcessor(tenantBExp)),
+	)
+	t.Cleanup(func() { require.NoError(t, lp.Shutdown(context.Background())) })
+
+	l := lp.Logger("test")
+	emit := func(ctx context.Context, body string) {
+		l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body, Context: ctx}))
+	}
+	emit(ContextWithTenant(context.Background(), "a"), "tenant a")
+	emit(ContextWithTenant(context.Background(), "b"), "tenant b")
+	emit(ContextWithTenant(context.Background(), "unknown"), "unknown tenant")
+	emit(context.Background(), "no tenant")
+	emit(nil, "no context")
+
+	bodies := func(exp *testExporter) []string {
+		exp.mu.Lock()
+		defer exp.mu.Unlock()
+		var got []string
+		for _, r := range exp.logs {
+			got = append(got, (*r).Body().(string))
+		}
+		return got
+	}
+	assert.Equal(t, []string{"tenant a"}, bodies(tenantAExp))
+	assert.Equal(t, []string{"tenant b"}, bodies(tenantBExp))
+	assert.Equal(t, []string{"unknown tenant", "no tenant", "no context"}, bodies(defaultExp))
+}
+
+func TestLoggerProviderShutdownTenantRoutes(t *testing.T) {
+	p := &shutdownProcessor{}
+	lp := NewLoggerProvider(WithTenantRoute("a", p))
+	require.NoError(t, lp.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), p.shutdowns.Load())
+}
+
+func TestLoggerProviderSDKDisabled(t *testing.T) {
+	tests := []struct {
+		value    string
+		disabled bool
+	}{
+		{value: "", disabled: false},
+		{value: "false", disabled: false},
+		{value: "1", disabled: false},
+		{value: "true", disabled: true},
+		{value: "TRUE", disabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("OTEL_SDK_DISABLED", tt.value)
+
+			exp := NewTestExporter()
+			lp := NewLoggerProvider(WithSyncer(exp))
+			body := "body"
+			lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+			require.NoError(t, lp.ForceFlush(context.Background()))
+			require.NoError(t, lp.Shutdown(context.Background()))
+
+			if tt.disabled {
+				assert.Empty(t, exp.logs)
+			} else {
+				assert.Len(t, exp.logs, 1)
+			}
+		})
+	}
+}
+
+func TestLoggerProviderMinSeverity(t *testing.T) {
+	emit := func(lp *LoggerProvider, severities ...logs.SeverityNumber) {
+		l := lp.Logger("test")
+		for _, severity := range severities {
+			severity := severity
+			l.Emit(logs.NewLogRecord(logs.LogRecordConfig{SeverityNumber: &severity}))
+		}
+		l.Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+	}
+	severities := func(records []ReadableLogRecord) []logs.SeverityNumber {
+		var got []logs.SeverityNumber
+		for _, r := range records {
+			if r.SeverityNumber() == nil {
+				got = append(got, logs.UNSPECIFIED)
+				continue
+			}
+			got = append(got, *r.SeverityNumber())
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		env      string
+		opts     []LoggerProviderOption
+		want     []logs.SeverityNumber
+		enabled  logs.SeverityNumber
+		disabled logs.SeverityNumber
+	}{
+		{
+			name:     "default",
+			want:     []logs.SeverityNumber{logs.DEBUG, logs.INFO, logs.WARN, logs.ERROR, logs.UNSPECIFIED},
+			enabled:  logs.TRACE,
+			disabled: -1,
+		},
+		{
+			name:     "option",
+			opts:     []LoggerProviderOption{WithMinSeverity(logs.WARN)},
+			want:     []logs.SeverityNumber{logs.WARN, logs.ERROR, logs.UNSPECIFIED},
+			enabled:  logs.WARN,
+			disabled: logs.INFO4,
+		},
+		{
+			name:     "env",
+			env:      "info",
+			want:     []logs.SeverityNumber{logs.INFO, logs.WARN, logs.ERROR, logs.UNSPECIFIED},
+			enabled:  logs.INFO,
+			disabled: logs.DEBUG4,
+		},
+		{
+			name:     "env overrides option",
+			env:      "ERROR",
+			opts:     []LoggerProviderOption{WithMinSeverity(logs.DEBUG)},
+			want:     []logs.SeverityNumber{logs.ERROR, logs.UNSPECIFIED},
+			enabled:  logs.ERROR,
+			disabled: logs.WARN4,
+		},
+		{
+			name:     "invalid env ignored",
+			env:      "verbose",
+			opts:     []LoggerProviderOption{WithMinSeverity(logs.WARN)},
+			want:     []logs.SeverityNumber{logs.WARN, logs.ERROR, logs.UNSPECIFIED},
+			enabled:  logs.WARN,
+			disabled: logs.INFO,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_LOGS_MIN_SEVERITY", tt.env)
+			// The level of the internal logger does not filter the records.
+			t.Setenv("OTEL_LOG_LEVEL", "error")
+			next := &recordingProcessor{}
+			lp := NewLoggerProvider(append([]LoggerProviderOption{WithLogRecordProcessor(next)}, tt.opts...)...)
+
+			emit(lp, logs.DEBUG, logs.INFO, logs.WARN, logs.ERROR)
+			assert.Equal(t, tt.want, severities(next.got()))
+
+			l := lp.Logger("test").(logs.EnabledLogger)
+			assert.True(t, l.Enabled(context.Background(), tt.enabled))
+			assert.True(t, l.Enabled(context.Background(), logs.UNSPECIFIED))
+			if tt.disabled >= 0 {
+				assert.False(t, l.Enabled(context.Background(), tt.disabled))
+			}
+		})
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []attribute.KeyValue
+	}{
+		{
+			name:  "pairs",
+			value: "env=prod,region=us",
+			want:  []attribute.KeyValue{attribute.String("env", "prod"), attribute.String("region", "us")},
+		},
+		{
+			name:  "spaces and encoded values",
+			value: " env = prod , team=a%2Cb ,",
+			want:  []attribute.KeyValue{attribute.String("env", "prod"), attribute.String("team", "a,b")},
+		},
+		{
+			name:  "invalid pairs ignored",
+			value: "env=prod,region,=us,zone=%zz",
+			want:  []attribute.KeyValue{attribute.String("env", "prod")},
+		},
+		{
+			name:  "empty value",
+			value: "env=",
+			want:  []attribute.KeyValue{attribute.String("env", "")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseAttributes(tt.value))
+		})
+	}
+}
+
+func TestLoggerProviderDefaultAttributesFromEnv(t *testing.T) {
+	t.Setenv("OTEL_LOG_RECORD_ATTRIBUTES", "env=prod,region=us,team=core")
+
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithDefaultAttributesFromEnv(), WithLogRecordProcessor(next))
+	l := lp.Logger("test", logs.WithAttributes(attribute.String("team", "billing")))
+
+	attrs := []attribute.KeyValue{attribute.String("region", "eu")}
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Attributes: &attrs}))
+	lp.Logger("other").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+	got := next.got()
+	require.Len(t, got, 2)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("env", "prod"),
+		attribute.String("team", "billing"),
+		attribute.String("region", "eu"),
+	}, *got[0].Attributes(), "code attributes win on conflict")
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("env", "prod"),
+		attribute.String("region", "us"),
+		attribute.String("team", "core"),
+	}, *got[1].Attributes())
+}
+
+func TestLoggerProviderDefaultAttributesFromEnvUnset(t *testing.T) {
+	t.Setenv("OTEL_LOG_RECORD_ATTRIBUTES", "env=prod")
+
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(next))
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+	got := next.got()
+	require.Len(t, got, 1)
+	assert.Nil(t, got[0].Attributes(), "the variable is only read with WithDefaultAttributesFromEnv")
+}
+
+func TestLoggerProviderServiceName(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		opts []LoggerProviderOption
+		want string
+	}{
+		{name: "option", opts: []LoggerProviderOption{WithServiceName("billing")}, want: "billing"},
+		{
+			name: "env with resource",
+			env:  "from-env",
+			opts: []LoggerProviderOption{WithResource(resource.NewSchemaless(semconv.HostName("host")))},
+			want: "from-env",
+		},
+		{name: "option over env", env: "from-env", opts: []LoggerProviderOption{WithServiceName("billing")}, want: "billing"},
+		{
+			name: "resource over option",
+			opts: []LoggerProviderOption{
+				WithResource(resource.NewSchemaless(semconv.ServiceName("from-resource"))),
+				WithServiceName("billing"),
+			},
+			want: "from-resource",
+		},
+		{
+			name: "option with resource without name",
+			env:  "from-env",
+			opts: []LoggerProviderOption{
+				WithServiceName("billing"),
+				WithResource(resource.NewSchemaless(semconv.HostName("host"))),
+			},
+			want: "billing",
+		},
+		{
+			name: "option over unknown_service resource",
+			opts: []LoggerProviderOption{
+				WithResource(resource.Default()),
+				WithServiceName("billing"),
+			},
+			want: "billing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_SERVICE_NAME", tt.env)
+			next := &recordingProcessor{}
+			lp := NewLoggerProvider(append([]LoggerProviderOption{WithLogRecordProcessor(next)}, tt.opts...)...)
+			lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+			got := next.got()
+			require.Len(t, got, 1)
+			name, ok := got[0].Resource().Set().Value(semconv.ServiceNameKey)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, name.AsString())
+		})
+	}
+}
+
+func TestLoggerProviderResourceAttributes(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=dev,team=env")
+
+	resourceValues := func(lp *LoggerProvider, next *recordingProcessor) map[attribute.Key]string {
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+		got := next.got()
+		require.Len(t, got, 1)
+		values := make(map[attribute.Key]string)
+		for _, kv := range got[0].Resource().Attributes() {
+			values[kv.Key] = kv.Value.Emit()
+		}
+		return values
+	}
+
+	t.Run("merged with the resource", func(t *testing.T) {
+		next := &recordingProcessor{}
+		lp := NewLoggerProvider(
+			WithLogRecordProcessor(next),
+			WithResourceAttributes(attribute.String("region", "us"), attribute.String("team", "billing")),
+			WithResource(resource.NewSchemaless(semconv.ServiceName("svc"), attribute.String("region", "eu"), attribute.String("host", "a"))),
+		)
+		values := resourceValues(lp, next)
+		assert.Equal(t, "dev", values["deployment.environment"], "environment attributes are kept")
+		assert.Equal(t, "svc", values["service.name"])
+		assert.Equal(t, "a", values["host"])
+		assert.Equal(t, "us", values["region"], "attributes win over WithResource")
+		assert.Equal(t, "billing", values["team"], "attributes win over the environment")
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		next := &recordingProcessor{}
+		lp := NewLoggerProvider(
+			WithLogRecordProcessor(next),
+			WithResourceAttributes(attribute.String("region", "us"), attribute.String("region", "eu")),
+			WithResourceAttributes(attribute.String("zone", "a")),
+			WithResourceAttributes(attribute.String("zone", "b")),
+		)
+		values := resourceValues(lp, next)
+		assert.Equal(t, "eu", values["region"])
+		assert.Equal(t, "b", values["zone"], "the last value wins")
+	})
+}
+
+func TestLoggerProviderForceFlushOnPanic(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(NewBatchLogRecordProcessor(exp, WithBatchTimeout(time.Hour))))
+	t.Cleanup(func() { require.NoError(t, lp.Shutdown(context.Background())) })
+
+	body := "about to crash"
+	var exportedBeforePanic int
+	func() {
+		defer func() {
+			assert.Equal(t, "boom", recover(), "the panic goes on after the flush")
+			exportedBeforePanic = len(exp.exported())
+		}()
+		defer lp.ForceFlushOnPanic()
+
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+		panic("boom")
+	}()
+
+	assert.Equal(t, 1, exportedBeforePanic)
+}
+
+func TestLoggerProviderForceFlushOnPanicWithoutPanic(t *testing.T) {
+	exp := &batchRecordingExporter{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(NewBatchLogRecordProcessor(exp, WithBatchTimeout(time.Hour))))
+	t.Cleanup(func() { require.NoError(t, lp.Shutdown(context.Background())) })
+
+	body := "all good"
+	func() {
+		defer lp.ForceFlushOnPanic()
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	}()
+
+	assert.Empty(t, exp.exported(), "nothing is flushed without a panic")
+}
+
+// orderProcessor appends its name to a shared log on each call.
+type orderProcessor struct {
+	name string
+	mu   *sync.Mutex
+	log  *[]string
+}
+
+func (p orderProcessor) record(event string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*p.log = append(*p.log, event+" "+p.name)
+}
+
+func (p orderProcessor) OnEmit(ReadableLogRecord)         { p.record("emit") }
+func (p orderProcessor) ForceFlush(context.Context) error { p.record("flush"); return nil }
+func (p orderProcessor) Shutdown(context.Context) error   { p.record("shutdown"); return nil }
+
+func TestLoggerProviderProcessorOrder(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+	names := []string{"filter", "enrich", "batch", "audit"}
+	var opts []LoggerProviderOption
+	for _, name := range names {
+		opts = append(opts, WithLogRecordProcessor(orderProcessor{name: name, mu: &mu, log: &log}))
+	}
+	opts = append(opts,
+		WithTenantRoute("b", orderProcessor{name: "tenant b", mu: &mu, log: &log}),
+		WithTenantRoute("a", orderProcessor{name: "tenant a", mu: &mu, log: &log}),
+	)
+	lp := NewLoggerProvider(opts...)
+
+	body := "ordered"
+	for i := 0; i < 10; i++ {
+		lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body}))
+	}
+	require.NoError(t, lp.ForceFlush(context.Background()))
+	require.NoError(t, lp.Shutdown(context.Background()))
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		for _, name := range names {
+			want = append(want, "emit "+name)
+		}
+	}
+	for _, event := range []string{"flush", "shutdown"} {
+		for _, name := range append(names, "tenant a", "tenant b") {
+			want = append(want, event+" "+name)
+		}
+	}
+	assert.Equal(t, want, log)
+}
+
+type hookKey struct{}
+
+func TestLoggerProviderLogRecordHook(t *testing.T) {
+	var order []string
+	exporter := NewTestExporter()
+	lp := NewLoggerProvider(
+		WithLogRecordHook(func(ctx context.Context, record MutableLogRecord) {
+			order = append(order, "first")
+			record.AddAttributes(attribute.String("git.sha", "abc123"))
+			if pod, ok := ctx.Value(hookKey{}).(string); ok {
+				record.AddAttributes(attribute.String("k8s.pod.name", pod))
+			}
+		}),
+		WithLogRecordHook(func(_ context.Context, record MutableLogRecord) {
+			order = append(order, "second")
+			body, _ := record.Body().(string)
+			record.SetBody(strings.ToUpper(body))
+		}),
+		WithSyncer(exporter),
+	)
+
+	body := "payment accepted"
+	attrs := []attribute.KeyValue{attribute.String("component", "billing")}
+	ctx := context.WithValue(context.Background(), hookKey{}, "billing-7f9c")
+	lp.Logger("test").Emit(logs.NewLogRecord(logs.LogRecordConfig{Body: &body, Attributes: &attrs, Context: ctx}))
+
+	assert.Equal(t, []string{"first", "second"}, order)
+	require.Len(t, exporter.logs, 1)
+	got := *exporter.logs[0]
+	assert.Equal(t, "PAYMENT ACCEPTED", got.Body())
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("component", "billing"),
+		attribute.String("git.sha", "abc123"),
+		attribute.String("k8s.pod.name", "billing-7f9c"),
+	}, *got.Attributes())
+	assert.Len(t, attrs, 1, "the emitted attributes are not modified")
+}
@@ -0,0 +1,161 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitingProcessorOption configures a RateLimitingProcessor.
+type RateLimitingProcessorOption func(o *RateLimitingProcessorOptions)
+
+// RateLimitingProcessorOptions is configuration settings for a
+// RateLimitingProcessor.
+type RateLimitingProcessorOptions struct {
+	// Rate is the number of log records per second passed to the next
+	// processor. A Rate <= 0 disables the rate limiting.
+	// The default value of Rate is 0.
+	Rate int
+
+	// Burst is the maximum number of log records passed at once, above the
+	// Rate, before records are dropped.
+	// The default value of Burst is the Rate.
+	Burst int
+}
+
+// WithRate returns a RateLimitingProcessorOption that configures the number of
+// log records per second a RateLimitingProcessor passes to the next processor.
+func WithRate(perSecond int) RateLimitingProcessorOption {
+	return func(o *RateLimitingProcessorOptions) {
+		o.Rate = perSecond
+	}
+}
+
+// WithBurst returns a RateLimitingProcessorOption that configures the maximum
+// number of log records a RateLimitingProcessor passes at once.
+func WithBurst(burst int) RateLimitingProcessorOption {
+	return func(o *RateLimitingProcessorOptions) {
+		o.Burst = burst
+	}
+}
+
+// RateLimitingProcessor is a LogRecordProcessor that passes log records to
+// the next processor as long as they do not exceed the configured rate, using
+// a token bucket. The records exceeding the rate are dropped and counted.
+type RateLimitingProcessor struct {
+	next LogRecordProcessor
+	o    RateLimitingProcessorOptions
+
+	// now is the clock of the token bucket, replaced in tests.
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	dropped atomic.Uint64
+}
+
+var _ LogRecordProcessor = (*RateLimitingProcessor)(nil)
+
+// NewRateLimitingProcessor returns a new RateLimitingProcessor passing the log
+// records within the rate configured with the supplied options to next.
+func NewRateLimitingProcessor(next LogRecordProcessor, options ...RateLimitingProcessorOption) *RateLimitingProcessor {
+	var o RateLimitingProcessorOptions
+	for _, opt := range options {
+		opt(&o)
+	}
+	if o.Burst <= 0 {
+		o.Burst = o.Rate
+	}
+	return &RateLimitingProcessor{
+		next:   next,
+		o:      o,
+		now:    time.Now,
+		tokens: float64(o.Burst),
+	}
+}
+
+// OnEmit passes the log record to the next processor if it is within the
+// rate, otherwise drops it.
+func (lrp *RateLimitingProcessor) OnEmit(rol ReadableLogRecord) {
+	if !lrp.allow() {
+		lrp.dropped.Add(1)
+		return
+	}
+	lrp.next.OnEmit(rol)
+}
+
+// allow takes a token from the bucket if one is available.
+func (lrp *RateLimitingProcessor) allow() bool {
+	if lrp.o.Rate <= 0 {
+		return true
+	}
+
+	lrp.mu.Lock()
+	defer lrp.mu.Unlock()
+
+	now := lrp.now()
+	if !lrp.last.IsZero() {
+		elapsed := now.Sub(lrp.last).Seconds()
+		if elapsed > 0 {
+			lrp.tokens += elapsed * float64(lrp.o.Rate)
+			if burst := float64(lrp.o.Burst); lrp.tokens > burst {
+				lrp.tokens = burst
+			}
+		}
+	}
+	lrp.last = now
+
+	if lrp.tokens < 1 {
+		return false
+	}
+	lrp.tokens--
+	return true
+}
+
+// Dropped returns the number of log records dropped because they exceeded
+// the rate.
+func (lrp *RateLimitingProcessor) Dropped() uint64 {
+	return lrp.dropped.Load()
+}
+
+// Shutdown shuts down the next processor.
+func (lrp *RateLimitingProcessor) Shutdown(ctx context.Context) error {
+	return lrp.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the next processor.
+func (lrp *RateLimitingProcessor) ForceFlush(ctx context.Context) error {
+	return lrp.next.ForceFlush(ctx)
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
+func (lrp *RateLimitingProcessor) MarshalLog() interface{} {
+	return struct {
+		Type               string
+		LogRecordProcessor LogRecordProcessor
+		Config             RateLimitingProcessorOptions
+	}{
+		Type:               "RateLimitingProcessor",
+		LogRecordProcessor: lrp.next,
+		Config:             lrp.o,
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestRateLimitingProcessor(next LogRecordProcessor, clock *fakeClock, options ...RateLimitingProcessorOption) *RateLimitingProcessor {
+	lrp := NewRateLimitingProcessor(next, options...)
+	lrp.now = clock.Now
+	return lrp
+}
+
+func TestRateLimitingProcessor(t *testing.T) {
+	next := &shutdownProcessor{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	lrp := newTestRateLimitingProcessor(next, clock, WithRate(10))
+
+	// 100 records in one second at a rate of 10/s: the initial burst of 10
+	// passes, then one record every 100ms.
+	for i := 0; i < 100; i++ {
+		lrp.OnEmit(&exportableLogRecord{})
+		clock.Advance(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(19), next.emitted.Load())
+	assert.Equal(t, uint64(81), lrp.Dropped())
+}
+
+func TestRateLimitingProcessorBurst(t *testing.T) {
+	next := &shutdownProcessor{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	lrp := newTestRateLimitingProcessor(next, clock, WithRate(1), WithBurst(5))
+
+	for i := 0; i < 10; i++ {
+		lrp.OnEmit(&exportableLogRecord{})
+	}
+	assert.Equal(t, int32(5), next.emitted.Load())
+	assert.Equal(t, uint64(5), lrp.Dropped())
+
+	// The bucket refills at the rate, never above the burst.
+	clock.Advance(time.Hour)
+	for i := 0; i < 10; i++ {
+		lrp.OnEmit(&exportableLogRecord{})
+	}
+	assert.Equal(t, int32(10), next.emitted.Load())
+	assert.Equal(t, uint64(10), lrp.Dropped())
+}
+
+func TestRateLimitingProcessorUnlimited(t *testing.T) {
+	next := &shutdownProcessor{}
+	lrp := NewRateLimitingProcessor(next)
+	for i := 0; i < 1000; i++ {
+		lrp.OnEmit(&exportableLogRecord{})
+	}
+	assert.Equal(t, int32(1000), next.emitted.Load())
+	assert.Zero(t, lrp.Dropped())
+
+	require.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), next.shutdowns.Load())
+}
@@ -0,0 +1,290 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"go.opentelemetry.io/otel"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRemoteSamplerRefreshInterval is the default interval between two
+	// fetches of the sampling rules of a RemoteSampler.
+	DefaultRemoteSamplerRefreshInterval = time.Minute
+	// maxRemoteSamplingRulesBytes bounds the size of a sampling rules response.
+	maxRemoteSamplingRulesBytes = 1 << 20
+)
+
+// RemoteSamplingRules are the sampling rules served by the endpoint of a
+// RemoteSampler, as a JSON object, e.g.
+//
+//	{"rules": [{"minSeverity": 17, "ratio": 1}], "defaultRatio": 0.1}
+//
+// keeps every error and 10% of the other records.
+type RemoteSamplingRules struct {
+	// Rules are evaluated in order, the first rule matching a record decides
+	// whether it is kept.
+	Rules []RemoteSamplingRule `json:"rules"`
+	// DefaultRatio is the fraction of the records matching no rule that are
+	// kept.
+	DefaultRatio float64 `json:"defaultRatio"`
+}
+
+// RemoteSamplingRule is a rule of RemoteSamplingRules.
+type RemoteSamplingRule struct {
+	// MinSeverity is the lowest severity number of the records matching the
+	// rule.
+	MinSeverity logs.SeverityNumber `json:"minSeverity"`
+	// Ratio is the fraction of the records matching the rule that are kept,
+	// see RatioSampler.
+	Ratio float64 `json:"ratio"`
+}
+
+// errInvalidSamplingRatio is returned for a ratio outside of [0, 1].
+var errInvalidSamplingRatio = errors.New("sampling ratio must be between 0 and 1")
+
+func (r RemoteSamplingRules) validate() error {
+	if r.DefaultRatio < 0 || r.DefaultRatio > 1 {
+		return fmt.Errorf("default ratio %v: %w", r.DefaultRatio, errInvalidSamplingRatio)
+	}
+	for i, rule := range r.Rules {
+		if rule.Ratio < 0 || rule.Ratio > 1 {
+			return fmt.Errorf("rule %d ratio %v: %w", i, rule.Ratio, errInvalidSamplingRatio)
+		}
+	}
+	return nil
+}
+
+type remoteSamplingRule struct {
+	min     logs.SeverityNumber
+	sampler Sampler
+}
+
+// rulesSampler is the Sampler compiled from RemoteSamplingRules.
+type rulesSampler struct {
+	rules []remoteSamplingRule
+	def   Sampler
+}
+
+func newRulesSampler(r RemoteSamplingRules) *rulesSampler {
+	s := &rulesSampler{def: RatioSampler(r.DefaultRatio)}
+	for _, rule := range r.Rules {
+		s.rules = append(s.rules, remoteSamplingRule{min: rule.MinSeverity, sampler: RatioSampler(rule.Ratio)})
+	}
+	return s
+}
+
+func (s *rulesSampler) ShouldSample(record ReadableLogRecord) bool {
+	severity := logs.UNSPECIFIED
+	if sn := record.SeverityNumber(); sn != nil {
+		severity = *sn
+	}
+	for _, rule := range s.rules {
+		if severity >= rule.min {
+			return rule.sampler.ShouldSample(record)
+		}
+	}
+	return s.def.ShouldSample(record)
+}
+
+// RemoteSamplerOption configures a RemoteSampler.
+type RemoteSamplerOption func(o *RemoteSamplerOptions)
+
+// RemoteSamplerOptions is configuration settings for a RemoteSampler.
+type RemoteSamplerOptions struct {
+	// RefreshInterval is the interval between two fetches of the sampling
+	// rules.
+	// The default value of RefreshInterval is
+	// DefaultRemoteSamplerRefreshInterval.
+	RefreshInterval time.Duration
+
+	// HTTPClient is the client fetching the sampling rules.
+	// The default value of HTTPClient is a client with a 10 seconds timeout.
+	HTTPClient *http.Client
+}
+
+// WithRemoteSamplerRefreshInterval returns a RemoteSamplerOption that
+// configures the interval between two fetches of the sampling rules.
+func WithRemoteSamplerRefreshInterval(interval time.Duration) RemoteSamplerOption {
+	return func(o *RemoteSamplerOptions) {
+		o.RefreshInterval = interval
+	}
+}
+
+// WithRemoteSamplerHTTPClient returns a RemoteSamplerOption that configures
+// the HTTP client fetching the sampling rules, e.g. to authenticate to the
+// decision service.
+func WithRemoteSamplerHTTPClient(client *http.Client) RemoteSamplerOption {
+	return func(o *RemoteSamplerOptions) {
+		o.HTTPClient = client
+	}
+}
+
+// RemoteSampler is a Sampler whose decisions follow the RemoteSamplingRules
+// served by an external decision service, e.g. a sidecar, like the remote
+// sampling of traces. The rules are fetched from the endpoint periodically
+// and cached in between, ShouldSample never waits for the service.
+//
+// Until the first fetch and while the service is unavailable or serves
+// invalid rules, the records are sampled by the fallback Sampler. The errors
+// are reported to the OpenTelemetry error handler.
+type RemoteSampler struct {
+	endpoint string
+	fallback Sampler
+	o        RemoteSamplerOptions
+
+	// sampler is the Sampler compiled from the last rules fetched, nil while
+	// the fallback is used.
+	sampler atomic.Pointer[rulesSampler]
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+var _ Sampler = (*RemoteSampler)(nil)
+
+// NewRemoteSampler returns a new RemoteSampler fetching the sampling rules
+// from the endpoint URL with a GET request, and sampling the records with
+// fallback while the rules are unavailable. If fallback is nil, AlwaysSample
+// is used.
+//
+// The rules are first fetched in the background, Shutdown must be called to
+// stop the refreshes.
+func NewRemoteSampler(endpoint string, fallback Sampler, options ...RemoteSamplerOption) *RemoteSampler {
+	o := RemoteSamplerOptions{RefreshInterval: DefaultRemoteSamplerRefreshInterval}
+	for _, opt := range options {
+		opt(&o)
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = DefaultRemoteSamplerRefreshInterval
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if fallback == nil {
+		fallback = AlwaysSample()
+	}
+	s := &RemoteSampler{
+		endpoint: endpoint,
+		fallback: fallback,
+		o:        o,
+		stopCh:   make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.refreshLoop()
+	return s
+}
+
+// ShouldSample returns the decision of the cached sampling rules, or of the
+// fallback Sampler if they are unavailable.
+func (s *RemoteSampler) ShouldSample(record ReadableLogRecord) bool {
+	if sampler := s.sampler.Load(); sampler != nil {
+		return sampler.ShouldSample(record)
+	}
+	return s.fallback.ShouldSample(record)
+}
+
+func (s *RemoteSampler) refreshLoop() {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.o.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			otel.Handle(err)
+		}
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the sampling rules from the endpoint now. If they cannot
+// be fetched, the fallback Sampler is used until the next successful refresh
+// and the error is returned. If ctx is done before they are fetched, the
+// cached rules are kept.
+func (s *RemoteSampler) Refresh(ctx context.Context) error {
+	rules, err := s.fetch(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.sampler.Store(nil)
+		}
+		return fmt.Errorf("remote sampler: %w", err)
+	}
+	s.sampler.Store(newRulesSampler(rules))
+	return nil
+}
+
+func (s *RemoteSampler) fetch(ctx context.Context) (RemoteSamplingRules, error) {
+	var rules RemoteSamplingRules
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint, nil)
+	if err != nil {
+		return rules, err
+	}
+	resp, err := s.o.HTTPClient.Do(req)
+	if err != nil {
+		return rules, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return rules, fmt.Errorf("fetch sampling rules: %s", resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteSamplingRulesBytes)).Decode(&rules); err != nil {
+		return rules, fmt.Errorf("decode sampling rules: %w", err)
+	}
+	return rules, rules.validate()
+}
+
+// Shutdown stops the refreshes of the sampling rules. The cached rules are
+// still used by ShouldSample.
+func (s *RemoteSampler) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ruleServer is a stub decision service serving the rules it is set.
+type ruleServer struct {
+	mu     sync.Mutex
+	status int
+	rules  string
+	hits   int
+}
+
+func (s *ruleServer) set(status int, rules string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.rules = status, rules
+}
+
+func (s *ruleServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits++
+	w.WriteHeader(s.status)
+	_, _ = w.Write([]byte(s.rules))
+}
+
+func (s *ruleServer) gotHits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+func runRuleServer(t *testing.T, status int, rules string) (*ruleServer, string) {
+	rs := &ruleServer{status: status, rules: rules}
+	server := httptest.NewServer(rs)
+	t.Cleanup(server.Close)
+	return rs, server.URL
+}
+
+// newTestRemoteSampler returns a RemoteSampler falling back to
+// SeverityThreshold(logs.WARN), once its first fetch of the rules is done.
+func newTestRemoteSampler(t *testing.T, rs *ruleServer, endpoint string) *RemoteSampler {
+	// Ignore the errors of the background refreshes.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	s := NewRemoteSampler(endpoint, SeverityThreshold(logs.WARN), WithRemoteSamplerRefreshInterval(time.Hour))
+	t.Cleanup(func() { require.NoError(t, s.Shutdown(context.Background())) })
+	if rs != nil {
+		require.Eventually(t, func() bool { return s.sampler.Load() != nil }, 5*time.Second, time.Millisecond)
+	}
+	return s
+}
+
+func TestRemoteSamplerRefresh(t *testing.T) {
+	rs, endpoint := runRuleServer(t, http.StatusOK, `{"rules": [{"minSeverity": 17, "ratio": 1}], "defaultRatio": 0}`)
+	s := newTestRemoteSampler(t, rs, endpoint)
+
+	ctx := context.Background()
+	require.NoError(t, s.Refresh(ctx))
+	assert.True(t, s.ShouldSample(severityRecord(logs.ERROR)))
+	assert.False(t, s.ShouldSample(severityRecord(logs.WARN)))
+	assert.False(t, s.ShouldSample(&exportableLogRecord{}))
+
+	rs.set(http.StatusOK, `{"rules": [{"minSeverity": 17, "ratio": 0}], "defaultRatio": 1}`)
+	assert.True(t, s.ShouldSample(severityRecord(logs.ERROR)), "decisions are cached until the refresh")
+	require.NoError(t, s.Refresh(ctx))
+	assert.False(t, s.ShouldSample(severityRecord(logs.ERROR)))
+	assert.True(t, s.ShouldSample(severityRecord(logs.DEBUG)))
+	assert.True(t, s.ShouldSample(&exportableLogRecord{}))
+}
+
+func TestRemoteSamplerFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		rules  string
+	}{
+		{name: "unavailable", status: http.StatusServiceUnavailable},
+		{name: "malformed", status: http.StatusOK, rules: `{"rules": [`},
+		{name: "invalid ratio", status: http.StatusOK, rules: `{"defaultRatio": 2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, endpoint := runRuleServer(t, http.StatusOK, `{"defaultRatio": 1}`)
+			s := newTestRemoteSampler(t, rs, endpoint)
+
+			ctx := context.Background()
+			require.NoError(t, s.Refresh(ctx))
+			assert.True(t, s.ShouldSample(severityRecord(logs.DEBUG)))
+
+			rs.set(tt.status, tt.rules)
+			assert.Error(t, s.Refresh(ctx))
+			// The records are sampled by the fallback SeverityThreshold.
+			assert.False(t, s.ShouldSample(severityRecord(logs.DEBUG)))
+			assert.True(t, s.ShouldSample(severityRecord(logs.WARN)))
+
+			rs.set(http.StatusOK, `{"defaultRatio": 1}`)
+			require.NoError(t, s.Refresh(ctx))
+			assert.True(t, s.ShouldSample(severityRecord(logs.DEBUG)), "the rules are used again once available")
+		})
+	}
+}
+
+func TestRemoteSamplerUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	s := newTestRemoteSampler(t, nil, endpoint)
+	assert.Error(t, s.Refresh(context.Background()))
+	assert.False(t, s.ShouldSample(severityRecord(logs.DEBUG)))
+	assert.True(t, s.ShouldSample(severityRecord(logs.ERROR)))
+}
+
+func TestRemoteSamplerPeriodicRefresh(t *testing.T) {
+	rs, endpoint := runRuleServer(t, http.StatusOK, `{"defaultRatio": 1}`)
+	s := NewRemoteSampler(endpoint, nil, WithRemoteSamplerRefreshInterval(10*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return s.ShouldSample(severityRecord(logs.DEBUG))
+	}, 5*time.Second, time.Millisecond)
+	rs.set(http.StatusOK, `{"defaultRatio": 0}`)
+	assert.Eventually(t, func() bool {
+		return !s.ShouldSample(severityRecord(logs.DEBUG))
+	}, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	hits := rs.gotHits()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, hits, rs.gotHits(), "no refresh after Shutdown")
+}
+
+func TestRemoteSamplerShutdownDuringFetch(t *testing.T) {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+	t.Cleanup(func() { otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {})) })
+
+	// The first fetch succeeds, the next ones hang until they are cancelled.
+	var hits atomic.Int32
+	fetching := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			_, _ = w.Write([]byte(`{"defaultRatio": 1}`))
+			return
+		}
+		select {
+		case fetching <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewRemoteSampler(server.URL, SeverityThreshold(logs.WARN), WithRemoteSamplerRefreshInterval(10*time.Millisecond))
+	require.Eventually(t, func() bool { return s.sampler.Load() != nil }, 5*time.Second, time.Millisecond)
+
+	// The refresh cancelled by Shutdown keeps the cached rules.
+	<-fetching
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.True(t, s.ShouldSample(severityRecord(logs.DEBUG)), "the cached rules are still used after Shutdown")
+
+	// So does a refresh cancelled by its caller.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-fetching
+		cancel()
+	}()
+	assert.Error(t, s.Refresh(ctx))
+	assert.True(t, s.ShouldSample(severityRecord(logs.DEBUG)), "the cached rules are kept")
+}
@@ -0,0 +1,159 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"encoding/binary"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"math/rand/v2"
+)
+
+// Sampler decides whether a log record is passed to the next processor by a
+// sampling LogRecordProcessor (see NewSamplingProcessor).
+type Sampler interface {
+	// ShouldSample returns true if the record must be kept.
+	//
+	// It is called synchronously on every emitted record and must be safe
+	// to call concurrently.
+	ShouldSample(record ReadableLogRecord) bool
+}
+
+type alwaysSampler struct{}
+
+func (alwaysSampler) ShouldSample(ReadableLogRecord) bool { return true }
+
+// AlwaysSample returns a Sampler that keeps every log record.
+func AlwaysSample() Sampler {
+	return alwaysSampler{}
+}
+
+type severityThresholdSampler struct {
+	min logs.SeverityNumber
+}
+
+func (s severityThresholdSampler) ShouldSample(record ReadableLogRecord) bool {
+	severity := logs.UNSPECIFIED
+	if sn := record.SeverityNumber(); sn != nil {
+		severity = *sn
+	}
+	return severity >= s.min
+}
+
+// SeverityThreshold returns a Sampler that keeps the log records with a
+// severity number greater than or equal to min. Records without a severity
+// number are dropped unless min is logs.UNSPECIFIED.
+func SeverityThreshold(min logs.SeverityNumber) Sampler {
+	return severityThresholdSampler{min: min}
+}
+
+type ratioSampler struct {
+	fraction          float64
+	traceIDUpperBound uint64
+}
+
+func (s ratioSampler) ShouldSample(record ReadableLogRecord) bool {
+	if tid := record.TraceId(); tid != nil && tid.IsValid() {
+		x := binary.BigEndian.Uint64(tid[8:16]) >> 1
+		return x < s.traceIDUpperBound
+	}
+	return rand.Float64() < s.fraction
+}
+
+// RatioSampler returns a Sampler that keeps the given fraction of log records.
+// Fractions >= 1 keep every record, fractions <= 0 drop every record.
+//
+// When a record has a valid trace ID, the decision is derived from it the same
+// way the trace TraceIDRatioBased sampler does, so the logs of a sampled trace
+// are kept consistently. Records without a trace ID are sampled randomly.
+func RatioSampler(fraction float64) Sampler {
+	if fraction >= 1 {
+		return AlwaysSample()
+	}
+	if fraction <= 0 {
+		fraction = 0
+	}
+	return ratioSampler{
+		fraction:          fraction,
+		traceIDUpperBound: uint64(fraction * (1 << 63)),
+	}
+}
+
+type traceSampledSampler struct {
+	fallback Sampler
+}
+
+func (s traceSampledSampler) ShouldSample(record ReadableLogRecord) bool {
+	tid, sid, flags := record.TraceId(), record.SpanId(), record.TraceFlags()
+	if tid != nil && tid.IsValid() && sid != nil && sid.IsValid() && flags != nil {
+		return flags.IsSampled()
+	}
+	return s.fallback.ShouldSample(record)
+}
+
+// TraceSampled returns a Sampler that keeps a log record if and only if the
+// trace it was emitted in is sampled, so that the logs kept match the traces
+// kept by the tracer. The decision is read from the trace flags of the record,
+// which the Logger sets from the span context of the record's context.
+//
+// Records without a trace context are sampled by fallback, AlwaysSample if
+// nil. For example, TraceSampled(SeverityThreshold(logs.WARN)) keeps the logs
+// of sampled traces and the warnings emitted outside of any trace.
+func TraceSampled(fallback Sampler) Sampler {
+	if fallback == nil {
+		fallback = AlwaysSample()
+	}
+	return traceSampledSampler{fallback: fallback}
+}
+
+type anyOfSampler []Sampler
+
+func (s anyOfSampler) ShouldSample(record ReadableLogRecord) bool {
+	for _, sampler := range s {
+		if sampler.ShouldSample(record) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyOf returns a Sampler that keeps a log record if any of samplers keeps
+// it. Samplers are evaluated in order and evaluation stops at the first one
+// keeping the record.
+//
+// For example, AnyOf(SeverityThreshold(logs.ERROR), RatioSampler(0.1)) keeps
+// every error and 10% of the other records.
+func AnyOf(samplers ...Sampler) Sampler {
+	return anyOfSampler(samplers)
+}
+
+type allOfSampler []Sampler
+
+func (s allOfSampler) ShouldSample(record ReadableLogRecord) bool {
+	for _, sampler := range s {
+		if !sampler.ShouldSample(record) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllOf returns a Sampler that keeps a log record only if all of samplers
+// keep it. Samplers are evaluated in order and evaluation stops at the first
+// one dropping the record.
+func AllOf(samplers ...Sampler) Sampler {
+	return allOfSampler(samplers)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func severityRecord(severity logs.SeverityNumber) ReadableLogRecord {
+	return &exportableLogRecord{severityNumber: &severity}
+}
+
+func tracedRecord(traceID trace.TraceID) ReadableLogRecord {
+	return &exportableLogRecord{traceId: &traceID}
+}
+
+func TestAlwaysSample(t *testing.T) {
+	assert.True(t, AlwaysSample().ShouldSample(&exportableLogRecord{}))
+	assert.True(t, AlwaysSample().ShouldSample(severityRecord(logs.TRACE)))
+}
+
+func TestSeverityThreshold(t *testing.T) {
+	s := SeverityThreshold(logs.WARN)
+	assert.False(t, s.ShouldSample(&exportableLogRecord{}), "unspecified severity")
+	assert.False(t, s.ShouldSample(severityRecord(logs.DEBUG)))
+	assert.False(t, s.ShouldSample(severityRecord(logs.INFO4)))
+	assert.True(t, s.ShouldSample(severityRecord(logs.WARN)))
+	assert.True(t, s.ShouldSample(severityRecord(logs.FATAL)))
+
+	assert.True(t, SeverityThreshold(logs.UNSPECIFIED).ShouldSample(&exportableLogRecord{}))
+}
+
+func TestRatioSampler(t *testing.T) {
+	low := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1}
+	high := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	s := RatioSampler(0.5)
+	for i := 0; i < 10; i++ {
+		assert.True(t, s.ShouldSample(tracedRecord(low)), "decision must be consistent for a trace ID")
+		assert.False(t, s.ShouldSample(tracedRecord(high)), "decision must be consistent for a trace ID")
+	}
+
+	assert.True(t, RatioSampler(1).ShouldSample(tracedRecord(high)))
+	assert.True(t, RatioSampler(2).ShouldSample(&exportableLogRecord{}))
+	assert.False(t, RatioSampler(0).ShouldSample(tracedRecord(low)))
+	assert.False(t, RatioSampler(-1).ShouldSample(&exportableLogRecord{}))
+}
+
+func TestRatioSamplerWithoutTraceID(t *testing.T) {
+	const n = 10000
+	s := RatioSampler(0.1)
+	var sampled int
+	for i := 0; i < n; i++ {
+		if s.ShouldSample(severityRecord(logs.DEBUG)) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, n/10, sampled, n/20)
+}
+
+func TestTraceSampled(t *testing.T) {
+	traceID := trace.TraceID{1}
+	spanID := trace.SpanID{1}
+	sampled, unsampled := trace.FlagsSampled, trace.TraceFlags(0)
+	warn, debug := logs.WARN, logs.DEBUG
+
+	tests := []struct {
+		name   string
+		record ReadableLogRecord
+		want   bool
+	}{
+		{"sampled trace", &exportableLogRecord{traceId: &traceID, spanId: &spanID, traceFlags: &sampled, severityNumber: &debug}, true},
+		{"unsampled trace", &exportableLogRecord{traceId: &traceID, spanId: &spanID, traceFlags: &unsampled, severityNumber: &warn}, false},
+		{"no trace context kept by fallback", &exportableLogRecord{severityNumber: &warn}, true},
+		{"no trace context dropped by fallback", &exportableLogRecord{severityNumber: &debug}, false},
+		{"invalid trace context", &exportableLogRecord{traceId: &trace.TraceID{}, spanId: &trace.SpanID{}, traceFlags: &sampled, severityNumber: &debug}, false},
+	}
+	s := TraceSampled(SeverityThreshold(logs.WARN))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.ShouldSample(tt.record))
+		})
+	}
+
+	assert.True(t, TraceSampled(nil).ShouldSample(&exportableLogRecord{}), "records without trace context are kept by default")
+}
+
+func TestTraceSampledWithLogger(t *testing.T) {
+	next := &recordingProcessor{}
+	lp := NewLoggerProvider(WithLogRecordProcessor(NewSamplingProcessor(next, TraceSampled(nil))))
+	l := lp.Logger("test")
+
+	for _, flags := range []trace.TraceFlags{trace.FlagsSampled, 0} {
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, TraceFlags: flags})
+		ctx := trace.ContextWithSpanContext(context.Background(), sc)
+		l.Emit(logs.NewLogRecord(logs.LogRecordConfig{Context: ctx}))
+	}
+	l.Emit(logs.NewLogRecord(logs.LogRecordConfig{}))
+
+	got := next.got()
+	if assert.Len(t, got, 2) {
+		assert.True(t, got[0].TraceFlags().IsSampled())
+		assert.Nil(t, got[1].TraceId())
+	}
+}
+
+func TestCompositeSamplers(t *testing.T) {
+	keepErrors := AnyOf(SeverityThreshold(logs.ERROR), RatioSampler(0))
+	assert.True(t, keepErrors.ShouldSample(severityRecord(logs.ERROR)))
+	assert.False(t, keepErrors.ShouldSample(severityRecord(logs.DEBUG)))
+	assert.False(t, AnyOf().ShouldSample(severityRecord(logs.ERROR)))
+
+	debugOnly := AllOf(SeverityThreshold(logs.DEBUG), RatioSampler(1))
+	assert.True(t, debugOnly.ShouldSample(severityRecord(logs.DEBUG)))
+	assert.False(t, debugOnly.ShouldSample(severityRecord(logs.TRACE)))
+	assert.True(t, AllOf().ShouldSample(severityRecord(logs.TRACE)))
+}
+
+func TestSamplingProcessor(t *testing.T) {
+	next := &shutdownProcessor{}
+	lrp := NewSamplingProcessor(next, AnyOf(SeverityThreshold(logs.WARN), RatioSampler(0)))
+
+	lrp.OnEmit(severityRecord(logs.DEBUG))
+	lrp.OnEmit(severityRecord(logs.INFO))
+	lrp.OnEmit(severityRecord(logs.WARN))
+	lrp.OnEmit(severityRecord(logs.ERROR))
+	assert.Equal(t, int32(2), next.emitted.Load())
+
+	assert.NoError(t, lrp.ForceFlush(context.Background()))
+	assert.NoError(t, lrp.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), next.shutdowns.Load())
+}
+
+func TestSamplingProcessorNilSampler(t *testing.T) {
+	next := &shutdownProcessor{}
+	lrp := NewSamplingProcessor(next, nil)
+	lrp.OnEmit(severityRecord(logs.TRACE))
+	assert.Equal(t, int32(1), next.emitted.Load())
+}
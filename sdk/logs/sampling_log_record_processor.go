@@ -0,0 +1,86 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"context"
+	"github.com/metoro-io/opentelemetry-logs-go/logs"
+)
+
+type samplingLogRecordProcessor struct {
+	next    LogRecordProcessor
+	sampler Sampler
+}
+
+var _ LogRecordProcessor = (*samplingLogRecordProcessor)(nil)
+var _ FilterProcessor = (*samplingLogRecordProcessor)(nil)
+
+// NewSamplingProcessor returns a new LogRecordProcessor that passes to next
+// only the log records the sampler keeps.
+//
+// If sampler is nil, AlwaysSample is used.
+func NewSamplingProcessor(next LogRecordProcessor, sampler Sampler) LogRecordProcessor {
+	if sampler == nil {
+		sampler = AlwaysSample()
+	}
+	return &samplingLogRecordProcessor{
+		next:    next,
+		sampler: sampler,
+	}
+}
+
+// OnEmit passes the log record to the next processor if it is sampled.
+func (lrp *samplingLogRecordProcessor) OnEmit(rol ReadableLogRecord) {
+	if lrp.sampler.ShouldSample(rol) {
+		lrp.next.OnEmit(rol)
+	}
+}
+
+// Enabled returns false if the sampler is a SeverityThreshold dropping the
+// severity, otherwise it reports whether the next processor is enabled.
+func (lrp *samplingLogRecordProcessor) Enabled(ctx context.Context, severity logs.SeverityNumber) bool {
+	if s, ok := lrp.sampler.(severityThresholdSampler); ok && severity < s.min {
+		return false
+	}
+	if fp, ok := lrp.next.(FilterProcessor); ok {
+		return fp.Enabled(ctx, severity)
+	}
+	return true
+}
+
+// Shutdown shuts down the next processor.
+func (lrp *samplingLogRecordProcessor) Shutdown(ctx context.Context) error {
+	return lrp.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the next processor.
+func (lrp *samplingLogRecordProcessor) ForceFlush(ctx context.Context) error {
+	return lrp.next.ForceFlush(ctx)
+}
+
+// MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
+func (lrp *samplingLogRecordProcessor) MarshalLog() interface{} {
+	return struct {
+		Type               string
+		LogRecordProcessor LogRecordProcessor
+		Sampler            Sampler
+	}{
+		Type:               "SamplingLogRecordProcessor",
+		LogRecordProcessor: lrp.next,
+		Sampler:            lrp.sampler,
+	}
+}
@@ -37,7 +37,10 @@ func (lrp *simpleLogRecordProcessor) ForceFlush(ctx context.Context) error {
 	return nil
 }
 
-var _ LogRecordProcessor = (*simpleLogRecordProcessor)(nil)
+var (
+	_ LogRecordProcessor = (*simpleLogRecordProcessor)(nil)
+	_ BulkProcessor      = (*simpleLogRecordProcessor)(nil)
+)
 
 // NewSimpleLogRecordProcessor returns a new LogRecordProcessor that will synchronously
 // send completed logs to the exporter immediately.
@@ -66,6 +69,16 @@ func (lrp *simpleLogRecordProcessor) OnEmit(rol ReadableLogRecord) {
 	}
 }
 
+// OnEmitBatch exports all the records in a single call to the exporter.
+func (lrp *simpleLogRecordProcessor) OnEmitBatch(records []ReadableLogRecord) {
+	lrp.exporterMu.Lock()
+	defer lrp.exporterMu.Unlock()
+
+	if err := lrp.exporter.Export(context.Background(), records); err != nil {
+		otel.Handle(err)
+	}
+}
+
 // MarshalLog is the marshaling function used by the logging system to represent this LogRecord Processor.
 func (lrp *simpleLogRecordProcessor) MarshalLog() interface{} {
 	return struct {
@@ -0,0 +1,39 @@
+/*
+Copyright Agoda Services Co.,Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import "context"
+
+type tenantKeyType struct{}
+
+var tenantKey tenantKeyType
+
+// ContextWithTenant returns a copy of parent carrying the tenant routing key.
+// Log records emitted with this context (see logs.LogRecordConfig.Context)
+// are sent to the processors registered for key with WithTenantRoute.
+func ContextWithTenant(parent context.Context, key string) context.Context {
+	return context.WithValue(parent, tenantKey, key)
+}
+
+// TenantFromContext returns the tenant routing key carried by ctx, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	key, ok := ctx.Value(tenantKey).(string)
+	return key, ok
+}